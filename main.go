@@ -1,12 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
+	"fmt"
+	"io"
 	"log"
+	"maps"
 	"os"
+	"slices"
 	"time"
 
 	knowledge "github.com/CodeClarityCE/service-knowledge/src"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensecompat"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensematch"
 	"github.com/robfig/cron/v3"
 )
 
@@ -16,9 +23,17 @@ func main() {
 	var daemon = flag.Bool("daemon", false, "Run as daemon with cron scheduler")
 	var debug = flag.Bool("debug", false, "Enable debug logging for cronjobs")
 	var action = ""
+	var from = ""
+	var to = ""
+	var file = ""
+	var license = ""
 
 	// Bind flags
 	flag.StringVar(&action, "action", action, "Action to perform")
+	flag.StringVar(&from, "from", from, "First day (YYYY-MM-DD) for epss-backfill, defaults to 30 days ago")
+	flag.StringVar(&to, "to", to, "Last day (YYYY-MM-DD) for epss-backfill, defaults to today")
+	flag.StringVar(&file, "file", file, "License file for license-match, JSON object of dependency licenses for license-compatibility, defaults to stdin")
+	flag.StringVar(&license, "license", license, "Project license expression for license-compatibility")
 
 	// Parse flags
 	flag.Parse()
@@ -54,6 +69,99 @@ func main() {
 				log.Fatalf("Failed to update knowledge: %v", err)
 			}
 			log.Println("Knowledge update completed successfully")
+		case "epss-backfill":
+			log.Println("Running EPSS history backfill...")
+
+			var err error
+			start, end := time.Now().AddDate(0, 0, -30), time.Now()
+			if from != "" {
+				start, err = time.Parse(time.DateOnly, from)
+				if err != nil {
+					log.Fatalf("Invalid -from date: %v", err)
+				}
+			}
+			if to != "" {
+				end, err = time.Parse(time.DateOnly, to)
+				if err != nil {
+					log.Fatalf("Invalid -to date: %v", err)
+				}
+			}
+
+			// Create knowledge service for database connections
+			knowledgeService, err := CreateKnowledgeService()
+			if err != nil {
+				log.Fatalf("Failed to create knowledge service: %v", err)
+			}
+			defer knowledgeService.Close()
+
+			err = knowledge.BackfillEPSS(knowledgeService.DB.Knowledge, start, end)
+			if err != nil {
+				log.Fatalf("Failed to backfill EPSS history: %v", err)
+			}
+			log.Println("EPSS history backfill completed successfully")
+		case "license-match":
+			var text []byte
+			var err error
+			if file != "" {
+				text, err = os.ReadFile(file)
+			} else {
+				text, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				log.Fatalf("Failed to read the license text: %v", err)
+			}
+
+			// Create knowledge service for database connections
+			knowledgeService, err := CreateKnowledgeService()
+			if err != nil {
+				log.Fatalf("Failed to create knowledge service: %v", err)
+			}
+			defer knowledgeService.Close()
+
+			matches, err := knowledge.MatchLicense(knowledgeService.DB.Knowledge, string(text), 3)
+			if err != nil {
+				log.Fatalf("Failed to match the license: %v", err)
+			}
+			if len(matches) == 0 || matches[0].Confidence < licensematch.MinConfidence {
+				fmt.Println("NOASSERTION")
+			} else {
+				fmt.Printf("%s %.2f\n", matches[0].ID, matches[0].Confidence)
+			}
+			for _, match := range matches {
+				log.Printf("%s (%s): %.2f", match.ID, match.Name, match.Confidence)
+			}
+		case "license-compatibility":
+			var data []byte
+			var err error
+			if file != "" {
+				data, err = os.ReadFile(file)
+			} else {
+				data, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				log.Fatalf("Failed to read the dependency licenses: %v", err)
+			}
+
+			// {"name": "license expression"}
+			var licenses map[string]string
+			err = json.Unmarshal(data, &licenses)
+			if err != nil {
+				log.Fatalf("Invalid dependency licenses: %v", err)
+			}
+			dependencies := make([]licensecompat.Dependency, 0, len(licenses))
+			for _, name := range slices.Sorted(maps.Keys(licenses)) {
+				dependencies = append(dependencies, licensecompat.Dependency{Name: name, License: licenses[name]})
+			}
+
+			report, err := licensecompat.Evaluate(license, dependencies)
+			if err != nil {
+				log.Fatalf("Failed to evaluate license compatibility: %v", err)
+			}
+			output, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to marshal the report: %v", err)
+			}
+			fmt.Println(string(output))
 		default:
 			flag.Usage()
 			os.Exit(0)
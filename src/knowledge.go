@@ -8,14 +8,20 @@ import (
 	"os"
 	"time"
 
+	"github.com/CodeClarityCE/service-knowledge/src/mirrors/capec"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/cwe"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/epss"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/gcve"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/js"
+	"github.com/CodeClarityCE/service-knowledge/src/mirrors/kev"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/licenses"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/nvd"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/osv"
 	"github.com/CodeClarityCE/service-knowledge/src/mirrors/php_security"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/advisory"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/aliasgraph"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensematch"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
 	dbhelper "github.com/CodeClarityCE/utility-dbhelper/helper"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
@@ -42,6 +48,12 @@ func SetupForDaemon(knowledgeDB *bun.DB) error {
 	}
 
 	log.Printf("Successfully connected to knowledge database '%s'", dbhelper.Config.Database.Knowledge)
+
+	// Until the next update, normalize the licenses with the list mirrored by the previous one
+	err = licenses.LoadNormalizer(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+	}
 	return nil
 }
 
@@ -84,6 +96,12 @@ func setupDatabases(confirm bool, daemonMode bool) error {
 		return err
 	}
 
+	// Create the tables owned by this service
+	err = pgsql.CreateTables(db)
+	if err != nil {
+		return err
+	}
+
 	if !daemonMode {
 		// Only create test and shared databases in CLI mode, not in daemon mode
 		err = dbhelper.CreateDatabase(dbhelper.Config.Database.Knowledge+"_test", confirm)
@@ -130,13 +148,25 @@ func UpdateWithSetup() error {
 }
 
 func Update(knowledgeDB *bun.DB, configDB *bun.DB) error {
+	// Create the tables owned by this service
+	err := pgsql.CreateTables(knowledgeDB)
+	if err != nil {
+		return err
+	}
+
 	// Update licenses
-	err := licenses.Update(knowledgeDB)
+	err = licenses.Update(knowledgeDB)
 	if err != nil {
 		log.Printf("%v", err)
 		// return err
 	}
 
+	// Normalize the licenses of packages with the mirrored list, even if this update failed
+	err = licenses.LoadNormalizer(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+	}
+
 	// Update EPSS
 	err = epss.Update(knowledgeDB)
 	if err != nil {
@@ -144,6 +174,13 @@ func Update(knowledgeDB *bun.DB, configDB *bun.DB) error {
 		// return err
 	}
 
+	// Update CISA Known Exploited Vulnerabilities
+	err = kev.Update(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+		// return err
+	}
+
 	// Update vulnerabilities
 	err = osv.Update(knowledgeDB)
 	if err != nil {
@@ -157,12 +194,26 @@ func Update(knowledgeDB *bun.DB, configDB *bun.DB) error {
 		// return err
 	}
 
+	// Update CAPEC attack patterns and their links to CWE entries
+	err = capec.Update(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+		// return err
+	}
+
 	err = nvd.Update(knowledgeDB, configDB)
 	if err != nil {
 		log.Printf("%v", err)
 		// return err
 	}
 
+	// Update NVD CPE Dictionary and CPE Match Criteria
+	err = nvd.UpdateCPE(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+		// return err
+	}
+
 	// Update GCVE/vulnerability-lookup (primary vulnerability source)
 	err = gcve.Update(knowledgeDB, configDB)
 	if err != nil {
@@ -177,6 +228,20 @@ func Update(knowledgeDB *bun.DB, configDB *bun.DB) error {
 		// return err
 	}
 
+	// Group the advisories of every source by real-world vulnerability
+	err = updateVulnerabilities(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+		// return err
+	}
+
+	// Merge the advisories of every vulnerability
+	err = advisory.Refresh(knowledgeDB)
+	if err != nil {
+		log.Printf("%v", err)
+		// return err
+	}
+
 	// // Import packages
 	// err = js.ImportTop10000(knowledgeDB, configDB)
 	// if err != nil {
@@ -191,9 +256,73 @@ func Update(knowledgeDB *bun.DB, configDB *bun.DB) error {
 		// return err
 	}
 
+	// Report the package licenses that could not be normalized into SPDX expressions
+	reportUnresolvedLicenses(knowledgeDB)
+
 	return nil
 }
 
+// reportUnresolvedLicenses logs the number of packages whose license is not an SPDX expression,
+// and the most used of these licenses, so that the normalizer can learn them.
+func reportUnresolvedLicenses(knowledgeDB *bun.DB) {
+	for _, language := range []string{"javascript", "php"} {
+		total, unresolved, err := pgsql.CountUnresolvedLicenses(knowledgeDB, language)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		if unresolved == 0 {
+			continue
+		}
+		log.Printf("%d of %d %s packages have a license that is not an SPDX expression", unresolved, total, language)
+
+		licenses, err := pgsql.GetUnresolvedLicenses(knowledgeDB, language, 10)
+		if err != nil {
+			log.Printf("%v", err)
+			continue
+		}
+		for _, license := range licenses {
+			log.Printf("  %q: %d packages", license.License, license.Packages)
+		}
+	}
+}
+
+// updateVulnerabilities rebuilds the vulnerabilities from the alias graph of the advisories of every source.
+func updateVulnerabilities(knowledgeDB *bun.DB) error {
+	records, err := pgsql.GetAliasRecords(knowledgeDB)
+	if err != nil {
+		return err
+	}
+
+	groups := aliasgraph.Build(records)
+	log.Printf("Grouped %d advisories into %d vulnerabilities", len(records), len(groups))
+
+	return pgsql.ReplaceVulnerabilities(knowledgeDB, groups)
+}
+
+// BackfillEPSS loads the historical daily EPSS scores between from and to into the score history.
+func BackfillEPSS(knowledgeDB *bun.DB, from time.Time, to time.Time) error {
+	err := pgsql.CreateTables(knowledgeDB)
+	if err != nil {
+		return err
+	}
+
+	return epss.Backfill(knowledgeDB, from, to)
+}
+
+// MatchLicense returns the n SPDX licenses whose templates best match a license text, the best first.
+func MatchLicense(knowledgeDB *bun.DB, text string, n int) ([]licensematch.Match, error) {
+	templates, err := pgsql.GetLicenseTemplates(knowledgeDB)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no license templates, run the knowledge update first")
+	}
+
+	return licensematch.NewMatcher(templates).Candidates(text, n), nil
+}
+
 // updateDatabases handles database setup and calls Update with proper connections
 func updateDatabases() error {
 	host := os.Getenv("PG_DB_HOST")
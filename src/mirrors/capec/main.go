@@ -0,0 +1,173 @@
+// Package capec mirrors the MITRE CAPEC attack patterns and their links to CWE entries in the knowledge database.
+package capec
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+const defaultURL = "https://capec.mitre.org/data/xml/capec_latest.xml"
+
+// Catalog is the CAPEC XML catalog.
+type Catalog struct {
+	XMLName        xml.Name        `xml:"Attack_Pattern_Catalog"`
+	Version        string          `xml:"Version,attr"`
+	Date           string          `xml:"Date,attr"`
+	AttackPatterns []AttackPattern `xml:"Attack_Patterns>Attack_Pattern"`
+}
+
+type AttackPattern struct {
+	ID                    string     `xml:"ID,attr"`
+	Name                  string     `xml:"Name,attr"`
+	Abstraction           string     `xml:"Abstraction,attr"`
+	Status                string     `xml:"Status,attr"`
+	Description           structured `xml:"Description"`
+	ExtendedDescription   structured `xml:"Extended_Description"`
+	LikelihoodOfAttack    string     `xml:"Likelihood_Of_Attack"`
+	TypicalSeverity       string     `xml:"Typical_Severity"`
+	RelatedAttackPatterns []struct {
+		Nature  string `xml:"Nature,attr"`
+		CAPECID string `xml:"CAPEC_ID,attr"`
+	} `xml:"Related_Attack_Patterns>Related_Attack_Pattern"`
+	Prerequisites     []structured `xml:"Prerequisites>Prerequisite"`
+	Mitigations       []structured `xml:"Mitigations>Mitigation"`
+	RelatedWeaknesses []struct {
+		CWEID string `xml:"CWE_ID,attr"`
+	} `xml:"Related_Weaknesses>Related_Weakness"`
+}
+
+// structured is a CAPEC text field, either plain text or XHTML markup.
+type structured struct {
+	Inner string `xml:",innerxml"`
+}
+
+var (
+	markupPattern     = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// Text returns the field stripped of its markup, on a single line.
+func (s structured) Text() string {
+	text := markupPattern.ReplaceAllString(s.Inner, " ")
+	text = html.UnescapeString(text)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
+
+// Update downloads the CAPEC catalog and replaces the capec and cwe_capec tables with its content.
+// The catalog is read from the CAPEC_FILE path when set, otherwise from CAPEC_URL or the MITRE website.
+func Update(db *bun.DB) error {
+	log.Println("Start updating CAPEC attack patterns")
+
+	data, err := readCatalog()
+	if err != nil {
+		return err
+	}
+
+	entries, links, err := parseCatalog(data)
+	if err != nil {
+		return err
+	}
+
+	err = pgsql.UpdateCAPEC(db, entries, links)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("CAPEC attack patterns updated: %d entries, %d CWE links", len(entries), len(links))
+	return nil
+}
+
+func readCatalog() ([]byte, error) {
+	if path := os.Getenv("CAPEC_FILE"); path != "" {
+		return os.ReadFile(path)
+	}
+
+	url := os.Getenv("CAPEC_URL")
+	if url == "" {
+		url = defaultURL
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch CAPEC catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch CAPEC catalog: HTTP %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseCatalog converts the CAPEC XML catalog into attack patterns and their links to CWE entries.
+func parseCatalog(data []byte) ([]types.CAPECEntry, []types.CWECAPEC, error) {
+	var catalog Catalog
+	if err := xml.Unmarshal(data, &catalog); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CAPEC catalog: %w", err)
+	}
+
+	entries := make([]types.CAPECEntry, 0, len(catalog.AttackPatterns))
+	var links []types.CWECAPEC
+	seen := map[types.CWECAPEC]bool{}
+
+	for _, pattern := range catalog.AttackPatterns {
+		if pattern.ID == "" {
+			continue
+		}
+
+		entry := types.CAPECEntry{
+			CAPECId:               pattern.ID,
+			Name:                  pattern.Name,
+			Abstraction:           pattern.Abstraction,
+			Status:                pattern.Status,
+			Description:           pattern.Description.Text(),
+			ExtendedDescription:   pattern.ExtendedDescription.Text(),
+			LikelihoodOfAttack:    pattern.LikelihoodOfAttack,
+			TypicalSeverity:       pattern.TypicalSeverity,
+			Prerequisites:         []string{},
+			Mitigations:           []string{},
+			RelatedAttackPatterns: []types.CAPECRelation{},
+			CatalogVersion:        catalog.Version,
+		}
+		for _, prerequisite := range pattern.Prerequisites {
+			if text := prerequisite.Text(); text != "" {
+				entry.Prerequisites = append(entry.Prerequisites, text)
+			}
+		}
+		for _, mitigation := range pattern.Mitigations {
+			if text := mitigation.Text(); text != "" {
+				entry.Mitigations = append(entry.Mitigations, text)
+			}
+		}
+		for _, related := range pattern.RelatedAttackPatterns {
+			entry.RelatedAttackPatterns = append(entry.RelatedAttackPatterns, types.CAPECRelation{
+				Nature:  related.Nature,
+				CAPECId: related.CAPECID,
+			})
+		}
+		entries = append(entries, entry)
+
+		for _, weakness := range pattern.RelatedWeaknesses {
+			link := types.CWECAPEC{CWEId: weakness.CWEID, CAPECId: pattern.ID}
+			if link.CWEId == "" || seen[link] {
+				continue
+			}
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	return entries, links, nil
+}
@@ -0,0 +1,73 @@
+package capec
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/testhelper"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdate(t *testing.T) {
+	db, cleanup := testhelper.SetupKnowledgeTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	err := Update(db)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+}
+
+func TestParseCatalog(t *testing.T) {
+	entries, links, err := parseCatalog([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<Attack_Pattern_Catalog xmlns="http://capec.mitre.org/capec-3" xmlns:xhtml="http://www.w3.org/1999/xhtml" Name="CAPEC" Version="3.9" Date="2023-01-24">
+	<Attack_Patterns>
+		<Attack_Pattern ID="66" Name="SQL Injection" Abstraction="Standard" Status="Draft">
+			<Description>This attack exploits target software that constructs SQL statements
+				based on user input.</Description>
+			<Extended_Description><xhtml:p>Depending on the database, the attacker may &amp; can</xhtml:p><xhtml:p>read data.</xhtml:p></Extended_Description>
+			<Likelihood_Of_Attack>High</Likelihood_Of_Attack>
+			<Typical_Severity>High</Typical_Severity>
+			<Related_Attack_Patterns>
+				<Related_Attack_Pattern Nature="ChildOf" CAPEC_ID="248"/>
+			</Related_Attack_Patterns>
+			<Prerequisites>
+				<Prerequisite>SQL queries used by the application to store data must incorporate user data.</Prerequisite>
+			</Prerequisites>
+			<Mitigations>
+				<Mitigation>Use of parameterized queries.</Mitigation>
+				<Mitigation><xhtml:p></xhtml:p></Mitigation>
+			</Mitigations>
+			<Related_Weaknesses>
+				<Related_Weakness CWE_ID="89"/>
+				<Related_Weakness CWE_ID="1286"/>
+				<Related_Weakness CWE_ID="89"/>
+			</Related_Weaknesses>
+		</Attack_Pattern>
+		<Attack_Pattern ID="" Name="Broken"/>
+	</Attack_Patterns>
+</Attack_Pattern_Catalog>`))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	entry := entries[0]
+	assert.Equal(t, "66", entry.CAPECId)
+	assert.Equal(t, "This attack exploits target software that constructs SQL statements based on user input.", entry.Description)
+	assert.Equal(t, "Depending on the database, the attacker may & can read data.", entry.ExtendedDescription)
+	assert.Equal(t, "High", entry.LikelihoodOfAttack)
+	assert.Equal(t, "High", entry.TypicalSeverity)
+	assert.Equal(t, "3.9", entry.CatalogVersion)
+	assert.Equal(t, []string{"Use of parameterized queries."}, entry.Mitigations)
+	assert.Len(t, entry.Prerequisites, 1)
+	assert.Equal(t, []types.CAPECRelation{{Nature: "ChildOf", CAPECId: "248"}}, entry.RelatedAttackPatterns)
+
+	assert.Equal(t, []types.CWECAPEC{{CWEId: "89", CAPECId: "66"}, {CWEId: "1286", CAPECId: "66"}}, links)
+}
+
+func TestParseCatalogInvalid(t *testing.T) {
+	_, _, err := parseCatalog([]byte("not xml"))
+	assert.Error(t, err)
+}
@@ -6,7 +6,6 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"regexp"
 	"strings"
@@ -14,45 +13,57 @@ import (
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 )
 
-// Write a function that downloads an XML file from the CWE website
-func downloadFile(url string) (knowledge.CWEListImport, error) {
+// downloadCatalog downloads the zipped XML catalog from the CWE website and returns the XML document.
+func downloadCatalog(url string) ([]byte, error) {
 	// Get the data
 	resp, err := http.Get(url)
 	if err != nil {
-		log.Println(err)
-		return knowledge.CWEListImport{}, err
+		return nil, fmt.Errorf("failed to fetch CWE catalog: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch CWE catalog: HTTP %d", resp.StatusCode)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Println(err)
-		return knowledge.CWEListImport{}, err
+		return nil, fmt.Errorf("failed to read CWE catalog: %w", err)
 	}
 
 	zipReader, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
 	if err != nil {
-		log.Println(err)
-		return knowledge.CWEListImport{}, err
+		return nil, fmt.Errorf("failed to open CWE catalog archive: %w", err)
 	}
 
 	// There is only one file in the zip archive
-	zipFile := zipReader.File[0]
-	// fmt.Println("Reading file:", zipFile.Name)
-	unzippedFileBytes, err := readZipFile(zipFile)
+	if len(zipReader.File) == 0 {
+		return nil, fmt.Errorf("CWE catalog archive is empty")
+	}
+	unzippedFileBytes, err := readZipFile(zipReader.File[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract CWE catalog: %w", err)
+	}
+
+	return unzippedFileBytes, nil
+}
+
+// parseCatalog decodes the XML catalog, once with the shared import structure and once
+// for the parts of the catalog it doesn't hold.
+func parseCatalog(data []byte) (knowledge.CWEListImport, catalogImport, error) {
+	var res knowledge.CWEListImport
+	err := xml.Unmarshal(data, &res)
 	if err != nil {
-		log.Println(err)
-		return knowledge.CWEListImport{}, err
+		return res, catalogImport{}, fmt.Errorf("failed to parse CWE catalog: %w", err)
 	}
 
-	var result knowledge.CWEListImport
-	err = xml.Unmarshal(unzippedFileBytes, &result)
+	var extras catalogImport
+	err = xml.Unmarshal(data, &extras)
 	if err != nil {
-		log.Println(err)
-		return knowledge.CWEListImport{}, err
+		return res, extras, fmt.Errorf("failed to parse CWE catalog: %w", err)
 	}
-	return result, nil
 
+	return res, extras, nil
 }
 
 func cleanString(text string) string {
@@ -65,13 +76,8 @@ func cleanString(text string) string {
 	return res
 }
 
-func downloadCWEs() ([]knowledge.CWEEntry, error) {
-	res, err := downloadFile("https://cwe.mitre.org/data/xml/cwec_latest.xml.zip")
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-
+// parseCWEs converts the weaknesses of the XML catalog into CWE entries.
+func parseCWEs(res knowledge.CWEListImport) []knowledge.CWEEntry {
 	weaknessMap := map[string]knowledge.WeaknessCWE{}
 	categoryMap := map[string]knowledge.Category{}
 
@@ -190,7 +196,7 @@ func downloadCWEs() ([]knowledge.CWEEntry, error) {
 		for _, consequence := range weaknessData.CommonConsequences.Consequence {
 			cweEntry.CommonConsequences = append(cweEntry.CommonConsequences, knowledge.CommonConsequence{
 				Scope:      consequence.Scope,
-				Note:       consequence.Note,
+				Note:       cleanString(consequence.Note),
 				Impact:     consequence.Impact,
 				Likelihood: consequence.Likelihood,
 			})
@@ -320,7 +326,7 @@ func downloadCWEs() ([]knowledge.CWEEntry, error) {
 		result = append(result, cweEntry)
 	}
 
-	return result, nil
+	return result
 }
 
 func readZipFile(zf *zip.File) ([]byte, error) {
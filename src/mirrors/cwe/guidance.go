@@ -0,0 +1,219 @@
+package cwe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"html"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// demonstrativeExample is a Demonstrative_Example of the XML catalog. Its intro texts,
+// example codes and body texts are interleaved, so they're decoded in document order.
+type demonstrativeExample struct {
+	ID    string        `xml:"Demonstrative_Example_ID,attr"`
+	Parts []examplePart `xml:",any"`
+}
+
+type examplePart struct {
+	XMLName  xml.Name
+	Nature   string `xml:"Nature,attr"`
+	Language string `xml:"Language,attr"`
+	Inner    string `xml:",innerxml"`
+}
+
+// xmlList is an XHTML list of the descriptions of the XML catalog.
+type xmlList = struct {
+	Text string   `xml:",chardata"`
+	Li   []string `xml:"li"`
+}
+
+var (
+	markupPattern     = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// parseGuidance extracts the remediation guidance of the weaknesses of the XML catalog.
+// Weaknesses without mitigations, detection methods nor examples have no guidance.
+func parseGuidance(res knowledge.CWEListImport, extras catalogImport) []types.CWEGuidance {
+	examples := map[string][]types.CWEDemonstrativeExample{}
+	for _, weakness := range extras.Weaknesses {
+		for _, example := range weakness.DemonstrativeExamples {
+			if parsed := parseExample(example); len(parsed.Parts) > 0 {
+				examples[weakness.ID] = append(examples[weakness.ID], parsed)
+			}
+		}
+	}
+
+	var result []types.CWEGuidance
+	for _, weakness := range res.Weaknesses.Weaknesses {
+		guidance := types.CWEGuidance{
+			CWEId:                 weakness.ID,
+			Mitigations:           []types.CWEMitigation{},
+			DetectionMethods:      []types.CWEDetectionMethod{},
+			DemonstrativeExamples: examples[weakness.ID],
+		}
+		if guidance.DemonstrativeExamples == nil {
+			guidance.DemonstrativeExamples = []types.CWEDemonstrativeExample{}
+		}
+
+		for _, mitigation := range weakness.PotentialMitigations.Mitigation {
+			description := mitigation.Description
+			paragraphs := slices.Concat(description.P, description.Div.Div)
+			guidance.Mitigations = append(guidance.Mitigations, types.CWEMitigation{
+				Id:                 mitigation.MitigationID,
+				Phases:             mitigation.Phase,
+				Strategy:           cleanString(mitigation.Strategy),
+				Description:        joinDescription(description.Text+" "+description.Div.Text, paragraphs, description.Ul),
+				Effectiveness:      cleanString(mitigation.Effectiveness),
+				EffectivenessNotes: joinDescription(mitigation.EffectivenessNotes.Text, []string{mitigation.EffectivenessNotes.P}, nil),
+			})
+		}
+
+		for _, method := range weakness.DetectionMethods.DetectionMethod {
+			description := method.Description
+			paragraphs := slices.Concat(description.P, description.Div.Div)
+			lists := slices.Concat(description.Ul, description.Div.Ul)
+			guidance.DetectionMethods = append(guidance.DetectionMethods, types.CWEDetectionMethod{
+				Id:                 method.DetectionMethodID,
+				Method:             cleanString(method.Method),
+				Description:        joinDescription(description.Text+" "+description.Div.Text, paragraphs, lists),
+				Effectiveness:      cleanString(method.Effectiveness),
+				EffectivenessNotes: cleanString(method.EffectivenessNotes),
+			})
+		}
+
+		if len(guidance.Mitigations) == 0 && len(guidance.DetectionMethods) == 0 && len(guidance.DemonstrativeExamples) == 0 {
+			continue
+		}
+		result = append(result, guidance)
+	}
+
+	return result
+}
+
+// joinDescription flattens an XHTML description into a single line, list items prefixed by a dash.
+func joinDescription(text string, paragraphs []string, lists []xmlList) string {
+	var parts []string
+	if cleaned := cleanString(text); cleaned != "" {
+		parts = append(parts, cleaned)
+	}
+	for _, paragraph := range paragraphs {
+		if cleaned := cleanString(paragraph); cleaned != "" {
+			parts = append(parts, cleaned)
+		}
+	}
+	for _, list := range lists {
+		for _, item := range list.Li {
+			if cleaned := cleanString(item); cleaned != "" {
+				parts = append(parts, "- "+cleaned)
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// parseExample converts a demonstrative example into text and code parts, its references are left out.
+func parseExample(example demonstrativeExample) types.CWEDemonstrativeExample {
+	parsed := types.CWEDemonstrativeExample{Id: example.ID, Parts: []types.CWEExamplePart{}}
+	for _, part := range example.Parts {
+		switch part.XMLName.Local {
+		case "Intro_Text", "Body_Text":
+			content := cleanString(html.UnescapeString(markupPattern.ReplaceAllString(part.Inner, " ")))
+			if content != "" {
+				parsed.Parts = append(parsed.Parts, types.CWEExamplePart{Type: types.CWEExampleText, Content: content})
+			}
+		case "Example_Code":
+			content := cleanCode(part.Inner)
+			if content != "" {
+				parsed.Parts = append(parsed.Parts, types.CWEExamplePart{
+					Type:     types.CWEExampleCode,
+					Nature:   part.Nature,
+					Language: part.Language,
+					Content:  content,
+				})
+			}
+		}
+	}
+	return parsed
+}
+
+// cleanCode converts the XHTML of an example code into plain text. Line breaks and divs
+// end lines and divs indented with a margin indent their lines, the whitespace of the
+// XML document itself is dropped. Unlike cleanString it keeps the quotes of the code.
+func cleanCode(inner string) string {
+	decoder := xml.NewDecoder(strings.NewReader(inner))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var lines []string
+	var line bytes.Buffer
+	var indents []bool
+	level := 0
+	endLine := func() {
+		if text := strings.TrimRight(line.String(), " "); text != "" {
+			lines = append(lines, text)
+		}
+		line.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Malformed markup, fall back to stripping the tags
+			return strings.TrimSpace(html.UnescapeString(markupPattern.ReplaceAllString(inner, "")))
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "br":
+				endLine()
+			case "div":
+				endLine()
+				indented := false
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "style" && strings.Contains(attr.Value, "margin-left") {
+						indented = true
+					}
+				}
+				indents = append(indents, indented)
+				if indented {
+					level++
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "div" {
+				endLine()
+				if len(indents) > 0 {
+					if indents[len(indents)-1] {
+						level--
+					}
+					indents = indents[:len(indents)-1]
+				}
+			}
+		case xml.CharData:
+			text := whitespacePattern.ReplaceAllString(string(t), " ")
+			if line.Len() == 0 {
+				text = strings.TrimLeft(text, " ")
+				if text == "" {
+					continue
+				}
+				line.WriteString(strings.Repeat("    ", level))
+			}
+			line.WriteString(text)
+		}
+	}
+	endLine()
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,95 @@
+package cwe
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const guidanceCatalog = `<?xml version="1.0" encoding="UTF-8"?>
+<Weakness_Catalog xmlns="http://cwe.mitre.org/cwe-7" xmlns:xhtml="http://www.w3.org/1999/xhtml" Name="CWE" Version="4.15" Date="2024-07-16">
+	<Weaknesses>
+		<Weakness ID="79" Name="Cross-site Scripting" Abstraction="Base" Structure="Simple" Status="Stable">
+			<Detection_Methods>
+				<Detection_Method Detection_Method_ID="DM-1">
+					<Method>Automated Static Analysis</Method>
+					<Description>Use automated static analysis tools
+						that target this type of weakness.</Description>
+					<Effectiveness>Moderate</Effectiveness>
+				</Detection_Method>
+			</Detection_Methods>
+			<Potential_Mitigations>
+				<Mitigation Mitigation_ID="MIT-1.4">
+					<Phase>Architecture and Design</Phase>
+					<Strategy>Libraries or Frameworks</Strategy>
+					<Description>
+						<xhtml:p>Use a vetted library or framework.</xhtml:p>
+						<xhtml:ul>
+							<xhtml:li>Microsoft's Anti-XSS library</xhtml:li>
+							<xhtml:li>Apache Wicket</xhtml:li>
+						</xhtml:ul>
+					</Description>
+				</Mitigation>
+				<Mitigation>
+					<Phase>Implementation</Phase>
+					<Description>Use output encoding.</Description>
+					<Effectiveness>High</Effectiveness>
+				</Mitigation>
+			</Potential_Mitigations>
+			<Demonstrative_Examples>
+				<Demonstrative_Example Demonstrative_Example_ID="DX-1">
+					<Intro_Text>The following code displays a welcome message.</Intro_Text>
+					<Example_Code Nature="Bad" Language="PHP">
+						<xhtml:div>$username = $_GET['username'];<xhtml:br/>if ($username) {<xhtml:div style="margin-left:1em;">echo '&lt;div class="header"&gt;' . $username;</xhtml:div>}</xhtml:div>
+					</Example_Code>
+					<Body_Text>Because the parameter can be arbitrary, the "url" of the page could be modified.</Body_Text>
+					<References>
+						<Reference External_Reference_ID="REF-1"/>
+					</References>
+				</Demonstrative_Example>
+			</Demonstrative_Examples>
+		</Weakness>
+		<Weakness ID="80" Name="Basic XSS" Abstraction="Variant" Structure="Simple" Status="Incomplete"/>
+	</Weaknesses>
+</Weakness_Catalog>`
+
+func TestParseGuidance(t *testing.T) {
+	res, extras, err := parseCatalog([]byte(guidanceCatalog))
+	assert.NoError(t, err)
+
+	guidance := parseGuidance(res, extras)
+	// A weakness without guidance is left out
+	assert.Len(t, guidance, 1)
+	assert.Equal(t, "79", guidance[0].CWEId)
+
+	assert.Equal(t, []types.CWEMitigation{
+		{
+			Id:          "MIT-1.4",
+			Phases:      []string{"Architecture and Design"},
+			Strategy:    "Libraries or Frameworks",
+			Description: "Use a vetted library or framework. - Microsoft's Anti-XSS library - Apache Wicket",
+		},
+		{
+			Phases:        []string{"Implementation"},
+			Description:   "Use output encoding.",
+			Effectiveness: "High",
+		},
+	}, guidance[0].Mitigations)
+
+	assert.Equal(t, []types.CWEDetectionMethod{{
+		Id:            "DM-1",
+		Method:        "Automated Static Analysis",
+		Description:   "Use automated static analysis tools that target this type of weakness.",
+		Effectiveness: "Moderate",
+	}}, guidance[0].DetectionMethods)
+
+	assert.Equal(t, []types.CWEDemonstrativeExample{{
+		Id: "DX-1",
+		Parts: []types.CWEExamplePart{
+			{Type: types.CWEExampleText, Content: "The following code displays a welcome message."},
+			{Type: types.CWEExampleCode, Nature: "Bad", Language: "PHP", Content: "$username = $_GET['username'];\nif ($username) {\n    echo '<div class=\"header\">' . $username;\n}"},
+			{Type: types.CWEExampleText, Content: "Because the parameter can be arbitrary, the url of the page could be modified."},
+		},
+	}}, guidance[0].DemonstrativeExamples)
+}
@@ -2,23 +2,68 @@
 package cwe
 
 import (
+	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	"github.com/uptrace/bun"
 )
 
+const (
+	catalogURL = "https://cwe.mitre.org/data/xml/cwec_latest.xml.zip"
+	stateName  = "cwe"
+)
+
+// catalogVersion is the version of the last loaded catalog, stored as the mirror state cursor.
+type catalogVersion struct {
+	Version string `json:"version"`
+	Date    string `json:"date"`
+}
+
 // Update is a function that updates the CWEs in the knowledge database graph.
-// It downloads the CWEs from the graph, and then updates them.
+// It downloads the CWE catalog, and then updates the weaknesses, the categories, the
+// remediation guidance of the weaknesses and the views with their hierarchy.
 func Update(db *bun.DB) error {
 	log.Println("Start updating CWEs")
-	cwes, err := downloadCWEs()
+	data, err := downloadCatalog(catalogURL)
+	if err != nil {
+		return err
+	}
+	res, extras, err := parseCatalog(data)
+	if err != nil {
+		return err
+	}
+
+	cwes := append(parseCWEs(res), parseCategories(res)...)
+	err = pgsql.UpdateCWE(db, cwes, parseStatuses(res))
+	if err != nil {
+		return err
+	}
+
+	guidance := parseGuidance(res, extras)
+	err = pgsql.ReplaceCWEGuidance(db, guidance)
+	if err != nil {
+		return err
+	}
+	log.Printf("CWE guidance updated: %d weaknesses", len(guidance))
+
+	views, relationships := parseViews(res, extras)
+	err = pgsql.ReplaceCWEViews(db, views, relationships)
+	if err != nil {
+		return err
+	}
+	log.Printf("CWE views updated: %d views, %d relationships", len(views), len(relationships))
+
+	cursor, err := json.Marshal(catalogVersion{Version: extras.Version, Date: extras.Date})
 	if err != nil {
 		return err
 	}
-	err = pgsql.UpdateCWE(db, cwes)
+	err = pgsql.SetMirrorState(db, types.MirrorState{Name: stateName, LastSync: time.Now(), Cursor: string(cursor)})
 	if err != nil {
 		return err
 	}
+	log.Printf("CWE catalog %s of %s loaded: %d entries", extras.Version, extras.Date, len(cwes))
 	return nil
 }
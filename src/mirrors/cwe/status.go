@@ -0,0 +1,119 @@
+package cwe
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+const deprecatedStatus = "Deprecated"
+
+var cweReferencePattern = regexp.MustCompile(`CWE-(\d+)`)
+
+// parseCategories converts the categories of the XML catalog into standalone CWE entries.
+func parseCategories(res knowledge.CWEListImport) []knowledge.CWEEntry {
+	var result []knowledge.CWEEntry
+	for _, category := range res.Categories.Categories {
+		if category.ID == "" {
+			continue
+		}
+		result = append(result, knowledge.CWEEntry{
+			CWEId:                category.ID,
+			Name:                 category.Name,
+			Status:               category.Status,
+			Description:          cleanString(category.Summary),
+			RelatedWeaknesses:    []knowledge.RelatedWeakness{},
+			ModesOfIntroduction:  []knowledge.ModesOfIntroduction{},
+			CommonConsequences:   []knowledge.CommonConsequence{},
+			DetectionMethods:     []knowledge.DetectionMethod{},
+			PotentialMitigations: []knowledge.PotentialMitigation{},
+			TaxonomyMappings:     []knowledge.TaxonomyMapping{},
+			ObservedExamples:     []knowledge.ObservedExamples{},
+			AlternateTerms:       []knowledge.AlternateTerm{},
+			Categories:           []knowledge.CategorySimplified{},
+		})
+	}
+	return result
+}
+
+// parseStatuses returns the lifecycle of the weaknesses and categories of the XML catalog.
+// The replacements of a deprecated weakness are its related weaknesses, or else the CWEs
+// its description and notes point at, like "This entry has been deprecated because it
+// was a duplicate of CWE-103". Only weaknesses and categories of the catalog can replace
+// an entry, the views these texts mention can't.
+func parseStatuses(res knowledge.CWEListImport) []types.CWEStatus {
+	var statuses []types.CWEStatus
+
+	entries := map[string]bool{}
+	for _, weakness := range res.Weaknesses.Weaknesses {
+		entries[weakness.ID] = true
+	}
+	for _, category := range res.Categories.Categories {
+		entries[category.ID] = true
+	}
+
+	for _, weakness := range res.Weaknesses.Weaknesses {
+		status := types.CWEStatus{
+			CWEId:      weakness.ID,
+			EntryType:  types.CWEEntryWeakness,
+			Deprecated: weakness.Status == deprecatedStatus,
+			ReplacedBy: []string{},
+		}
+		if status.Deprecated {
+			for _, related := range weakness.RelatedWeaknesses.RelatedWeakness {
+				status.ReplacedBy = appendReplacement(status.ReplacedBy, weakness.ID, related.CWEID)
+			}
+			if len(status.ReplacedBy) == 0 {
+				texts := []string{weakness.Description, weakness.ExtendedDescription.Text}
+				texts = append(texts, weakness.ExtendedDescription.P...)
+				for _, note := range weakness.Notes.Note {
+					texts = append(texts, note.Text)
+					texts = append(texts, note.P...)
+				}
+				status.ReplacedBy = referencedCWEs(weakness.ID, texts, entries)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, category := range res.Categories.Categories {
+		status := types.CWEStatus{
+			CWEId:      category.ID,
+			EntryType:  types.CWEEntryCategory,
+			Deprecated: category.Status == deprecatedStatus,
+			ReplacedBy: []string{},
+		}
+		if status.Deprecated {
+			texts := []string{category.Summary}
+			for _, note := range category.Notes.Note {
+				texts = append(texts, note.Text)
+				texts = append(texts, note.P...)
+			}
+			status.ReplacedBy = referencedCWEs(category.ID, texts, entries)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// referencedCWEs returns the entries mentioned in the texts, other than the entry itself.
+func referencedCWEs(cweId string, texts []string, entries map[string]bool) []string {
+	replacements := []string{}
+	for _, match := range cweReferencePattern.FindAllStringSubmatch(strings.Join(texts, " "), -1) {
+		if entries[match[1]] {
+			replacements = appendReplacement(replacements, cweId, match[1])
+		}
+	}
+	return replacements
+}
+
+func appendReplacement(replacements []string, cweId string, replacement string) []string {
+	if replacement == "" || replacement == cweId || slices.Contains(replacements, replacement) {
+		return replacements
+	}
+	return append(replacements, replacement)
+}
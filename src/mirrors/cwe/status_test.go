@@ -0,0 +1,68 @@
+package cwe
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const statusCatalog = `<?xml version="1.0" encoding="UTF-8"?>
+<Weakness_Catalog xmlns="http://cwe.mitre.org/cwe-7" Name="CWE" Version="4.15" Date="2024-07-16">
+	<Weaknesses>
+		<Weakness ID="79" Name="Cross-site Scripting" Abstraction="Base" Structure="Simple" Status="Stable"/>
+		<Weakness ID="62" Name="UNIX Hard Link" Abstraction="Variant" Structure="Simple" Status="Incomplete"/>
+		<Weakness ID="71" Name="DEPRECATED: Apple '.DS_Store'" Abstraction="Variant" Structure="Simple" Status="Deprecated">
+			<Description>This entry has been deprecated as it represents a specific observed example of a UNIX Hard Link weakness type rather than its own individual weakness type. Please refer to CWE-62.</Description>
+		</Weakness>
+		<Weakness ID="217" Name="DEPRECATED: Failure to Protect Stored Data from Modification" Abstraction="Base" Structure="Simple" Status="Deprecated">
+			<Description>This entry has been deprecated because it incorporated and confused multiple weaknesses.</Description>
+			<Related_Weaknesses>
+				<Related_Weakness Nature="ChildOf" CWE_ID="471" View_ID="1000"/>
+			</Related_Weaknesses>
+		</Weakness>
+	</Weaknesses>
+	<Categories>
+		<Category ID="1347" Name="OWASP Top Ten 2021 Category A03:2021 - Injection" Status="Stable">
+			<Summary>Weaknesses in this category are related to
+				the A03 category "Injection" of the OWASP Top Ten 2021.</Summary>
+		</Category>
+		<Category ID="18" Name="DEPRECATED: Source Code" Status="Deprecated">
+			<Summary>This entry has been deprecated. It was originally used for organizing the Development View (CWE-699) and some other views, but it introduced unnecessary complexity and depth to the resulting tree.</Summary>
+		</Category>
+	</Categories>
+</Weakness_Catalog>`
+
+func TestParseStatuses(t *testing.T) {
+	res, extras, err := parseCatalog([]byte(statusCatalog))
+	assert.NoError(t, err)
+	assert.Equal(t, "4.15", extras.Version)
+	assert.Equal(t, "2024-07-16", extras.Date)
+
+	// The view CWE 18 mentions can't replace it
+	assert.Equal(t, []types.CWEStatus{
+		{CWEId: "79", EntryType: types.CWEEntryWeakness, ReplacedBy: []string{}},
+		{CWEId: "62", EntryType: types.CWEEntryWeakness, ReplacedBy: []string{}},
+		{CWEId: "71", EntryType: types.CWEEntryWeakness, Deprecated: true, ReplacedBy: []string{"62"}},
+		{CWEId: "217", EntryType: types.CWEEntryWeakness, Deprecated: true, ReplacedBy: []string{"471"}},
+		{CWEId: "1347", EntryType: types.CWEEntryCategory, ReplacedBy: []string{}},
+		{CWEId: "18", EntryType: types.CWEEntryCategory, Deprecated: true, ReplacedBy: []string{}},
+	}, parseStatuses(res))
+}
+
+func TestParseCategories(t *testing.T) {
+	res, _, err := parseCatalog([]byte(statusCatalog))
+	assert.NoError(t, err)
+
+	categories := parseCategories(res)
+	assert.Len(t, categories, 2)
+	assert.Equal(t, "1347", categories[0].CWEId)
+	assert.Equal(t, "OWASP Top Ten 2021 Category A03:2021 - Injection", categories[0].Name)
+	assert.Equal(t, "Weaknesses in this category are related to the A03 category Injection of the OWASP Top Ten 2021.", categories[0].Description)
+	assert.Equal(t, "Deprecated", categories[1].Status)
+}
+
+func TestParseCatalogInvalid(t *testing.T) {
+	_, _, err := parseCatalog([]byte("not xml"))
+	assert.Error(t, err)
+}
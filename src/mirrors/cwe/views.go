@@ -0,0 +1,101 @@
+package cwe
+
+import (
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// catalogImport holds the parts of the XML catalog knowledge.CWEListImport doesn't parse:
+// the version of the catalog, its views and the demonstrative examples of the weaknesses.
+type catalogImport struct {
+	Name       string `xml:"Name,attr"`
+	Version    string `xml:"Version,attr"`
+	Date       string `xml:"Date,attr"`
+	Weaknesses []struct {
+		ID                    string                 `xml:"ID,attr"`
+		DemonstrativeExamples []demonstrativeExample `xml:"Demonstrative_Examples>Demonstrative_Example"`
+	} `xml:"Weaknesses>Weakness"`
+	Views []struct {
+		ID        string `xml:"ID,attr"`
+		Name      string `xml:"Name,attr"`
+		Type      string `xml:"Type,attr"`
+		Status    string `xml:"Status,attr"`
+		Objective struct {
+			Text string   `xml:",chardata"`
+			P    []string `xml:"p"`
+		} `xml:"Objective"`
+		Members []struct {
+			CWEID  string `xml:"CWE_ID,attr"`
+			ViewID string `xml:"View_ID,attr"`
+		} `xml:"Members>Has_Member"`
+	} `xml:"Views>View"`
+}
+
+// parseViews extracts the views of the XML catalog and the relationships of their hierarchies:
+// the members of the views and of the categories, and the ChildOf relationships of the weaknesses.
+func parseViews(res knowledge.CWEListImport, viewList catalogImport) ([]types.CWEView, []types.CWERelationship) {
+	var views []types.CWEView
+	var relationships []types.CWERelationship
+	known := map[string]bool{}
+	seen := map[types.CWERelationship]bool{}
+	addRelationship := func(viewId string, parentId string, childId string, nature string) {
+		relationship := types.CWERelationship{ViewId: viewId, ParentId: parentId, ChildId: childId, Nature: nature}
+		if !known[viewId] || parentId == "" || childId == "" || seen[relationship] {
+			return
+		}
+		seen[relationship] = true
+		relationships = append(relationships, relationship)
+	}
+
+	for _, viewData := range viewList.Views {
+		if viewData.ID == "" {
+			continue
+		}
+		known[viewData.ID] = true
+
+		view := types.CWEView{
+			ViewId:  viewData.ID,
+			Name:    viewData.Name,
+			Type:    viewData.Type,
+			Status:  viewData.Status,
+			Members: []string{},
+		}
+		view.Objective = cleanString(viewData.Objective.Text)
+		if len(viewData.Objective.P) > 0 {
+			view.Objective = strings.TrimSpace(view.Objective + " " + cleanString(strings.Join(viewData.Objective.P, " ")))
+		}
+		for _, member := range viewData.Members {
+			view.Members = append(view.Members, member.CWEID)
+		}
+		views = append(views, view)
+	}
+
+	for _, viewData := range viewList.Views {
+		for _, member := range viewData.Members {
+			viewId := member.ViewID
+			if viewId == "" {
+				viewId = viewData.ID
+			}
+			addRelationship(viewId, viewData.ID, member.CWEID, "HasMember")
+		}
+	}
+
+	for _, category := range res.Categories.Categories {
+		for _, member := range category.Relationships.HasMember {
+			addRelationship(member.ViewID, category.ID, member.CWEID, "HasMember")
+		}
+	}
+
+	for _, weakness := range res.Weaknesses.Weaknesses {
+		for _, related := range weakness.RelatedWeaknesses.RelatedWeakness {
+			if related.Nature != "ChildOf" {
+				continue
+			}
+			addRelationship(related.ViewID, related.CWEID, weakness.ID, related.Nature)
+		}
+	}
+
+	return views, relationships
+}
@@ -0,0 +1,72 @@
+package cwe
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const viewsCatalog = `<?xml version="1.0" encoding="UTF-8"?>
+<Weakness_Catalog xmlns="http://cwe.mitre.org/cwe-7" xmlns:xhtml="http://www.w3.org/1999/xhtml" Name="CWE" Version="4.15" Date="2024-07-16">
+	<Weaknesses>
+		<Weakness ID="79" Name="Improper Neutralization of Input During Web Page Generation ('Cross-site Scripting')" Abstraction="Base" Structure="Simple" Status="Stable">
+			<Related_Weaknesses>
+				<Related_Weakness Nature="ChildOf" CWE_ID="74" View_ID="1000" Ordinal="Primary"/>
+				<Related_Weakness Nature="ChildOf" CWE_ID="74" View_ID="1003" Ordinal="Primary"/>
+				<Related_Weakness Nature="CanPrecede" CWE_ID="494" View_ID="1000"/>
+			</Related_Weaknesses>
+		</Weakness>
+		<Weakness ID="74" Name="Improper Neutralization of Special Elements in Output Used by a Downstream Component ('Injection')" Abstraction="Class" Structure="Simple" Status="Incomplete">
+			<Related_Weaknesses>
+				<Related_Weakness Nature="ChildOf" CWE_ID="707" View_ID="1000" Ordinal="Primary"/>
+			</Related_Weaknesses>
+		</Weakness>
+	</Weaknesses>
+	<Categories>
+		<Category ID="1347" Name="OWASP Top Ten 2021 Category A03:2021 - Injection" Status="Stable">
+			<Relationships>
+				<Has_Member CWE_ID="79" View_ID="1344"/>
+				<Has_Member CWE_ID="74" View_ID="1344"/>
+			</Relationships>
+		</Category>
+	</Categories>
+	<Views>
+		<View ID="1000" Name="Research Concepts" Type="Graph" Status="Draft">
+			<Objective>This view is intended to facilitate research into weaknesses,
+				including their inter-dependencies.</Objective>
+			<Members>
+				<Has_Member CWE_ID="707" View_ID="1000"/>
+			</Members>
+		</View>
+		<View ID="1344" Name="Weaknesses in OWASP Top Ten (2021)" Type="Graph" Status="Draft">
+			<Objective><xhtml:p>CWE entries in this view are associated with the OWASP Top Ten.</xhtml:p></Objective>
+			<Members>
+				<Has_Member CWE_ID="1347" View_ID="1344"/>
+			</Members>
+		</View>
+	</Views>
+</Weakness_Catalog>`
+
+func TestParseViews(t *testing.T) {
+	res, extras, err := parseCatalog([]byte(viewsCatalog))
+	assert.NoError(t, err)
+	views, relationships := parseViews(res, extras)
+
+	assert.Len(t, views, 2)
+	assert.Equal(t, "1000", views[0].ViewId)
+	assert.Equal(t, "Graph", views[0].Type)
+	assert.Equal(t, "This view is intended to facilitate research into weaknesses, including their inter-dependencies.", views[0].Objective)
+	assert.Equal(t, []string{"707"}, views[0].Members)
+	assert.Equal(t, "CWE entries in this view are associated with the OWASP Top Ten.", views[1].Objective)
+
+	// Relationships of views missing from the catalog (1003) and other natures are left out
+	assert.ElementsMatch(t, []types.CWERelationship{
+		{ViewId: "1000", ParentId: "1000", ChildId: "707", Nature: "HasMember"},
+		{ViewId: "1344", ParentId: "1344", ChildId: "1347", Nature: "HasMember"},
+		{ViewId: "1344", ParentId: "1347", ChildId: "79", Nature: "HasMember"},
+		{ViewId: "1344", ParentId: "1347", ChildId: "74", Nature: "HasMember"},
+		{ViewId: "1000", ParentId: "74", ChildId: "79", Nature: "ChildOf"},
+		{ViewId: "1000", ParentId: "707", ChildId: "74", Nature: "ChildOf"},
+	}, relationships)
+}
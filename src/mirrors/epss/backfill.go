@@ -0,0 +1,47 @@
+package epss
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/uptrace/bun"
+)
+
+// Backfill loads the daily EPSS files published between from and to (inclusive) into the score history.
+// Days already stored are skipped, as are days for which no file was published.
+func Backfill(db *bun.DB, from, to time.Time) error {
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+	if to.Before(from) {
+		return fmt.Errorf("invalid EPSS backfill range: %s is before %s", to.Format(time.DateOnly), from.Format(time.DateOnly))
+	}
+
+	log.Printf("Start backfilling EPSS scores from %s to %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+
+	loaded := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		exists, err := pgsql.HasEPSSHistory(db, day)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		err = load(db, fmt.Sprintf(datedURLTemplate, day.Format(time.DateOnly)), false)
+		if errors.Is(err, errNotPublished) {
+			log.Printf("No EPSS scores published for %s", day.Format(time.DateOnly))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		loaded++
+	}
+
+	log.Printf("EPSS backfill completed: %d days loaded", loaded)
+	return nil
+}
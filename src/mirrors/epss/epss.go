@@ -1,71 +1,208 @@
 package epss
 
 import (
+	"bufio"
 	"compress/gzip"
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
-
-	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"time"
 )
 
-// downloadEPSS downloads the list of EPSS scores from the given URL and parses it as an array of knowledge.EPSS.
-func downloadEPSS(url string) ([]knowledge.EPSS, error) {
+// errNotPublished is returned when no EPSS file exists at the requested URL, e.g. for a day without scores.
+var errNotPublished = errors.New("EPSS scores not published")
+
+// expectedHeader is the CSV header of the EPSS files, after the metadata line.
+var expectedHeader = []string{"cve", "epss", "percentile"}
+
+// epssReader streams a gzipped EPSS scores file.
+// The metadata line and the CSV header are read when it is opened; rows are read by WriteCSV.
+type epssReader struct {
+	body   io.ReadCloser
+	gzip   *gzip.Reader
+	csv    *csv.Reader
+	closed bool
+
+	ModelVersion string
+	ScoreDate    time.Time
+	// Rows and Malformed count the valid and rejected rows once WriteCSV returned
+	Rows      int
+	Malformed int
+}
+
+// datedFile matches the name of the EPSS files of a given day, e.g. epss_scores-2021-04-14.csv.gz
+var datedFile = regexp.MustCompile(`(\d{4}-\d{2}-\d{2})\.csv\.gz$`)
+
+// fileDate returns the day in the name of a dated EPSS file, or the zero time.
+func fileDate(url string) time.Time {
+	match := datedFile.FindStringSubmatch(url)
+	if match == nil {
+		return time.Time{}
+	}
+	date, err := time.Parse(time.DateOnly, match[1])
+	if err != nil {
+		return time.Time{}
+	}
+	return date
+}
+
+// openEPSS downloads the EPSS file at url and reads its metadata and header.
+// The caller must Close the returned reader.
+func openEPSS(url string) (*epssReader, error) {
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errNotPublished
+	}
 	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
 		return nil, errors.New("failed to fetch EPSS scores")
 	}
 
-	gzipReader, err := gzip.NewReader(resp.Body)
+	reader, err := newEPSSReader(resp.Body, fileDate(url))
 	if err != nil {
+		resp.Body.Close()
 		return nil, err
 	}
-	defer gzipReader.Close()
+	return reader, nil
+}
 
-	decompressedContent := new(strings.Builder)
-	_, err = io.Copy(decompressedContent, gzipReader)
+// newEPSSReader reads the metadata line and validates the CSV header of a gzipped EPSS file.
+// Files published before 2022 have no metadata line, their score date is the day of their file name.
+func newEPSSReader(body io.ReadCloser, day time.Time) (*epssReader, error) {
+	gzipReader, err := gzip.NewReader(body)
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Split(decompressedContent.String(), "\n")
-	if len(lines) < 2 {
-		return nil, errors.New("CSV file does not contain enough rows")
+	buffered := bufio.NewReader(gzipReader)
+	r := &epssReader{body: body, gzip: gzipReader, csv: csv.NewReader(buffered), ScoreDate: day}
+
+	first, err := buffered.Peek(1)
+	if err != nil {
+		gzipReader.Close()
+		return nil, fmt.Errorf("failed to read EPSS metadata: %w", err)
+	}
+	if first[0] == '#' {
+		metadata, err := buffered.ReadString('\n')
+		if err != nil {
+			gzipReader.Close()
+			return nil, fmt.Errorf("failed to read EPSS metadata: %w", err)
+		}
+		r.ModelVersion, r.ScoreDate, err = parseMetadata(metadata)
+		if err != nil {
+			gzipReader.Close()
+			return nil, err
+		}
+	} else if day.IsZero() {
+		gzipReader.Close()
+		return nil, errors.New("EPSS file has neither a metadata line nor a dated file name")
 	}
 
-	csvReader := csv.NewReader(strings.NewReader(strings.Join(lines[1:], "\n"))) // Skip the first line
-	records, err := csvReader.ReadAll()
+	r.csv.FieldsPerRecord = -1 // Row lengths are validated in WriteCSV
+	r.csv.ReuseRecord = true
+	header, err := r.csv.Read()
 	if err != nil {
-		return nil, err
+		gzipReader.Close()
+		return nil, fmt.Errorf("failed to read EPSS header: %w", err)
+	}
+	if !slices.Equal(header, expectedHeader) {
+		gzipReader.Close()
+		return nil, fmt.Errorf("unexpected EPSS header %v, expected %v", header, expectedHeader)
 	}
 
-	var epssScores []knowledge.EPSS
-	for _, record := range records { // Skip header row
-		if len(record) < 3 {
-			continue
+	return r, nil
+}
+
+// WriteCSV writes the valid rows as "cve,score,percentile" CSV to w.
+// Rows with a missing column, a malformed CVE id or a value outside [0, 1] are counted as malformed and skipped.
+func (r *epssReader) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	for {
+		record, err := r.csv.Read()
+		if err == io.EOF {
+			break
 		}
-		score, err := strconv.ParseFloat(record[1], 32)
 		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				r.Malformed++
+				continue
+			}
+			return err
+		}
+
+		if !validRow(record) {
+			r.Malformed++
 			continue
 		}
-		percentile, err := strconv.ParseFloat(record[2], 32)
+		if err := writer.Write(record[:3]); err != nil {
+			return err
+		}
+		r.Rows++
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Close releases the download.
+func (r *epssReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.gzip.Close()
+	return r.body.Close()
+}
+
+func validRow(record []string) bool {
+	if len(record) < 3 || !strings.HasPrefix(record[0], "CVE-") {
+		return false
+	}
+	for _, value := range record[1:3] {
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil || f < 0 || f > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMetadata parses the first line of an EPSS file:
+// #model_version:v2023.03.01,score_date:2023-04-12T00:00:00+0000
+func parseMetadata(line string) (string, time.Time, error) {
+	var modelVersion, scoreDate string
+	for _, field := range strings.Split(strings.TrimPrefix(strings.TrimSpace(line), "#"), ",") {
+		key, value, _ := strings.Cut(field, ":")
+		switch key {
+		case "model_version":
+			modelVersion = value
+		case "score_date":
+			scoreDate = value
+		}
+	}
+
+	if scoreDate == "" {
+		return "", time.Time{}, fmt.Errorf("EPSS file has no score date in %q", line)
+	}
+	date, err := time.Parse("2006-01-02T15:04:05-0700", scoreDate)
+	if err != nil {
+		date, err = time.Parse(time.DateOnly, scoreDate)
 		if err != nil {
-			continue
+			return "", time.Time{}, fmt.Errorf("invalid EPSS score date %q: %w", scoreDate, err)
 		}
-		epssScores = append(epssScores, knowledge.EPSS{
-			CVE:        record[0],
-			Score:      float32(score),
-			Percentile: float32(percentile),
-		})
 	}
 
-	return epssScores, nil
+	return modelVersion, date.UTC().Truncate(24 * time.Hour), nil
 }
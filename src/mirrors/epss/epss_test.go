@@ -0,0 +1,83 @@
+package epss
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMetadata(t *testing.T) {
+	modelVersion, scoreDate, err := parseMetadata("#model_version:v2023.03.01,score_date:2023-04-12T00:00:00+0000")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2023.03.01", modelVersion)
+	assert.Equal(t, time.Date(2023, 4, 12, 0, 0, 0, 0, time.UTC), scoreDate)
+
+	_, _, err = parseMetadata("cve,epss,percentile")
+	assert.Error(t, err)
+}
+
+func TestEPSSReader(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte("#model_version:v2025.03.14,score_date:2025-06-01T00:00:00+0000\n" +
+		"cve,epss,percentile\n" +
+		"CVE-2021-44228,0.94358,0.99982\n" +
+		"CVE-2024-0001,1.5,0.2\n" +
+		"not-a-cve,0.1,0.2\n" +
+		"CVE-2024-0002,0.00043\n" +
+		"CVE-2024-0003,0.00052,0.16\n"))
+	gz.Close()
+
+	reader, err := newEPSSReader(io.NopCloser(&compressed), time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "v2025.03.14", reader.ModelVersion)
+
+	var out strings.Builder
+	assert.NoError(t, reader.WriteCSV(&out))
+	assert.Equal(t, "CVE-2021-44228,0.94358,0.99982\nCVE-2024-0003,0.00052,0.16\n", out.String())
+	assert.Equal(t, 2, reader.Rows)
+	assert.Equal(t, 3, reader.Malformed)
+}
+
+func TestEPSSReaderHeader(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte("#model_version:v2025.03.14,score_date:2025-06-01T00:00:00+0000\ncve,score\n"))
+	gz.Close()
+
+	_, err := newEPSSReader(io.NopCloser(&compressed), time.Time{})
+	assert.Error(t, err)
+}
+
+func TestEPSSReaderWithoutMetadata(t *testing.T) {
+	compress := func(content string) io.ReadCloser {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write([]byte(content))
+		gz.Close()
+		return io.NopCloser(&compressed)
+	}
+	content := "cve,epss,percentile\nCVE-2021-44228,0.97,0.99\n"
+
+	day := fileDate("https://epss.empiricalsecurity.com/epss_scores-2021-12-14.csv.gz")
+	assert.Equal(t, time.Date(2021, 12, 14, 0, 0, 0, 0, time.UTC), day)
+	assert.True(t, fileDate(currentURL).IsZero())
+
+	reader, err := newEPSSReader(compress(content), day)
+	assert.NoError(t, err)
+	assert.Equal(t, day, reader.ScoreDate)
+	assert.Empty(t, reader.ModelVersion)
+
+	var out strings.Builder
+	assert.NoError(t, reader.WriteCSV(&out))
+	assert.Equal(t, "CVE-2021-44228,0.97,0.99\n", out.String())
+
+	// The current file must have its metadata
+	_, err = newEPSSReader(compress(content), time.Time{})
+	assert.Error(t, err)
+}
@@ -1,23 +1,45 @@
 package epss
 
 import (
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
 	"github.com/uptrace/bun"
 )
 
-// Update is a function that updates the CWEs in the knowledge database graph.
-// It downloads the CWEs from the graph, and then updates them.
+const (
+	currentURL       = "https://epss.empiricalsecurity.com/epss_scores-current.csv.gz"
+	datedURLTemplate = "https://epss.empiricalsecurity.com/epss_scores-%s.csv.gz"
+)
+
+// Update streams the current EPSS scores into the score history and refreshes the epss table,
+// which holds the latest score of every CVE.
+// CVEs whose score spiked since last week are then notified.
 func Update(db *bun.DB) error {
 	log.Println("Start updating EPSS scores")
-	epss, err := downloadEPSS("https://epss.empiricalsecurity.com/epss_scores-current.csv.gz")
+	err := load(db, currentURL, true)
 	if err != nil {
 		return err
 	}
-	err = pgsql.UpdateEPSS(db, epss)
+
+	return notifySpikes(db)
+}
+
+// load streams the EPSS file at url into the database.
+func load(db *bun.DB, url string, current bool) error {
+	reader, err := openEPSS(url)
 	if err != nil {
 		return err
 	}
+	defer reader.Close()
+
+	copied, err := pgsql.LoadEPSS(db, reader.ScoreDate, reader.ModelVersion, current, reader.WriteCSV)
+	if err != nil {
+		return fmt.Errorf("failed to load EPSS scores of %s: %w", reader.ScoreDate.Format(time.DateOnly), err)
+	}
+
+	log.Printf("EPSS scores of %s (model %s): %d rows loaded, %d malformed rows skipped", reader.ScoreDate.Format(time.DateOnly), reader.ModelVersion, copied, reader.Malformed)
 	return nil
 }
@@ -0,0 +1,78 @@
+package epss
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	amqp_helper "github.com/CodeClarityCE/utility-amqp-helper"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// spikeWindow is the period over which score increases are measured
+	spikeWindow = 7 * 24 * time.Hour
+	// defaultSpikeThreshold is the minimum score increase notified, overridden by EPSS_SPIKE_THRESHOLD
+	defaultSpikeThreshold = 0.5
+	// maxNotifiedSpikes caps the number of CVEs sent in a notification
+	maxNotifiedSpikes = 100
+
+	spikesStateName = "epss_spikes"
+)
+
+// notifySpikes sends the CVEs whose EPSS score rose by more than the threshold over the last week.
+// Each score date is notified once, although the update runs several times a day.
+func notifySpikes(db *bun.DB) error {
+	threshold := float32(defaultSpikeThreshold)
+	if value := os.Getenv("EPSS_SPIKE_THRESHOLD"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			log.Printf("Invalid EPSS_SPIKE_THRESHOLD %q, using %.2f", value, defaultSpikeThreshold)
+		} else {
+			threshold = float32(parsed)
+		}
+	}
+
+	spikes, err := pgsql.GetEPSSSpikes(db, spikeWindow, threshold)
+	if err != nil {
+		return err
+	}
+	if len(spikes) == 0 {
+		return nil
+	}
+
+	state, err := pgsql.GetMirrorState(db, spikesStateName)
+	if err != nil {
+		return err
+	}
+	scoreDate := spikes[0].CurrentDate.Format(time.DateOnly)
+	if state.Cursor == scoreDate {
+		return nil
+	}
+
+	log.Printf("%d CVEs saw their EPSS score rise by more than %.2f since %s", len(spikes), threshold, spikes[0].PreviousDate.Format(time.DateOnly))
+
+	notified := spikes
+	if len(notified) > maxNotifiedSpikes {
+		notified = notified[:maxNotifiedSpikes]
+	}
+	notification := map[string]interface{}{
+		"type":        "epss_spike",
+		"score_date":  scoreDate,
+		"threshold":   threshold,
+		"total_count": len(spikes),
+		"spikes":      notified,
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	amqp_helper.Send("service_notifier", data)
+
+	state.Cursor = scoreDate
+	state.LastSync = time.Now()
+	return pgsql.SetMirrorState(db, state)
+}
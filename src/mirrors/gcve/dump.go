@@ -0,0 +1,224 @@
+package gcve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/uptrace/bun"
+)
+
+// maxDownloadAttempts is the number of times in a row a dump download is resumed without
+// downloading anything before giving up.
+const maxDownloadAttempts = 5
+
+// dumpCheckpoint is the progress of a dump import, stored as the cursor of its mirror state.
+// It lets an import interrupted by a disconnect, a crash or a deploy resume the download where
+// it stopped and the processing after the last committed batch.
+type dumpCheckpoint struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Downloaded   bool   `json:"downloaded"`
+	// Offset is the number of bytes of the dump whose records have been committed
+	Offset  int64 `json:"offset"`
+	Records int   `json:"records"`
+}
+
+// dumpDir returns the directory the dumps are downloaded to, set with GCVE_DUMP_DIR.
+// It should be a persistent volume for imports to survive a redeploy.
+func dumpDir() string {
+	if dir := os.Getenv("GCVE_DUMP_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.TempDir(), "gcve")
+}
+
+// importDump downloads an NDJSON dump to a local file and processes it, resuming a previous
+// import of the same dump if one was interrupted. The file is removed once fully processed.
+func importDump(db *bun.DB, name string, url string) error {
+	stateName := "gcve_dump_" + name
+	state, err := pgsql.GetMirrorState(db, stateName)
+	if err != nil {
+		return err
+	}
+
+	checkpoint := dumpCheckpoint{URL: url}
+	if state.Cursor != "" {
+		var previous dumpCheckpoint
+		if err := json.Unmarshal([]byte(state.Cursor), &previous); err != nil {
+			log.Printf("Ignoring invalid %s checkpoint: %v", name, err)
+		} else if previous.URL == url {
+			checkpoint = previous
+		}
+	}
+	save := func() error {
+		data, err := json.Marshal(checkpoint)
+		if err != nil {
+			return err
+		}
+		state.Cursor = string(data)
+		return pgsql.SetMirrorState(db, state)
+	}
+
+	if err := os.MkdirAll(dumpDir(), 0o755); err != nil {
+		return fmt.Errorf("failed to create GCVE dump directory: %w", err)
+	}
+	path := filepath.Join(dumpDir(), name+".ndjson")
+
+	if _, err := os.Stat(path); checkpoint.Downloaded && errors.Is(err, fs.ErrNotExist) {
+		log.Printf("GCVE: %s dump vanished, downloading it again", name)
+		checkpoint = dumpCheckpoint{URL: url}
+	}
+
+	if !checkpoint.Downloaded {
+		log.Printf("Downloading %s dump...", name)
+		if err := downloadDump(url, path, &checkpoint, save); err != nil {
+			return fmt.Errorf("failed to download %s dump: %w", name, err)
+		}
+		checkpoint.Downloaded = true
+		state.LastSync = time.Now()
+		if err := save(); err != nil {
+			return err
+		}
+	} else if checkpoint.Offset > 0 {
+		log.Printf("GCVE: resuming %s dump after %d records", name, checkpoint.Records)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s dump: %w", name, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(checkpoint.Offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek %s dump: %w", name, err)
+	}
+
+	baseOffset, baseRecords := checkpoint.Offset, checkpoint.Records
+	err = processNDJSONStream(db, file, func(consumed int64, records int) error {
+		checkpoint.Offset = baseOffset + consumed
+		checkpoint.Records = baseRecords + records
+		return save()
+	})
+	if err != nil {
+		return err
+	}
+
+	// Done: the next import starts from a fresh dump
+	if err := os.Remove(path); err != nil {
+		log.Printf("Failed to remove %s dump: %v", name, err)
+	}
+	checkpoint = dumpCheckpoint{URL: url}
+	return save()
+}
+
+// downloadDump downloads url to path through a ".part" file, resuming with HTTP Range requests
+// after a disconnect or a previous interrupted run. If-Range makes the server send the whole
+// dump again when it changed since the download started.
+func downloadDump(url string, path string, checkpoint *dumpCheckpoint, save func() error) error {
+	partPath := path + ".part"
+
+	// The attempts only count the disconnects in a row that didn't download anything
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+	failures := 0
+	for {
+		complete, err := downloadDumpPart(url, partPath, checkpoint, save)
+		if err == nil && complete {
+			return os.Rename(partPath, path)
+		}
+		if err == nil {
+			err = fmt.Errorf("download of %s ended early", url)
+		}
+
+		if info, statErr := os.Stat(partPath); statErr == nil {
+			if info.Size() > offset {
+				failures = 0
+			}
+			offset = info.Size()
+		}
+		failures++
+		if failures >= maxDownloadAttempts {
+			return err
+		}
+		log.Printf("GCVE: resuming download at %d bytes (attempt %d/%d) after: %v", offset, failures+1, maxDownloadAttempts, err)
+		time.Sleep(time.Duration(failures) * retryDelay)
+	}
+}
+
+// downloadDumpPart appends the missing part of the dump to partPath in a single request.
+// It reports whether the file is complete.
+func downloadDumpPart(url string, partPath string, checkpoint *dumpCheckpoint, save func() error) (bool, error) {
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+		if checkpoint.ETag != "" {
+			req.Header.Set("If-Range", checkpoint.ETag)
+		} else if checkpoint.LastModified != "" {
+			req.Header.Set("If-Range", checkpoint.LastModified)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// Range not supported or the dump changed, start over
+		if offset > 0 {
+			log.Printf("GCVE: server sent the whole dump, restarting download")
+		}
+		offset = 0
+		flags |= os.O_TRUNC
+		checkpoint.ETag = resp.Header.Get("ETag")
+		checkpoint.LastModified = resp.Header.Get("Last-Modified")
+		if err := save(); err != nil {
+			return false, err
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Nothing left to download
+		return offset > 0, nil
+	default:
+		return false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	// Without a length the end of the body is the end of the dump
+	return resp.ContentLength < 0 || written == resp.ContentLength, nil
+}
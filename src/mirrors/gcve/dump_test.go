@@ -0,0 +1,96 @@
+package gcve
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serveDump serves content with Range support, recording the Range headers received.
+func serveDump(t *testing.T, content string, etag string) (string, *[]string) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "dump.ndjson", time.Time{}, strings.NewReader(content))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL, &ranges
+}
+
+func TestDownloadDumpResumes(t *testing.T) {
+	content := strings.Repeat(`{"cveMetadata":{"cveId":"CVE-2024-0001"}}`+"\n", 100)
+	url, ranges := serveDump(t, content, `"v1"`)
+
+	path := filepath.Join(t.TempDir(), "cvelistv5.ndjson")
+	assert.NoError(t, os.WriteFile(path+".part", []byte(content[:1234]), 0o644))
+
+	checkpoint := dumpCheckpoint{URL: url, ETag: `"v1"`}
+	err := downloadDump(url, path, &checkpoint, func() error { return nil })
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal([]byte(content), data))
+	assert.Equal(t, []string{"bytes=1234-"}, *ranges)
+}
+
+func TestDownloadDumpRestartsWhenChanged(t *testing.T) {
+	content := strings.Repeat(`{"cveMetadata":{"cveId":"CVE-2024-0002"}}`+"\n", 100)
+	url, _ := serveDump(t, content, `"v2"`)
+
+	// The partial download belongs to a previous version of the dump
+	path := filepath.Join(t.TempDir(), "cvelistv5.ndjson")
+	assert.NoError(t, os.WriteFile(path+".part", []byte("stale"), 0o644))
+
+	saved := 0
+	checkpoint := dumpCheckpoint{URL: url, ETag: `"v1"`}
+	err := downloadDump(url, path, &checkpoint, func() error { saved++; return nil })
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+	assert.Equal(t, `"v2"`, checkpoint.ETag)
+	assert.Equal(t, 1, saved)
+}
+
+func TestDownloadDumpKeepsResumingWhileProgressing(t *testing.T) {
+	previous := retryDelay
+	retryDelay = 0
+	t.Cleanup(func() { retryDelay = previous })
+
+	// Every response is cut after 100 bytes, more times than maxDownloadAttempts
+	content := strings.Repeat(`{"cveMetadata":{"cveId":"CVE-2024-0003"}}`+"\n", 30)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset := 0
+		if r.Header.Get("Range") != "" {
+			fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &offset)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(content)-1, len(content)))
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)-offset))
+		if offset > 0 {
+			w.WriteHeader(http.StatusPartialContent)
+		}
+		_, _ = w.Write([]byte(content[offset:min(offset+100, len(content))]))
+	}))
+	t.Cleanup(server.Close)
+
+	path := filepath.Join(t.TempDir(), "cvelistv5.ndjson")
+	checkpoint := dumpCheckpoint{URL: server.URL}
+	err := downloadDump(server.URL, path, &checkpoint, func() error { return nil })
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+}
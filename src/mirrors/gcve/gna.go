@@ -0,0 +1,108 @@
+package gcve
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/uptrace/bun"
+)
+
+var (
+	// gcveIdPattern matches GCVE identifiers, GCVE-<GNA id>-<year>-<number>
+	gcveIdPattern = regexp.MustCompile(`^GCVE-(\d+)-(\d{4})-(\d+)$`)
+	cveIdPattern  = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+)
+
+// parseGNA returns the id of the GCVE Numbering Authority that issued a GCVE identifier.
+// GNA 0 is the CVE program.
+func parseGNA(gcveId string) (int, bool) {
+	match := gcveIdPattern.FindStringSubmatch(gcveId)
+	if match == nil {
+		return 0, false
+	}
+	gna, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	return gna, true
+}
+
+// recordIds returns the GCVE and CVE identifiers of a record. CVE records map to GNA 0,
+// records issued by another GNA keep their identifier and are linked to the CVE referenced
+// by their references, when they reference exactly one.
+func recordIds(recordId string, references []knowledge.GCVEReference) (string, string) {
+	recordId = strings.TrimSpace(recordId)
+	if strings.HasPrefix(recordId, "CVE-") {
+		return cveToGCVEId(recordId), recordId
+	}
+
+	gna, ok := parseGNA(recordId)
+	if !ok {
+		return recordId, ""
+	}
+	if gna == 0 {
+		return recordId, "CVE-" + strings.TrimPrefix(recordId, "GCVE-0-")
+	}
+
+	aliases := make(map[string]bool)
+	for _, reference := range references {
+		for _, alias := range cveIdPattern.FindAllString(reference.Name+" "+reference.URL, -1) {
+			aliases[alias] = true
+		}
+	}
+	if len(aliases) != 1 {
+		return recordId, ""
+	}
+	for alias := range aliases {
+		return recordId, alias
+	}
+	return recordId, ""
+}
+
+// gnaSources returns the vulnerability-lookup sources publishing advisories of GNAs,
+// set with GCVE_GNA_SOURCES as a comma separated list.
+func gnaSources() []string {
+	var sources []string
+	for _, source := range strings.Split(os.Getenv("GCVE_GNA_SOURCES"), ",") {
+		source = strings.TrimSpace(source)
+		if source != "" {
+			sources = append(sources, source)
+		}
+	}
+	return sources
+}
+
+// syncGNASources syncs the advisories of every GNA source. Each source has its own cursor,
+// only advanced once everything changed since the previous sync has been processed.
+func syncGNASources(db *bun.DB) error {
+	var errs []error
+	for _, source := range gnaSources() {
+		stateName := "gcve_gna_" + source
+		state, err := pgsql.GetMirrorState(db, stateName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		log.Printf("GCVE: syncing GNA source %s", source)
+		cursor, err := syncSource(db, source, state.LastSync, time.Now())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync GNA source %s: %w", source, err))
+			continue
+		}
+
+		state.LastSync = cursor
+		if err := pgsql.SetMirrorState(db, state); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package gcve
+
+import (
+	"testing"
+
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGNA(t *testing.T) {
+	gna, ok := parseGNA("GCVE-1-2025-0042")
+	assert.True(t, ok)
+	assert.Equal(t, 1, gna)
+
+	gna, ok = parseGNA("GCVE-0-2024-1234")
+	assert.True(t, ok)
+	assert.Equal(t, 0, gna)
+
+	_, ok = parseGNA("CVE-2024-1234")
+	assert.False(t, ok)
+}
+
+func TestRecordIds(t *testing.T) {
+	gcveId, cveId := recordIds("CVE-2024-1234", nil)
+	assert.Equal(t, "GCVE-0-2024-1234", gcveId)
+	assert.Equal(t, "CVE-2024-1234", cveId)
+
+	gcveId, cveId = recordIds("GCVE-0-2024-1234", nil)
+	assert.Equal(t, "GCVE-0-2024-1234", gcveId)
+	assert.Equal(t, "CVE-2024-1234", cveId)
+
+	// A GNA advisory referencing its CVE
+	gcveId, cveId = recordIds("GCVE-1-2025-0042", []knowledge.GCVEReference{
+		{URL: "https://example.org/advisory"},
+		{URL: "https://www.cve.org/CVERecord?id=CVE-2025-12345"},
+		{URL: "https://nvd.nist.gov/vuln/detail/CVE-2025-12345", Name: "CVE-2025-12345"},
+	})
+	assert.Equal(t, "GCVE-1-2025-0042", gcveId)
+	assert.Equal(t, "CVE-2025-12345", cveId)
+
+	// Several CVEs are ambiguous, the advisory isn't linked
+	_, cveId = recordIds("GCVE-1-2025-0043", []knowledge.GCVEReference{
+		{Name: "CVE-2025-0001"},
+		{Name: "CVE-2025-0002"},
+	})
+	assert.Empty(t, cveId)
+}
+
+func TestParseGNARecord(t *testing.T) {
+	record, err := parseCVERecord([]byte(`{
+		"dataType": "CVE_RECORD",
+		"cveMetadata": {"vulnId": "GCVE-1-2025-0042", "state": "PUBLISHED"},
+		"containers": {"cna": {"references": [{"url": "https://www.cve.org/CVERecord?id=CVE-2025-12345"}]}}
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "GCVE-1-2025-0042", record.Item.GCVEId)
+	assert.Equal(t, "CVE-2025-12345", record.Item.CVEId)
+}
@@ -0,0 +1,264 @@
+package gcve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	// updatedSource is the vulnerability-lookup source the incremental sync pages through
+	updatedSource = "cvelistv5"
+	// maxUpdatedPages bounds the requests of the incremental sync, a backlog this large is cheaper to reimport
+	maxUpdatedPages = 4000
+	// maxFetchAttempts is the number of times a page of the listing is requested before giving up
+	maxFetchAttempts = 3
+)
+
+// updatedAPI lists the vulnerabilities of a source by modification time.
+var updatedAPI = "https://vulnerability.circl.lu/api/vulnerability/"
+
+// updatedPage is a page of the vulnerability listing. Depending on the deployment the
+// records are either wrapped with pagination metadata or returned as a bare array.
+type updatedPage struct {
+	Data []json.RawMessage `json:"data"`
+}
+
+// incrementalUpdate fetches every vulnerability modified since the previous sync via the API.
+// It returns the cursor of the next sync, until unless a batch couldn't be processed, and fails
+// when the listing couldn't be paged through completely.
+func incrementalUpdate(db *bun.DB, since time.Time, until time.Time) (time.Time, error) {
+	log.Printf("GCVE incremental update since %s", since.Format(time.RFC3339))
+	return syncSource(db, updatedSource, since, until)
+}
+
+// syncSource processes every record of a vulnerability-lookup source modified since the given
+// time, or all of them when since is zero. See syncRecords for the returned cursor.
+func syncSource(db *bun.DB, source string, since time.Time, until time.Time) (time.Time, error) {
+	return syncRecords(source, since, until, func(batch []cveRecord) error {
+		return processBatchWithRetry(db, batch)
+	})
+}
+
+// syncRecords hands the records of a source modified since the given time to process, batch by batch.
+// It returns the cursor of the next sync: until when every batch was processed, otherwise the day
+// the oldest record of a failed batch was modified, so that the next sync lists it again. A failed
+// batch is skipped, the records after it are still processed. Fails when the listing couldn't be
+// fully covered.
+func syncRecords(source string, since time.Time, until time.Time, process func(batch []cveRecord) error) (time.Time, error) {
+	processed := 0
+	skipped := 0
+	cursor := until
+	total, err := walkUpdated(source, since, func(records []json.RawMessage) error {
+		var batch []cveRecord
+		for _, data := range records {
+			record, err := parseCVERecord(data)
+			if err != nil {
+				continue
+			}
+			batch = append(batch, *record)
+		}
+
+		if err := process(batch); err != nil {
+			ids := make([]string, len(batch))
+			for i, record := range batch {
+				ids[i] = record.Item.GCVEId
+			}
+			log.Printf("Skipping incremental GCVE batch %v: %v", ids, err)
+			skipped += len(batch)
+
+			// The records are listed oldest first, the first one of the batch is its oldest
+			resume := since
+			key := parseRecordKey(records[0])
+			if day, err := time.Parse(time.DateOnly, key.updated[:min(len(key.updated), len(time.DateOnly))]); err == nil && day.After(since) {
+				resume = day
+			}
+			if resume.Before(cursor) {
+				cursor = resume
+			}
+			return nil
+		}
+		processed += len(batch)
+		return nil
+	})
+	if err != nil {
+		return since, err
+	}
+
+	log.Printf("GCVE %s: %d records listed, %d processed, %d skipped", source, total, processed, skipped)
+	if skipped > 0 {
+		log.Printf("GCVE %s: holding the cursor at %s to process the skipped records again", source, cursor.Format(time.RFC3339))
+	}
+	return cursor, nil
+}
+
+// recordKey is the position of a record in the listing sorted by modification time.
+type recordKey struct {
+	updated string
+	id      string
+}
+
+// parseRecordKey returns the modification time and the identifier of a listed record.
+func parseRecordKey(data json.RawMessage) recordKey {
+	var raw struct {
+		CveMetadata struct {
+			CveId       string `json:"cveId"`
+			VulnId      string `json:"vulnId"`
+			DateUpdated string `json:"dateUpdated"`
+		} `json:"cveMetadata"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return recordKey{id: string(data)}
+	}
+	id := raw.CveMetadata.CveId
+	if id == "" {
+		id = raw.CveMetadata.VulnId
+	}
+	return recordKey{updated: raw.CveMetadata.DateUpdated, id: id}
+}
+
+// walkUpdated pages through the vulnerabilities of a source modified since the given time, oldest first,
+// and hands the records it didn't see yet to handle. It returns the number of records handled,
+// and an error if the listing wasn't fully covered.
+//
+// Offset pages shift when a record is modified while paging: it moves to the end of the listing
+// and the records after it move back by one. The walk is therefore keyed on (updated, id): every
+// request is anchored on the day of the last record handled, which the API filters on, and the
+// records up to the last key are skipped. A page with new records is read again before moving to
+// the next one, so that the records shifted into it are not missed.
+func walkUpdated(source string, since time.Time, handle func(records []json.RawMessage) error) (int, error) {
+	total := 0
+	anchor := since
+	page := 1
+	var last recordKey
+	// seen are the ids of the records handled that were modified at last.updated
+	seen := make(map[string]bool)
+	previousPage := 0
+	var previousFirst string
+
+	for request := 0; request < maxUpdatedPages; request++ {
+		records, err := fetchUpdatedPageWithRetry(source, anchor, page)
+		if err != nil {
+			return total, fmt.Errorf("failed to fetch page %d of updated vulnerabilities: %w", page, err)
+		}
+		if len(records) == 0 {
+			return total, nil
+		}
+
+		// An API ignoring the page parameter would return the first page forever
+		first := string(records[0])
+		if page != previousPage && first == previousFirst {
+			return total, fmt.Errorf("page %d of updated vulnerabilities repeats the previous page", page)
+		}
+		previousPage, previousFirst = page, first
+
+		var fresh []json.RawMessage
+		for _, data := range records {
+			key := parseRecordKey(data)
+			if key.updated < last.updated || (key.updated == last.updated && seen[key.id]) {
+				continue
+			}
+			if key.updated > last.updated {
+				last.updated = key.updated
+				clear(seen)
+			}
+			last.id = key.id
+			seen[key.id] = true
+			fresh = append(fresh, data)
+		}
+
+		if len(fresh) > 0 {
+			if err := handle(fresh); err != nil {
+				return total, err
+			}
+			total += len(fresh)
+		}
+
+		if len(records) < batchSize {
+			return total, nil
+		}
+
+		if day, err := time.Parse(time.DateOnly, last.updated[:min(len(last.updated), len(time.DateOnly))]); err == nil && day.After(anchor) {
+			anchor, page = day, 1
+		} else if len(fresh) == 0 {
+			page++
+		}
+	}
+
+	return total, fmt.Errorf("updated vulnerabilities not caught up after %d records, last %s", total, last.id)
+}
+
+// fetchUpdatedPageWithRetry fetches a page of updated vulnerabilities, retrying transient failures.
+func fetchUpdatedPageWithRetry(source string, since time.Time, page int) ([]json.RawMessage, error) {
+	var lastErr error
+	for attempt := 1; attempt <= maxFetchAttempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("GCVE: fetching page %d again (attempt %d/%d) after: %v", page, attempt, maxFetchAttempts, lastErr)
+			time.Sleep(time.Duration(attempt) * retryDelay)
+		}
+		records, err := fetchUpdatedPage(source, since, page)
+		if err == nil {
+			return records, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// fetchUpdatedPage fetches a page of the vulnerabilities of a source modified since the given day. The since parameter has
+// a one-day granularity, re-processing the records of the day of the previous sync is harmless.
+// A zero since lists every record of the source.
+func fetchUpdatedPage(source string, since time.Time, page int) ([]json.RawMessage, error) {
+	query := url.Values{}
+	query.Set("source", source)
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.DateOnly))
+	}
+	query.Set("date_sort", "updated")
+	query.Set("sort_order", "asc")
+	query.Set("page", strconv.Itoa(page))
+	query.Set("per_page", strconv.Itoa(batchSize))
+
+	req, err := http.NewRequest("GET", updatedAPI+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	apiKey := os.Getenv("VULNERABILITY_LOOKUP_API_KEY")
+	if apiKey != "" && apiKey != "!ChangeMe!" {
+		req.Header.Set("X-API-KEY", apiKey)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(body, &records); err == nil {
+		return records, nil
+	}
+
+	var wrapped updatedPage
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return wrapped.Data, nil
+}
@@ -0,0 +1,147 @@
+package gcve
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// serveUpdated serves count records, paged as the vulnerability-lookup API does.
+// When ignorePages is set every request returns the first page.
+func serveUpdated(t *testing.T, count int, ignorePages bool) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2024-03-01", r.URL.Query().Get("since"))
+		assert.Equal(t, "asc", r.URL.Query().Get("sort_order"))
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if ignorePages {
+			page = 1
+		}
+
+		data := []json.RawMessage{}
+		for i := (page - 1) * perPage; i < min(page*perPage, count); i++ {
+			data = append(data, json.RawMessage(fmt.Sprintf(`{"cveMetadata":{"cveId":"CVE-2024-%05d"}}`, i)))
+		}
+		response := map[string]any{"metadata": map[string]int{"count": count}, "data": data}
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	previous := updatedAPI
+	updatedAPI = server.URL + "/"
+	t.Cleanup(func() { updatedAPI = previous })
+}
+
+func TestWalkUpdated(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// More records than a single page, e.g. more than /api/last used to return
+	serveUpdated(t, 2*batchSize+42, false)
+	pages := 0
+	total, err := walkUpdated(updatedSource, since, func(records []json.RawMessage) error {
+		pages++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2*batchSize+42, total)
+	assert.Equal(t, 3, pages)
+}
+
+func TestWalkUpdatedExactPages(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	serveUpdated(t, 2*batchSize, false)
+	total, err := walkUpdated(updatedSource, since, func(records []json.RawMessage) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 2*batchSize, total)
+}
+
+func TestWalkUpdatedIgnoredPagination(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	serveUpdated(t, 3*batchSize, true)
+	_, err := walkUpdated(updatedSource, since, func(records []json.RawMessage) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestWalkUpdatedShiftingPages(t *testing.T) {
+	type record struct{ id, updated string }
+	var records []record
+	for i := 0; i < 2*batchSize+10; i++ {
+		records = append(records, record{fmt.Sprintf("CVE-2024-%05d", i), "2024-03-01T10:00:00"})
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// The first record is modified after the first page was read: it moves to the end of the
+		// listing, and the records of the next pages move back by one
+		if requests == 2 {
+			moved := records[0]
+			moved.updated = "2024-03-02T08:00:00"
+			records = append(records[1:], moved)
+		}
+
+		since := r.URL.Query().Get("since")
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		var listed []record
+		for _, record := range records {
+			if record.updated[:10] >= since {
+				listed = append(listed, record)
+			}
+		}
+
+		data := []json.RawMessage{}
+		for i := (page - 1) * perPage; i < min(page*perPage, len(listed)); i++ {
+			data = append(data, json.RawMessage(fmt.Sprintf(`{"cveMetadata":{"cveId":%q,"dateUpdated":%q}}`, listed[i].id, listed[i].updated)))
+		}
+		_ = json.NewEncoder(w).Encode(data)
+	}))
+	t.Cleanup(server.Close)
+	previous := updatedAPI
+	updatedAPI = server.URL + "/"
+	t.Cleanup(func() { updatedAPI = previous })
+
+	handled := make(map[string]int)
+	_, err := walkUpdated(updatedSource, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), func(records []json.RawMessage) error {
+		for _, data := range records {
+			handled[parseRecordKey(data).id]++
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Len(t, handled, 2*batchSize+10)
+	// The modified record is handled again with its new modification time
+	assert.Equal(t, 2, handled["CVE-2024-00000"])
+	assert.Equal(t, 1, handled[fmt.Sprintf("CVE-2024-%05d", batchSize)])
+}
+
+func TestSyncRecordsHoldsCursorOnFailedBatch(t *testing.T) {
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+	serveUpdated(t, 2*batchSize+42, false)
+
+	cursor, err := syncRecords(updatedSource, since, until, func(batch []cveRecord) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, until, cursor)
+
+	// The records after the failed batch are still processed, but the cursor stays put
+	batches := 0
+	cursor, err = syncRecords(updatedSource, since, until, func(batch []cveRecord) error {
+		batches++
+		if batches == 2 {
+			return fmt.Errorf("deadlock detected")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, batches)
+	assert.Equal(t, since, cursor)
+}
@@ -5,17 +5,18 @@ package gcve
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	config "github.com/CodeClarityCE/utility-types/config_db"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/google/uuid"
@@ -25,10 +26,18 @@ import (
 const (
 	bulkDumpURL     = "https://vulnerability.circl.lu/dumps/cvelistv5.ndjson"
 	vulnrichmentURL = "https://vulnerability.circl.lu/dumps/vulnrichment.ndjson"
-	lastUpdatedAPI  = "https://vulnerability.circl.lu/api/last"
 	batchSize       = 100
+
+	// stateRejected is the cveMetadata.state of CVEs rejected by their CNA
+	stateRejected = "REJECTED"
 )
 
+// retryDelay is the delay before the second attempt of a request or a batch, later attempts wait longer
+var retryDelay = 10 * time.Second
+
+// maxBatchAttempts is the number of times a batch is processed before giving up
+const maxBatchAttempts = 3
+
 // httpClient is a shared HTTP client with timeouts for all GCVE requests.
 // Bulk downloads are ~4.7GB so the timeout must be generous.
 var httpClient = &http.Client{
@@ -46,6 +55,11 @@ func Update(db *bun.DB, db_config *bun.DB) error {
 		return err
 	}
 
+	// Advisories issued directly by GNAs are synced on their own cursors
+	if err := syncGNASources(db); err != nil {
+		log.Printf("GCVE GNA sources sync failed (non-fatal): %v", err)
+	}
+
 	if conf.GcveLast.IsZero() {
 		log.Println("No previous GCVE sync, performing full bulk import")
 		if err := bulkImport(db); err != nil {
@@ -73,15 +87,19 @@ func Update(db *bun.DB, db_config *bun.DB) error {
 		return setLastGCVESync(db_config, conf)
 	}
 
-	// Incremental update via API
-	if err := incrementalUpdate(db, conf.GcveLast); err != nil {
+	// Incremental update via API. The cursor is only advanced to the start of this sync
+	// once everything changed since the previous one has been processed.
+	syncStart := time.Now()
+	cursor, err := incrementalUpdate(db, conf.GcveLast, syncStart)
+	if err != nil {
 		log.Printf("GCVE incremental update failed, falling back to bulk import: %v", err)
 		if err := bulkImport(db); err != nil {
 			return fmt.Errorf("GCVE fallback bulk import failed: %w", err)
 		}
+		cursor = syncStart
 	}
 
-	conf.GcveLast = time.Now()
+	conf.GcveLast = cursor
 	return setLastGCVESync(db_config, conf)
 }
 
@@ -109,89 +127,81 @@ func setLastGCVESync(db_config *bun.DB, conf config.Config) error {
 
 // bulkImport downloads and processes the cvelistv5 NDJSON dump.
 func bulkImport(db *bun.DB) error {
-	log.Println("Downloading cvelistv5 bulk dump...")
-
-	resp, err := httpClient.Get(bulkDumpURL)
-	if err != nil {
-		return fmt.Errorf("failed to download bulk dump: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	return processNDJSONStream(db, resp.Body)
+	return importDump(db, "cvelistv5", bulkDumpURL)
 }
 
 // importVulnrichment downloads and merges CISA ADP enrichment data.
 func importVulnrichment(db *bun.DB) error {
-	log.Println("Downloading vulnrichment dump...")
-
-	resp, err := httpClient.Get(vulnrichmentURL)
-	if err != nil {
-		return fmt.Errorf("failed to download vulnrichment dump: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	return processNDJSONStream(db, resp.Body)
+	return importDump(db, "vulnrichment", vulnrichmentURL)
 }
 
 // processNDJSONStream reads an NDJSON stream line by line and processes in batches.
-func processNDJSONStream(db *bun.DB, reader io.Reader) error {
-	scanner := bufio.NewScanner(reader)
-	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024) // 10MB max line
+// After every batch, checkpoint is called with the number of bytes and records of the stream
+// consumed so far, so that an interrupted import can resume after the last committed batch.
+func processNDJSONStream(db *bun.DB, reader io.Reader, checkpoint func(consumed int64, records int) error) error {
+	buffered := bufio.NewReaderSize(reader, 1024*1024)
 
-	var batch []knowledge.GCVEItem
+	var batch []cveRecord
+	var consumed int64
 	totalProcessed := 0
 	parseErrors := 0
 	rejected := 0
 
-	for scanner.Scan() {
-		line := scanner.Bytes()
-		if len(line) == 0 {
-			continue
+	// The checkpoint only moves past a batch once it is committed, a batch that keeps failing
+	// stops the import so that the next run resumes with it
+	flush := func() error {
+		if len(batch) > 0 {
+			if err := processBatchWithRetry(db, batch); err != nil {
+				return fmt.Errorf("failed to process GCVE batch after %d records: %w", totalProcessed, err)
+			}
+			totalProcessed += len(batch)
+			batch = batch[:0]
 		}
+		return checkpoint(consumed, totalProcessed)
+	}
 
-		item, err := parseCVERecord(line)
-		if err != nil {
-			parseErrors++
-			continue
+	for {
+		line, readErr := buffered.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
 		}
-		if item == nil {
-			rejected++
-			continue // Skip REJECTED records
+		// A truncated last line is only consumed at the end of the stream
+		consumed += int64(len(line))
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			record, err := parseCVERecord(line)
+			if err != nil {
+				parseErrors++
+			} else {
+				if record.Item.State == stateRejected {
+					rejected++ // Kept in the batch so processBatch can withdraw it
+				}
+				batch = append(batch, *record)
+			}
 		}
 
-		batch = append(batch, *item)
-
 		if len(batch) >= batchSize {
-			if err := processBatch(db, batch); err != nil {
-				log.Printf("Error processing GCVE batch: %v", err)
+			if err := flush(); err != nil {
+				return err
 			}
-			totalProcessed += len(batch)
-			batch = batch[:0]
-
 			if totalProcessed%10000 == 0 {
 				log.Printf("GCVE: processed %d records", totalProcessed)
 			}
 		}
+
+		if readErr == io.EOF {
+			break
+		}
 	}
 
 	// Process remaining
-	if len(batch) > 0 {
-		if err := processBatch(db, batch); err != nil {
-			log.Printf("Error processing final GCVE batch: %v", err)
-		}
-		totalProcessed += len(batch)
+	if err := flush(); err != nil {
+		return err
 	}
 
 	log.Printf("GCVE: total %d CVE records processed, %d parse errors, %d rejected", totalProcessed, parseErrors, rejected)
-	return scanner.Err()
+	return nil
 }
 
 // CVERecordRaw represents the raw JSON structure from the NDJSON dump.
@@ -200,6 +210,7 @@ type CVERecordRaw struct {
 	DataVersion string `json:"dataVersion"`
 	CveMetadata struct {
 		CveId             string `json:"cveId"`
+		VulnId            string `json:"vulnId"`
 		AssignerOrgId     string `json:"assignerOrgId"`
 		AssignerShortName string `json:"assignerShortName"`
 		State             string `json:"state"`
@@ -224,6 +235,9 @@ type CNAContainer struct {
 type RawAffected struct {
 	Vendor        string                  `json:"vendor"`
 	Product       string                  `json:"product"`
+	CollectionURL string                  `json:"collectionURL,omitempty"`
+	PackageName   string                  `json:"packageName,omitempty"`
+	Repo          string                  `json:"repo,omitempty"`
 	DefaultStatus string                  `json:"defaultStatus,omitempty"`
 	Versions      []knowledge.GCVEVersion `json:"versions"`
 	Platforms     []string                `json:"platforms,omitempty"`
@@ -241,6 +255,7 @@ type ADPContainer struct {
 
 // cveToGCVEId converts a CVE ID (e.g., "CVE-2024-1234") to GCVE format
 // (e.g., "GCVE-0-2024-1234") using GNA ID 0 for legacy CVE identifiers.
+// GCVE IDs issued by other GNAs are returned as is.
 func cveToGCVEId(cveId string) string {
 	if strings.HasPrefix(cveId, "CVE-") {
 		return "GCVE-0-" + cveId[4:]
@@ -248,21 +263,27 @@ func cveToGCVEId(cveId string) string {
 	return cveId
 }
 
-// parseCVERecord transforms the raw CVE Record v5.x JSON into a GCVEItem.
-func parseCVERecord(data []byte) (*knowledge.GCVEItem, error) {
+// cveRecord is a parsed CVE record: the GCVEItem stored in the shared gcve table, along with
+// what this model can't hold.
+type cveRecord struct {
+	Item knowledge.GCVEItem
+	// Decisions are the SSVC decisions of the ADP containers
+	Decisions []types.GCVESSVC
+	// Packages are the packages of the CNA and ADP affected entries
+	Packages []affectedPackage
+}
+
+// parseCVERecord transforms the raw CVE Record v5.x JSON into a GCVEItem, along with the
+// SSVC decisions and the affected packages of its containers.
+// REJECTED records are returned too, processBatch withdraws them instead of storing them.
+func parseCVERecord(data []byte) (*cveRecord, error) {
 	var raw CVERecordRaw
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return nil, err
 	}
 
-	// Skip REJECTED records
-	if raw.CveMetadata.State == "REJECTED" {
-		return nil, nil
-	}
-
+	var packages []affectedPackage
 	item := &knowledge.GCVEItem{
-		GCVEId:        cveToGCVEId(raw.CveMetadata.CveId),
-		CVEId:         raw.CveMetadata.CveId,
 		DataVersion:   raw.DataVersion,
 		State:         raw.CveMetadata.State,
 		DatePublished: raw.CveMetadata.DatePublished,
@@ -282,6 +303,7 @@ func parseCVERecord(data []byte) (*knowledge.GCVEItem, error) {
 			for _, rawAff := range cna.Affected {
 				var aff RawAffected
 				if err := json.Unmarshal(rawAff, &aff); err == nil {
+					packages = appendAffectedPackage(packages, aff)
 					item.Affected = append(item.Affected, knowledge.GCVEAffected{
 						Vendor:        aff.Vendor,
 						Product:       aff.Product,
@@ -300,7 +322,18 @@ func parseCVERecord(data []byte) (*knowledge.GCVEItem, error) {
 		}
 	}
 
+	// Identify the record, advisories of other GNAs are linked to their CVE alias if any
+	recordId := raw.CveMetadata.CveId
+	if recordId == "" {
+		recordId = raw.CveMetadata.VulnId
+	}
+	item.GCVEId, item.CVEId = recordIds(recordId, item.References)
+	if item.GCVEId == "" {
+		return nil, fmt.Errorf("record without identifier")
+	}
+
 	// Parse ADP enrichments
+	var decisions []types.GCVESSVC
 	for _, rawAdp := range raw.Containers.ADP {
 		var adp ADPContainer
 		if err := json.Unmarshal(rawAdp, &adp); err == nil {
@@ -313,6 +346,7 @@ func parseCVERecord(data []byte) (*knowledge.GCVEItem, error) {
 
 			// Parse ADP affected (may contain additional CPE data)
 			for _, aff := range adp.Affected {
+				packages = appendAffectedPackage(packages, aff)
 				gcveAdp.Affected = append(gcveAdp.Affected, knowledge.GCVEAffected{
 					Vendor:        aff.Vendor,
 					Product:       aff.Product,
@@ -323,6 +357,15 @@ func parseCVERecord(data []byte) (*knowledge.GCVEItem, error) {
 			}
 
 			item.ADPEnrichments = append(item.ADPEnrichments, gcveAdp)
+
+			// SSVC and KEV are "other" metrics, which GCVEMetricEntry doesn't keep
+			provider := adp.ProviderMetadata.ShortName
+			if provider == "" {
+				provider = adp.ProviderMetadata.OrgId
+			}
+			if decision := parseSSVC(item.GCVEId, item.CVEId, provider, adp.Metrics); decision != nil {
+				decisions = append(decisions, *decision)
+			}
 		}
 	}
 
@@ -361,7 +404,7 @@ func parseCVERecord(data []byte) (*knowledge.GCVEItem, error) {
 		item.Cwes = []string{}
 	}
 
-	return item, nil
+	return &cveRecord{Item: *item, Decisions: decisions, Packages: packages}, nil
 }
 
 // parseMetrics parses raw metric JSON entries into typed GCVEMetricEntry structs.
@@ -417,153 +460,117 @@ func buildAffectedFlattened(affected []knowledge.GCVEAffected) []knowledge.GCVEP
 	return products
 }
 
-// processBatch inserts GCVE records and creates package-vulnerability links.
-func processBatch(db *bun.DB, batch []knowledge.GCVEItem) error {
+// processBatch inserts GCVE records, their SSVC decisions and creates package-vulnerability links.
+func processBatch(db *bun.DB, records []cveRecord) error {
+	// Step 0: Withdraw the records that were rejected since they were imported
+	var batch []knowledge.GCVEItem
+	var published []cveRecord
+	var decisions []types.GCVESSVC
+	var rejectedIds, publishedIds []string
+	for _, record := range records {
+		decisions = append(decisions, record.Decisions...)
+		if record.Item.State == stateRejected {
+			rejectedIds = append(rejectedIds, record.Item.GCVEId)
+		} else {
+			batch = append(batch, record.Item)
+			published = append(published, record)
+			publishedIds = append(publishedIds, record.Item.GCVEId)
+		}
+	}
+
+	retractions, err := pgsql.WithdrawGcve(db, rejectedIds)
+	if err != nil {
+		return fmt.Errorf("failed to withdraw rejected GCVE records: %w", err)
+	}
+	if len(retractions) > 0 {
+		log.Printf("GCVE: withdrew %d rejected records", len(retractions))
+		pgsql.SendRetractionNotifications(retractions)
+	}
+
+	// Rejected records lose their decisions along with the other records of the batch
+	if err := pgsql.ReplaceGcveSSVC(db, append(rejectedIds, publishedIds...), decisions); err != nil {
+		log.Printf("Error storing GCVE SSVC decisions: %v", err)
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
 	// Step 1: Upsert GCVE records
 	if err := pgsql.BatchUpdateGcve(db, batch); err != nil {
 		return fmt.Errorf("batch update failed: %w", err)
 	}
-
-	// Step 2: Get UUIDs for inserted records
-	gcveIds := make([]string, len(batch))
-	for i, item := range batch {
-		gcveIds[i] = item.GCVEId
+	if err := pgsql.RestoreGcve(db, publishedIds); err != nil {
+		return err
 	}
 
-	gcveIdToUUID, err := pgsql.GetGcveUUIDsByGcveIds(db, gcveIds)
+	// Step 2: Get UUIDs for inserted records
+	gcveIdToUUID, err := pgsql.GetGcveUUIDsByGcveIds(db, publishedIds)
 	if err != nil {
 		return fmt.Errorf("failed to get GCVE UUIDs: %w", err)
 	}
 
-	// Step 3: Extract and insert package-vulnerability relationships
-	pkgVulns := extractPackageVulnerabilities(batch, gcveIdToUUID)
-	if len(pkgVulns) > 0 {
-		if err := pgsql.BatchInsertGcvePackageVulnerabilities(db, pkgVulns); err != nil {
-			log.Printf("Error inserting GCVE package vulnerabilities: %v", err)
-		}
+	// Step 3: Replace the package-vulnerability relationships of the records
+	gcveUUIDs := make([]uuid.UUID, 0, len(gcveIdToUUID))
+	for _, id := range gcveIdToUUID {
+		gcveUUIDs = append(gcveUUIDs, id)
+	}
+	pkgVulns := extractPackageVulnerabilities(published, gcveIdToUUID)
+	if err := pgsql.ReplaceGcvePackageVulnerabilities(db, gcveUUIDs, pkgVulns); err != nil {
+		log.Printf("Error replacing GCVE package vulnerabilities: %v", err)
+	}
+
+	// Step 4: Store normalized severity scores
+	if err := pgsql.ReplaceVulnerabilityScores(db, "gcve", publishedIds, extractScores(batch)); err != nil {
+		log.Printf("Error storing GCVE scores: %v", err)
 	}
 
 	return nil
 }
 
-// extractPackageVulnerabilities creates package-vulnerability junction records from GCVE items.
-func extractPackageVulnerabilities(gcveItems []knowledge.GCVEItem, gcveIdToUUID map[string]uuid.UUID) []knowledge.PackageVulnerability {
+// processBatchWithRetry processes a batch, retrying it when it fails, e.g. on a deadlock
+// or a lost connection.
+func processBatchWithRetry(db *bun.DB, records []cveRecord) error {
+	var err error
+	for attempt := 1; attempt <= maxBatchAttempts; attempt++ {
+		if attempt > 1 {
+			log.Printf("GCVE: processing batch again (attempt %d/%d) after: %v", attempt, maxBatchAttempts, err)
+			time.Sleep(time.Duration(attempt) * retryDelay)
+		}
+		if err = processBatch(db, records); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// extractPackageVulnerabilities creates package-vulnerability junction records from the
+// affected packages of CVE records, CNA and ADP ones alike.
+func extractPackageVulnerabilities(records []cveRecord, gcveIdToUUID map[string]uuid.UUID) []knowledge.PackageVulnerability {
 	var pkgVulns []knowledge.PackageVulnerability
 	seen := make(map[string]bool)
 
-	for _, item := range gcveItems {
-		gcveUUID, ok := gcveIdToUUID[item.GCVEId]
+	for _, record := range records {
+		gcveUUID, ok := gcveIdToUUID[record.Item.GCVEId]
 		if !ok {
 			continue
 		}
 
-		for _, aff := range item.Affected {
-			if aff.Product == "" || aff.Product == "*" {
-				continue
-			}
-
-			key := fmt.Sprintf("%s|gcve|%s", strings.ToLower(aff.Product), item.GCVEId)
+		for _, pkg := range record.Packages {
+			key := fmt.Sprintf("%s|%s|%s", pkg.Name, pkg.Ecosystem, record.Item.GCVEId)
 			if seen[key] {
 				continue
 			}
 			seen[key] = true
 
 			pkgVuln := knowledge.PackageVulnerability{
-				PackageName:      strings.ToLower(aff.Product),
-				PackageEcosystem: "gcve",
+				PackageName:      pkg.Name,
+				PackageEcosystem: pkg.Ecosystem,
 				GcveId:           &gcveUUID,
 			}
 			pkgVulns = append(pkgVulns, pkgVuln)
 		}
-
-		// Also index ADP affected products
-		for _, adp := range item.ADPEnrichments {
-			for _, aff := range adp.Affected {
-				if aff.Product == "" || aff.Product == "*" {
-					continue
-				}
-
-				key := fmt.Sprintf("%s|gcve|%s", strings.ToLower(aff.Product), item.GCVEId)
-				if seen[key] {
-					continue
-				}
-				seen[key] = true
-
-				pkgVuln := knowledge.PackageVulnerability{
-					PackageName:      strings.ToLower(aff.Product),
-					PackageEcosystem: "gcve",
-					GcveId:           &gcveUUID,
-				}
-				pkgVulns = append(pkgVulns, pkgVuln)
-			}
-		}
 	}
 
 	return pkgVulns
 }
-
-// incrementalUpdate fetches recently modified vulnerabilities via the API.
-func incrementalUpdate(db *bun.DB, since time.Time) error {
-	log.Printf("GCVE incremental update since %s", since.Format(time.RFC3339))
-
-	apiKey := os.Getenv("VULNERABILITY_LOOKUP_API_KEY")
-
-	req, err := http.NewRequest("GET", lastUpdatedAPI, nil)
-	if err != nil {
-		return err
-	}
-	if apiKey != "" && apiKey != "!ChangeMe!" {
-		req.Header.Set("X-API-KEY", apiKey)
-	}
-
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to fetch recent vulnerabilities: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	// The /api/last endpoint returns an array of vulnerability records
-	var records []json.RawMessage
-	if err := json.Unmarshal(body, &records); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	var batch []knowledge.GCVEItem
-	processed := 0
-
-	for _, record := range records {
-		item, err := parseCVERecord(record)
-		if err != nil || item == nil {
-			continue
-		}
-
-		batch = append(batch, *item)
-
-		if len(batch) >= batchSize {
-			if err := processBatch(db, batch); err != nil {
-				log.Printf("Error processing incremental GCVE batch: %v", err)
-			}
-			processed += len(batch)
-			batch = batch[:0]
-		}
-	}
-
-	if len(batch) > 0 {
-		if err := processBatch(db, batch); err != nil {
-			log.Printf("Error processing final incremental GCVE batch: %v", err)
-		}
-		processed += len(batch)
-	}
-
-	log.Printf("GCVE incremental update: %d records processed", processed)
-	return nil
-}
@@ -0,0 +1,101 @@
+package gcve
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cpemap"
+)
+
+// ecosystemFallback files the affected products that don't identify a registry package.
+const ecosystemFallback = "gcve"
+
+// registryHosts maps the collectionURL hosts of CVE JSON 5 affected entries to ecosystems.
+var registryHosts = map[string]string{
+	"registry.npmjs.org":     cpemap.EcosystemNpm,
+	"npmjs.com":              cpemap.EcosystemNpm,
+	"www.npmjs.com":          cpemap.EcosystemNpm,
+	"packagist.org":          cpemap.EcosystemPackagist,
+	"repo.packagist.org":     cpemap.EcosystemPackagist,
+	"pypi.org":               cpemap.EcosystemPyPI,
+	"pypi.python.org":        cpemap.EcosystemPyPI,
+	"upload.pypi.org":        cpemap.EcosystemPyPI,
+	"files.pythonhosted.org": cpemap.EcosystemPyPI,
+	"repo.maven.apache.org":  cpemap.EcosystemMaven,
+	"repo1.maven.org":        cpemap.EcosystemMaven,
+	"search.maven.org":       cpemap.EcosystemMaven,
+	"central.sonatype.com":   cpemap.EcosystemMaven,
+}
+
+var pypiSeparators = regexp.MustCompile(`[-_.]+`)
+
+// affectedPackage is a package affected by a CVE record.
+type affectedPackage struct {
+	Ecosystem string
+	Name      string
+}
+
+// appendAffectedPackage appends the package of an affected entry. Entries whose collectionURL
+// is a known registry are filed under its ecosystem with their packageName, the others under
+// "gcve" with their product, as vendor records can't be told apart from registry packages.
+func appendAffectedPackage(packages []affectedPackage, aff RawAffected) []affectedPackage {
+	if ecosystem := registryEcosystem(aff.CollectionURL); ecosystem != "" && aff.PackageName != "" {
+		return append(packages, affectedPackage{
+			Ecosystem: ecosystem,
+			Name:      normalizePackageName(ecosystem, aff.PackageName),
+		})
+	}
+
+	name := aff.Product
+	if name == "" || name == "*" {
+		name = aff.PackageName
+	}
+	if name == "" || name == "*" {
+		name = repoName(aff.Repo)
+	}
+	if name == "" || name == "*" {
+		return packages
+	}
+	return append(packages, affectedPackage{
+		Ecosystem: ecosystemFallback,
+		Name:      strings.ToLower(name),
+	})
+}
+
+// registryEcosystem returns the ecosystem of a collectionURL, or "" if it isn't a known registry.
+func registryEcosystem(collectionURL string) string {
+	if collectionURL == "" {
+		return ""
+	}
+	parsed, err := url.Parse(strings.TrimSpace(collectionURL))
+	if err != nil {
+		return ""
+	}
+	return registryHosts[strings.ToLower(parsed.Hostname())]
+}
+
+// normalizePackageName returns the name of a package as the registry indexes it.
+func normalizePackageName(ecosystem, name string) string {
+	name = strings.TrimSpace(name)
+	switch ecosystem {
+	case cpemap.EcosystemNpm, cpemap.EcosystemPackagist:
+		return strings.ToLower(name)
+	case cpemap.EcosystemPyPI:
+		// https://peps.python.org/pep-0503/#normalized-names
+		return pypiSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	default:
+		// Maven coordinates (groupId:artifactId) are case sensitive
+		return name
+	}
+}
+
+// repoName returns the name of the repository of a repo URL, e.g. "express" for
+// https://github.com/expressjs/express.git.
+func repoName(repo string) string {
+	repo = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(repo), "/"), ".git")
+	if i := strings.LastIndex(repo, "/"); i >= 0 {
+		return repo[i+1:]
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package gcve
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendAffectedPackage(t *testing.T) {
+	var packages []affectedPackage
+
+	packages = appendAffectedPackage(packages, RawAffected{Vendor: "expressjs", Product: "express", CollectionURL: "https://registry.npmjs.org", PackageName: "express"})
+	packages = appendAffectedPackage(packages, RawAffected{Vendor: "Acme", Product: "Express"})
+	packages = appendAffectedPackage(packages, RawAffected{Product: "twig", CollectionURL: "https://packagist.org", PackageName: "Twig/Twig"})
+	packages = appendAffectedPackage(packages, RawAffected{Product: "Django", CollectionURL: "https://pypi.org/simple", PackageName: "Django_Rest.Framework"})
+	packages = appendAffectedPackage(packages, RawAffected{Product: "log4j-core", CollectionURL: "https://repo.maven.apache.org/maven2", PackageName: "org.apache.logging.log4j:log4j-core"})
+	// A registry without packageName can't be linked to a package
+	packages = appendAffectedPackage(packages, RawAffected{Product: "lodash", CollectionURL: "https://registry.npmjs.org"})
+	// Unknown collection
+	packages = appendAffectedPackage(packages, RawAffected{Product: "*", CollectionURL: "https://example.org", PackageName: "Widget"})
+	packages = appendAffectedPackage(packages, RawAffected{Product: "*", Repo: "https://github.com/acme/Gadget.git"})
+	packages = appendAffectedPackage(packages, RawAffected{Product: "*"})
+
+	assert.Equal(t, []affectedPackage{
+		{Ecosystem: "npm", Name: "express"},
+		{Ecosystem: "gcve", Name: "express"},
+		{Ecosystem: "packagist", Name: "twig/twig"},
+		{Ecosystem: "pypi", Name: "django-rest-framework"},
+		{Ecosystem: "maven", Name: "org.apache.logging.log4j:log4j-core"},
+		{Ecosystem: "gcve", Name: "lodash"},
+		{Ecosystem: "gcve", Name: "widget"},
+		{Ecosystem: "gcve", Name: "gadget"},
+	}, packages)
+}
+
+func TestExtractPackageVulnerabilities(t *testing.T) {
+	record, err := parseCVERecord([]byte(`{
+		"cveMetadata": {"cveId": "CVE-2024-43796", "state": "PUBLISHED"},
+		"containers": {
+			"cna": {"affected": [{"vendor": "expressjs", "product": "express", "collectionURL": "https://registry.npmjs.org", "packageName": "express"}]},
+			"adp": [{"affected": [
+				{"vendor": "expressjs", "product": "express", "collectionURL": "https://registry.npmjs.org", "packageName": "express"},
+				{"vendor": "openjsf", "product": "express"}
+			]}]
+		}
+	}`))
+	assert.NoError(t, err)
+
+	id := uuid.New()
+	pkgVulns := extractPackageVulnerabilities([]cveRecord{*record}, map[string]uuid.UUID{"GCVE-0-2024-43796": id})
+
+	assert.Len(t, pkgVulns, 2)
+	assert.Equal(t, "npm", pkgVulns[0].PackageEcosystem)
+	assert.Equal(t, "express", pkgVulns[0].PackageName)
+	assert.Equal(t, "gcve", pkgVulns[1].PackageEcosystem)
+	assert.Equal(t, id, *pkgVulns[1].GcveId)
+}
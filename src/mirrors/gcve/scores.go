@@ -0,0 +1,48 @@
+package gcve
+
+import (
+	"log"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cvss"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// extractScores normalizes the CVSS metrics of the CNA and ADP containers of GCVE items.
+// CNA metrics are attributed to the assigner, ADP metrics to the ADP (e.g. CISA-ADP).
+func extractScores(gcveItems []knowledge.GCVEItem) []types.VulnerabilityScore {
+	var scores []types.VulnerabilityScore
+
+	add := func(gcveId, provider string, metrics []knowledge.GCVEMetricEntry) {
+		for _, metric := range metrics {
+			for _, cvssScore := range []*knowledge.GCVECvssScore{metric.CvssV40, metric.CvssV31, metric.CvssV30, metric.CvssV2} {
+				if cvssScore == nil || cvssScore.VectorString == "" {
+					continue
+				}
+				var provided *float64
+				if cvssScore.BaseScore > 0 {
+					provided = &cvssScore.BaseScore
+				}
+				score, err := cvss.NewScore("gcve", gcveId, provider, cvssScore.VectorString, provided)
+				if err != nil {
+					log.Printf("Skipping CVSS vector of %s: %v", gcveId, err)
+					continue
+				}
+				scores = append(scores, score)
+			}
+		}
+	}
+
+	for _, item := range gcveItems {
+		add(item.GCVEId, item.AssignerOrgId, item.Metrics)
+		for _, adp := range item.ADPEnrichments {
+			provider := adp.ShortName
+			if provider == "" {
+				provider = adp.ProviderOrgId
+			}
+			add(item.GCVEId, provider, adp.Metrics)
+		}
+	}
+
+	return scores
+}
@@ -0,0 +1,93 @@
+package gcve
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+)
+
+// otherMetric is a metrics entry of an ADP container that isn't a CVSS score,
+// e.g. the SSVC decision and KEV marker of CISA-ADP vulnrichment.
+type otherMetric struct {
+	Other *struct {
+		Type    string          `json:"type"`
+		Content json.RawMessage `json:"content"`
+	} `json:"other"`
+}
+
+type ssvcContent struct {
+	Timestamp string              `json:"timestamp"`
+	Id        string              `json:"id"`
+	Options   []map[string]string `json:"options"`
+	Role      string              `json:"role"`
+	Version   string              `json:"version"`
+}
+
+type kevContent struct {
+	DateAdded string `json:"dateAdded"`
+	Reference string `json:"reference"`
+}
+
+// parseSSVC extracts the SSVC decision and KEV marker of an ADP container from its metrics.
+// It returns nil when the container has neither.
+func parseSSVC(gcveId, cveId, provider string, rawMetrics []json.RawMessage) *types.GCVESSVC {
+	decision := types.GCVESSVC{
+		GCVEId:   gcveId,
+		CVEId:    cveId,
+		Provider: provider,
+	}
+	found := false
+
+	for _, raw := range rawMetrics {
+		var metric otherMetric
+		if err := json.Unmarshal(raw, &metric); err != nil || metric.Other == nil {
+			continue
+		}
+
+		switch strings.ToLower(metric.Other.Type) {
+		case "ssvc":
+			var content ssvcContent
+			if err := json.Unmarshal(metric.Other.Content, &content); err != nil {
+				continue
+			}
+			found = true
+			decision.Role = content.Role
+			decision.Version = content.Version
+			if assessedAt, err := time.Parse(time.RFC3339Nano, content.Timestamp); err == nil {
+				decision.AssessedAt = assessedAt
+			}
+			// Options are single-key objects, e.g. {"Exploitation": "active"}
+			for _, option := range content.Options {
+				for key, value := range option {
+					value = strings.ToLower(strings.TrimSpace(value))
+					switch strings.ToLower(strings.TrimSpace(key)) {
+					case "exploitation":
+						decision.Exploitation = value
+					case "automatable":
+						decision.Automatable = value
+					case "technical impact":
+						decision.TechnicalImpact = value
+					}
+				}
+			}
+		case "kev":
+			var content kevContent
+			if err := json.Unmarshal(metric.Other.Content, &content); err != nil {
+				continue
+			}
+			found = true
+			decision.KEV = true
+			decision.KEVReference = content.Reference
+			if dateAdded, err := time.Parse(time.DateOnly, content.DateAdded); err == nil {
+				decision.KEVDateAdded = dateAdded
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return &decision
+}
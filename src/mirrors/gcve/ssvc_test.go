@@ -0,0 +1,66 @@
+package gcve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const vulnrichmentRecord = `{
+	"dataType": "CVE_RECORD",
+	"dataVersion": "5.1",
+	"cveMetadata": {"cveId": "CVE-2021-44228", "state": "PUBLISHED"},
+	"containers": {
+		"cna": {"affected": [{"vendor": "Apache Software Foundation", "product": "Apache Log4j2"}]},
+		"adp": [
+			{
+				"providerMetadata": {"orgId": "134c704f-9b21-4f2e-91b3-4a467353bcc0", "shortName": "CISA-ADP"},
+				"title": "CISA ADP Vulnrichment",
+				"metrics": [
+					{"cvssV3_1": {"version": "3.1", "baseScore": 10, "vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"}},
+					{"other": {"type": "ssvc", "content": {
+						"timestamp": "2024-05-30T13:51:44.958358Z",
+						"id": "CVE-2021-44228",
+						"options": [{"Exploitation": "Active"}, {"Automatable": "yes"}, {"Technical Impact": "total"}],
+						"role": "CISA Coordinator",
+						"version": "2.0.3"
+					}}},
+					{"other": {"type": "kev", "content": {"dateAdded": "2021-12-10", "reference": "https://www.cisa.gov/known-exploited-vulnerabilities-catalog"}}}
+				]
+			},
+			{
+				"providerMetadata": {"orgId": "af854a3a-2127-422b-91ae-364da2661108", "shortName": "CVE"},
+				"title": "CVE Program Container",
+				"references": [{"url": "https://logging.apache.org/log4j/2.x/security.html"}]
+			}
+		]
+	}
+}`
+
+func TestParseCVERecordSSVC(t *testing.T) {
+	record, err := parseCVERecord([]byte(vulnrichmentRecord))
+	assert.NoError(t, err)
+	item, decisions := record.Item, record.Decisions
+	assert.Equal(t, "GCVE-0-2021-44228", item.GCVEId)
+
+	// Only the CISA-ADP container carries a decision
+	assert.Len(t, decisions, 1)
+	decision := decisions[0]
+	assert.Equal(t, "GCVE-0-2021-44228", decision.GCVEId)
+	assert.Equal(t, "CVE-2021-44228", decision.CVEId)
+	assert.Equal(t, "CISA-ADP", decision.Provider)
+	assert.Equal(t, "active", decision.Exploitation)
+	assert.Equal(t, "yes", decision.Automatable)
+	assert.Equal(t, "total", decision.TechnicalImpact)
+	assert.Equal(t, "2.0.3", decision.Version)
+	assert.Equal(t, 2024, decision.AssessedAt.Year())
+	assert.True(t, decision.KEV)
+	assert.Equal(t, "2021-12-10", decision.KEVDateAdded.Format("2006-01-02"))
+
+	// The CVSS metric of the container is still kept
+	assert.NotNil(t, item.ADPEnrichments[0].Metrics[0].CvssV31)
+}
+
+func TestParseSSVCWithoutOtherMetrics(t *testing.T) {
+	assert.Nil(t, parseSSVC("GCVE-0-2024-0001", "CVE-2024-0001", "CISA-ADP", nil))
+}
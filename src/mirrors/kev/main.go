@@ -0,0 +1,131 @@
+// Package kev mirrors the CISA Known Exploited Vulnerabilities (KEV) catalog in the knowledge database.
+package kev
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+const defaultURL = "https://www.cisa.gov/sites/default/files/feeds/known_exploited_vulnerabilities.json"
+
+// Catalog is the KEV catalog JSON feed.
+type Catalog struct {
+	Title           string         `json:"title"`
+	CatalogVersion  string         `json:"catalogVersion"`
+	DateReleased    string         `json:"dateReleased"`
+	Count           int            `json:"count"`
+	Vulnerabilities []CatalogEntry `json:"vulnerabilities"`
+}
+
+type CatalogEntry struct {
+	CVEId                      string   `json:"cveID"`
+	VendorProject              string   `json:"vendorProject"`
+	Product                    string   `json:"product"`
+	VulnerabilityName          string   `json:"vulnerabilityName"`
+	DateAdded                  string   `json:"dateAdded"`
+	ShortDescription           string   `json:"shortDescription"`
+	RequiredAction             string   `json:"requiredAction"`
+	DueDate                    string   `json:"dueDate"`
+	KnownRansomwareCampaignUse string   `json:"knownRansomwareCampaignUse"`
+	Notes                      string   `json:"notes"`
+	Cwes                       []string `json:"cwes"`
+}
+
+// Update downloads the KEV catalog and replaces the kev table with its entries.
+// The catalog is read from the KEV_FILE path when set, otherwise from KEV_URL or the CISA feed.
+func Update(db *bun.DB) error {
+	log.Println("Start updating CISA KEV catalog")
+
+	data, err := readCatalog()
+	if err != nil {
+		return err
+	}
+
+	entries, err := parseCatalog(data)
+	if err != nil {
+		return err
+	}
+
+	err = pgsql.UpdateKEV(db, entries)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("CISA KEV catalog updated: %d entries", len(entries))
+	return nil
+}
+
+func readCatalog() ([]byte, error) {
+	if path := os.Getenv("KEV_FILE"); path != "" {
+		return os.ReadFile(path)
+	}
+
+	url := os.Getenv("KEV_URL")
+	if url == "" {
+		url = defaultURL
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch KEV catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch KEV catalog: HTTP %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseCatalog converts the KEV catalog JSON into database entries.
+func parseCatalog(data []byte) ([]types.KEVEntry, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to parse KEV catalog: %w", err)
+	}
+
+	entries := make([]types.KEVEntry, 0, len(catalog.Vulnerabilities))
+	for _, vuln := range catalog.Vulnerabilities {
+		if vuln.CVEId == "" {
+			continue
+		}
+
+		dateAdded, err := time.Parse(time.DateOnly, vuln.DateAdded)
+		if err != nil {
+			log.Printf("Invalid KEV date added %q for %s", vuln.DateAdded, vuln.CVEId)
+			continue
+		}
+		// The due date is informative, a missing one is kept empty
+		dueDate, _ := time.Parse(time.DateOnly, vuln.DueDate)
+
+		entries = append(entries, types.KEVEntry{
+			CVEId:                      vuln.CVEId,
+			VendorProject:              vuln.VendorProject,
+			Product:                    vuln.Product,
+			VulnerabilityName:          vuln.VulnerabilityName,
+			DateAdded:                  dateAdded,
+			ShortDescription:           vuln.ShortDescription,
+			RequiredAction:             vuln.RequiredAction,
+			DueDate:                    dueDate,
+			KnownRansomwareCampaignUse: vuln.KnownRansomwareCampaignUse == "Known",
+			Notes:                      vuln.Notes,
+			Cwes:                       vuln.Cwes,
+			CatalogVersion:             catalog.CatalogVersion,
+		})
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("KEV catalog %s has no entries", catalog.CatalogVersion)
+	}
+	return entries, nil
+}
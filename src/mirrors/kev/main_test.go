@@ -0,0 +1,49 @@
+package kev
+
+import (
+	"testing"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/testhelper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdate(t *testing.T) {
+	db, cleanup := testhelper.SetupKnowledgeTestDB(t)
+	if db == nil {
+		return // Test was skipped
+	}
+	defer cleanup()
+
+	err := Update(db)
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+}
+
+func TestParseCatalog(t *testing.T) {
+	entries, err := parseCatalog([]byte(`{
+		"catalogVersion": "2025.06.01",
+		"vulnerabilities": [
+			{
+				"cveID": "CVE-2021-44228",
+				"vendorProject": "Apache",
+				"product": "Log4j2",
+				"vulnerabilityName": "Apache Log4j2 Remote Code Execution Vulnerability",
+				"dateAdded": "2021-12-10",
+				"dueDate": "2021-12-24",
+				"knownRansomwareCampaignUse": "Known",
+				"notes": "https://nvd.nist.gov/vuln/detail/CVE-2021-44228",
+				"cwes": ["CWE-20", "CWE-400", "CWE-502"]
+			},
+			{"cveID": "CVE-2024-0001", "dateAdded": "not a date"}
+		]
+	}`))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "CVE-2021-44228", entries[0].CVEId)
+	assert.Equal(t, time.Date(2021, 12, 10, 0, 0, 0, 0, time.UTC), entries[0].DateAdded)
+	assert.True(t, entries[0].KnownRansomwareCampaignUse)
+	assert.Equal(t, "2025.06.01", entries[0].CatalogVersion)
+	assert.Equal(t, []string{"CWE-20", "CWE-400", "CWE-502"}, entries[0].Cwes)
+}
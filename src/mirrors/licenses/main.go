@@ -4,30 +4,57 @@ package licenses
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensepolicy"
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 
 	"github.com/uptrace/bun"
 )
 
+// listURL is the JSON export of the SPDX license list: licenses.json, exceptions.json
+// and the details of every license and exception in the details/ and exceptions/ folders.
+const listURL = "https://raw.githubusercontent.com/spdx/license-list-data/main/json"
+
 // Update updates the licenses metadata in the provided graph.
-// It fetches the licenses from a remote source and updates them in the graph.
+// It fetches the licenses and the license exceptions with their texts from the SPDX license list,
+// or from SPDX_LICENSE_LIST_URL if set, classifies them with the license policy catalog overlaid
+// with the LICENSE_POLICY_FILE overrides if set, and updates them in the graph.
 // Returns an error if there is a problem when fetching or updating licenses.
 func Update(db *bun.DB) error {
 	log.Println("Start updating Licenses metadata")
 
+	// Load the policy first, an invalid overrides file shouldn't wait for the download
+	catalog, err := licensepolicy.LoadCatalog(os.Getenv("LICENSE_POLICY_FILE"))
+	if err != nil {
+		log.Print("Problem when loading the license policy")
+		return err
+	}
+	log.Printf("License policy catalog v%d (SPDX %s) loaded", catalog.Version, catalog.SPDXListVersion)
+
+	url := os.Getenv("SPDX_LICENSE_LIST_URL")
+	if url == "" {
+		url = listURL
+	}
+	url = strings.TrimSuffix(url, "/")
+
 	// Get licenses
-	licenses, err := downloadLicenses()
+	licenses, headers, err := downloadLicenses(url)
 	if err != nil {
 		log.Print("Problem when fetching licenses")
 		return err
 	}
 
-	licenses = addCodeClarityInfo(licenses)
+	licenses = catalog.Apply(licenses)
 
 	// Update licenses
 	err = pgsql.UpdateLicenses(db, licenses)
@@ -35,35 +62,163 @@ func Update(db *bun.DB) error {
 		log.Print("Problem when updating licenses")
 		return err
 	}
+	err = pgsql.UpdateLicenseHeaders(db, headers)
+	if err != nil {
+		log.Print("Problem when updating license headers")
+		return err
+	}
+
+	// Get license exceptions
+	exceptions, err := downloadExceptions(url)
+	if err != nil {
+		log.Print("Problem when fetching license exceptions")
+		return err
+	}
+
+	err = pgsql.UpdateLicenseExceptions(db, exceptions)
+	if err != nil {
+		log.Print("Problem when updating license exceptions")
+		return err
+	}
+
+	log.Printf("Updated %d licenses and %d license exceptions", len(licenses), len(exceptions))
+
 	return nil
 }
 
-// downloadLicenses fetches licenses from a remote source and returns them as a slice of types.License.
-// It makes an HTTP GET request to the specified URL and parses the response body as JSON.
-// Returns the licenses and an error if there is a problem when fetching or parsing the licenses.
-func downloadLicenses() ([]knowledge.License, error) {
-	// Get licenses from remote source
-	resp, err := http.Get("https://raw.githubusercontent.com/spdx/license-list-data/main/json/licenses.json")
+// LoadNormalizer replaces the embedded SPDX license list used to normalize the licenses of
+// packages by the licenses and exceptions mirrored in the database. The embedded list is kept
+// until the licenses have been mirrored once.
+func LoadNormalizer(db *bun.DB) error {
+	licenseIds, err := pgsql.GetLicenseIds(db)
 	if err != nil {
-		log.Println("No response from request")
-		return nil, err
+		return err
+	}
+	exceptionIds, err := pgsql.GetLicenseExceptionIds(db)
+	if err != nil {
+		return err
+	}
+	if len(licenseIds) == 0 || len(exceptionIds) == 0 {
+		return nil
 	}
-	defer resp.Body.Close()
 
-	// Parse response body
+	spdx.SetDefault(spdx.NewNormalizer(licenseIds, exceptionIds))
+	log.Printf("Normalizing licenses with %d mirrored licenses and %d license exceptions", len(licenseIds), len(exceptionIds))
+	return nil
+}
+
+// licenseDetails is the details JSON of a license, with the standard header the shared model doesn't hold.
+type licenseDetails struct {
+	knowledge.Details
+	StandardLicenseHeader         string `json:"standardLicenseHeader"`
+	StandardLicenseHeaderTemplate string `json:"standardLicenseHeaderTemplate"`
+}
+
+// exceptionList is the exceptions.json of the SPDX license list.
+type exceptionList struct {
+	LicenseListVersion string                   `json:"licenseListVersion"`
+	Exceptions         []types.LicenseException `json:"exceptions"`
+}
+
+// downloadLicenses fetches licenses from a remote source and returns them as a slice of types.License.
+// The details of every license, its texts and cross references, are fetched as well.
+// Returns the licenses, their standard headers and an error if there is a problem when fetching or parsing the licenses.
+func downloadLicenses(url string) ([]knowledge.License, []types.LicenseHeader, error) {
 	var result knowledge.LicenseList
-	body, err := io.ReadAll(resp.Body)
+	err := download(url+"/licenses.json", &result)
 	if err != nil {
-		log.Println("Can't read response body", err)
-		return nil, err
+		return nil, nil, err
+	}
+	licenses := result.Licenses
+
+	headers := make([]types.LicenseHeader, len(licenses))
+	downloadAll(len(licenses), func(i int) {
+		var details licenseDetails
+		err := download(url+"/details/"+licenses[i].LicenseID+".json", &details)
+		if err != nil {
+			log.Printf("Can't fetch the details of license %s: %v", licenses[i].LicenseID, err)
+			return
+		}
+		licenses[i].Details = details.Details
+		headers[i] = types.LicenseHeader{
+			LicenseID:      licenses[i].LicenseID,
+			Header:         details.StandardLicenseHeader,
+			HeaderTemplate: details.StandardLicenseHeaderTemplate,
+		}
+	})
+
+	// Licenses whose details couldn't be fetched keep their header
+	var fetched []types.LicenseHeader
+	for _, header := range headers {
+		if header.LicenseID != "" {
+			fetched = append(fetched, header)
+		}
 	}
+	return licenses, fetched, nil
+}
 
-	// Unmarshal response body
-	err = json.Unmarshal(body, &result)
+// downloadExceptions fetches the license exceptions and their texts.
+func downloadExceptions(url string) ([]types.LicenseException, error) {
+	var result exceptionList
+	err := download(url+"/exceptions.json", &result)
 	if err != nil {
-		log.Println(err)
 		return nil, err
 	}
+	exceptions := result.Exceptions
+
+	downloadAll(len(exceptions), func(i int) {
+		details := exceptions[i]
+		err := download(url+"/exceptions/"+exceptions[i].ExceptionID+".json", &details)
+		if err != nil {
+			log.Printf("Can't fetch the details of license exception %s: %v", exceptions[i].ExceptionID, err)
+			return
+		}
+		exceptions[i].Text = details.Text
+		exceptions[i].TextHTML = details.TextHTML
+		exceptions[i].Template = details.Template
+		exceptions[i].Comments = details.Comments
+	})
+
+	for i := range exceptions {
+		exceptions[i].ListVersion = result.LicenseListVersion
+	}
+	return exceptions, nil
+}
+
+// downloadAll calls fetch for the indexes 0 to n-1, a few at a time.
+func downloadAll(n int, fetch func(i int)) {
+	var wg sync.WaitGroup
+	guard := make(chan struct{}, 20)
+	for i := range n {
+		wg.Add(1)
+		guard <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-guard }()
+			fetch(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// download fetches the JSON document at url into v.
+func download(url string, v any) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Println("No response from request")
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s when fetching %s", resp.Status, url)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Println("Can't read response body", err)
+		return err
+	}
 
-	return knowledge.GetDetails(result.Licenses), nil
+	return json.Unmarshal(body, v)
 }
@@ -1,9 +1,13 @@
 package licenses
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/CodeClarityCE/service-knowledge/src/testhelper"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestUpdate(t *testing.T) {
@@ -18,3 +22,44 @@ func TestUpdate(t *testing.T) {
 		t.Fatalf("Update failed: %v", err)
 	}
 }
+
+func TestDownload(t *testing.T) {
+	files := map[string]string{
+		"/licenses.json":                           `{"licenseListVersion": "3.25.0", "licenses": [{"reference": "https://spdx.org/licenses/MIT.html", "licenseId": "MIT", "name": "MIT License", "isOsiApproved": true, "seeAlso": ["https://opensource.org/license/mit/"]}, {"licenseId": "Missing-1.0", "name": "Missing"}]}`,
+		"/details/MIT.json":                        `{"licenseId": "MIT", "name": "MIT License", "licenseText": "MIT License\n\nCopyright (c) <year> <copyright holders>", "standardLicenseTemplate": "<<beginOptional>>MIT License<<endOptional>>", "crossRef": [{"url": "https://opensource.org/license/mit/", "isValid": true}]}`,
+		"/exceptions.json":                         `{"licenseListVersion": "3.25.0", "exceptions": [{"reference": "https://spdx.org/licenses/Classpath-exception-2.0.html", "licenseExceptionId": "Classpath-exception-2.0", "name": "Classpath exception 2.0", "seeAlso": ["https://www.gnu.org/software/classpath/license.html"]}]}`,
+		"/exceptions/Classpath-exception-2.0.json": `{"licenseExceptionId": "Classpath-exception-2.0", "licenseExceptionText": "Linking this library statically or dynamically with other modules is making a combined work based on this library.", "licenseComments": "Typically used with GPL-2.0-only"}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	licenses, headers, err := downloadLicenses(server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, licenses, 2)
+	assert.Equal(t, "MIT", licenses[0].Details.LicenseID)
+	assert.Contains(t, licenses[0].Details.LicenseText, "Copyright (c) <year>")
+	assert.Len(t, licenses[0].Details.CrossRef, 1)
+	assert.Empty(t, licenses[1].Details.LicenseText)
+	assert.Equal(t, []types.LicenseHeader{{LicenseID: "MIT"}}, headers)
+
+	exceptions, err := downloadExceptions(server.URL)
+	assert.NoError(t, err)
+	if assert.Len(t, exceptions, 1) {
+		assert.Equal(t, "Classpath-exception-2.0", exceptions[0].ExceptionID)
+		assert.Equal(t, "Classpath exception 2.0", exceptions[0].Name)
+		assert.Equal(t, []string{"https://www.gnu.org/software/classpath/license.html"}, exceptions[0].SeeAlso)
+		assert.Contains(t, exceptions[0].Text, "combined work")
+		assert.Equal(t, "Typically used with GPL-2.0-only", exceptions[0].Comments)
+		assert.Equal(t, "3.25.0", exceptions[0].ListVersion)
+	}
+
+	_, _, err = downloadLicenses(server.URL + "/missing")
+	assert.Error(t, err)
+}
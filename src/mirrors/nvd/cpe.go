@@ -0,0 +1,237 @@
+package nvd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/schollz/progressbar/v3"
+	"github.com/uptrace/bun"
+)
+
+const (
+	cpeURLTemplate      = "https://services.nvd.nist.gov/rest/json/cpes/2.0/?resultsPerPage=%d&startIndex=%d"
+	cpeMatchURLTemplate = "https://services.nvd.nist.gov/rest/json/cpematch/2.0/?resultsPerPage=%d&startIndex=%d"
+
+	// Maximum page sizes allowed by the NVD for each API
+	cpePageSize      = 10000
+	cpeMatchPageSize = 500
+
+	cpeStateName      = "nvd_cpe"
+	cpeMatchStateName = "nvd_cpe_match"
+
+	nvdDateFormat = "2006-01-02T15:04:05.000Z"
+)
+
+type cpeResponse struct {
+	TotalResults int `json:"totalResults"`
+	Products     []struct {
+		CPE cpeItem `json:"cpe"`
+	} `json:"products"`
+}
+
+type cpeItem struct {
+	CPEName      string             `json:"cpeName"`
+	CPENameId    string             `json:"cpeNameId"`
+	Deprecated   bool               `json:"deprecated"`
+	Created      string             `json:"created"`
+	LastModified string             `json:"lastModified"`
+	Titles       []types.CPETitle   `json:"titles"`
+	Refs         []types.CPERef     `json:"refs"`
+	DeprecatedBy []types.CPENameRef `json:"deprecatedBy"`
+}
+
+type cpeMatchResponse struct {
+	TotalResults int `json:"totalResults"`
+	MatchStrings []struct {
+		MatchString types.CPEMatchCriteria `json:"matchString"`
+	} `json:"matchStrings"`
+}
+
+// UpdateCPE mirrors the NVD CPE Dictionary and CPE Match Criteria APIs into the knowledge database.
+// Both mirrors share the API key and rate limiter of the CVE mirror and keep their own cursor in mirror_state.
+func UpdateCPE(db *bun.DB) error {
+	apiKey := getAPIKey()
+	rateLimiter := getRateLimiter(apiKey)
+
+	log.Println("Start updating NVD CPE Dictionary")
+	err := syncPaginated(db, cpeStateName, cpeURLTemplate, cpePageSize, apiKey, rateLimiter, func(body []byte) error {
+		var page cpeResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("error unmarshalling CPE page: %w", err)
+		}
+		products := make([]types.CPEProduct, 0, len(page.Products))
+		for _, p := range page.Products {
+			products = append(products, convertCPEItem(p.CPE))
+		}
+		return pgsql.BatchUpdateCPEProducts(db, products)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Println("Start updating NVD CPE Match Criteria")
+	return syncPaginated(db, cpeMatchStateName, cpeMatchURLTemplate, cpeMatchPageSize, apiKey, rateLimiter, func(body []byte) error {
+		var page cpeMatchResponse
+		if err := json.Unmarshal(body, &page); err != nil {
+			return fmt.Errorf("error unmarshalling CPE match page: %w", err)
+		}
+		criteria := make([]types.CPEMatchCriteria, 0, len(page.MatchStrings))
+		for _, m := range page.MatchStrings {
+			criteria = append(criteria, m.MatchString)
+		}
+		return pgsql.BatchUpdateCPEMatchCriteria(db, criteria)
+	})
+}
+
+// syncPaginated downloads every page of a paginated NVD 2.0 API modified since the mirror's cursor.
+// The first sync downloads the full dataset; later syncs use lastModStartDate/lastModEndDate windows
+// of at most 120 days, as imposed by the NVD. The cursor only advances when every page was processed.
+func syncPaginated(db *bun.DB, stateName, urlTemplate string, pageSize int, apiKey string, rateLimiter chan struct{}, process func(body []byte) error) error {
+	state, err := pgsql.GetMirrorState(db, stateName)
+	if err != nil {
+		return err
+	}
+
+	end := time.Now().UTC()
+	restart := false
+	window := ""
+	if !state.LastSync.IsZero() {
+		if time.Since(state.LastSync).Hours() > 24*120 {
+			end = state.LastSync.AddDate(0, 0, 120)
+			restart = true
+		}
+		window = fmt.Sprintf("&lastModStartDate=%s&lastModEndDate=%s", state.LastSync.UTC().Format(nvdDateFormat), end.Format(nvdDateFormat))
+	}
+
+	pageURL := func(i int) string {
+		return fmt.Sprintf(urlTemplate, pageSize, i*pageSize) + window
+	}
+
+	// The first page tells us how many results there are
+	body, err := fetchPage(pageURL(0), apiKey, rateLimiter)
+	if err != nil {
+		return err
+	}
+	var stats NVDStats
+	if err = json.Unmarshal(body, &stats); err != nil {
+		return fmt.Errorf("error unmarshalling response body: %w", err)
+	}
+	log.Printf("Total %s results: %d", stateName, stats.TotalResults)
+
+	n_page := stats.TotalResults / pageSize
+	if stats.TotalResults%pageSize != 0 {
+		n_page++
+	}
+
+	var mu sync.Mutex
+	var errs []error
+	record := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	bar := progressbar.Default(int64(n_page))
+	if err = process(body); err != nil {
+		record(err)
+	}
+	bar.Add(1)
+
+	var wg sync.WaitGroup
+	for i := 1; i < n_page; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer bar.Add(1)
+
+			body, err := fetchPage(pageURL(i), apiKey, rateLimiter)
+			if err == nil {
+				err = process(body)
+			}
+			if err != nil {
+				record(fmt.Errorf("%s page %d: %w", stateName, i, err))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	state.LastSync = end
+	if err = pgsql.SetMirrorState(db, state); err != nil {
+		return err
+	}
+
+	if restart {
+		return syncPaginated(db, stateName, urlTemplate, pageSize, apiKey, rateLimiter, process)
+	}
+	return nil
+}
+
+// convertCPEItem converts a CPE Dictionary API item into its database model.
+func convertCPEItem(item cpeItem) types.CPEProduct {
+	product := types.CPEProduct{
+		CPENameId:    item.CPENameId,
+		CPEName:      item.CPEName,
+		Titles:       item.Titles,
+		Refs:         item.Refs,
+		Deprecated:   item.Deprecated,
+		DeprecatedBy: item.DeprecatedBy,
+		Created:      item.Created,
+		LastModified: item.LastModified,
+	}
+
+	// cpe:2.3:part:vendor:product:version:update:edition:language:sw_edition:target_sw:target_hw:other
+	parts := splitCPE(item.CPEName)
+	if len(parts) == 13 {
+		product.Part = parts[2]
+		product.Vendor = parts[3]
+		product.Product = parts[4]
+		product.Version = parts[5]
+		product.TargetSw = parts[10]
+	}
+
+	for _, title := range item.Titles {
+		if title.Lang == "en" {
+			product.Title = title.Title
+			break
+		}
+	}
+	if product.Title == "" && len(item.Titles) > 0 {
+		product.Title = item.Titles[0].Title
+	}
+
+	return product
+}
+
+// splitCPE splits a CPE 2.3 formatted string on its colons, ignoring escaped colons ("\:").
+func splitCPE(name string) []string {
+	var parts []string
+	var current strings.Builder
+	escaped := false
+	for _, r := range name {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			current.WriteRune(r)
+			escaped = true
+		case r == ':':
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, current.String())
+}
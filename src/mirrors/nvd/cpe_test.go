@@ -0,0 +1,49 @@
+package nvd
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCPE(t *testing.T) {
+	parts := splitCPE(`cpe:2.3:a:foo\:bar:baz:1.0:*:*:*:*:node.js:*:*`)
+	assert.Len(t, parts, 13)
+	assert.Equal(t, `foo\:bar`, parts[3])
+	assert.Equal(t, "node.js", parts[10])
+}
+
+func TestConvertCPEItem(t *testing.T) {
+	product := convertCPEItem(cpeItem{
+		CPEName:    "cpe:2.3:a:lodash:lodash:4.17.20:*:*:*:*:node.js:*:*",
+		CPENameId:  "5A1C1D8E-0000-0000-0000-000000000000",
+		Deprecated: true,
+		Titles: []types.CPETitle{
+			{Title: "ロダッシュ", Lang: "ja"},
+			{Title: "Lodash 4.17.20 for Node.js", Lang: "en"},
+		},
+	})
+
+	assert.Equal(t, "a", product.Part)
+	assert.Equal(t, "lodash", product.Vendor)
+	assert.Equal(t, "lodash", product.Product)
+	assert.Equal(t, "4.17.20", product.Version)
+	assert.Equal(t, "node.js", product.TargetSw)
+	assert.Equal(t, "Lodash 4.17.20 for Node.js", product.Title)
+	assert.True(t, product.Deprecated)
+}
+
+func TestMatchedTargetSoftware(t *testing.T) {
+	targetSw := matchedTargetSoftware(map[string][]string{
+		"A1": {
+			"cpe:2.3:a:lodash:lodash:4.17.20:*:*:*:*:node.js:*:*",
+			"cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:node.js:*:*",
+			"cpe:2.3:a:lodash:lodash:4.17.21:*:*:*:*:*:*:*",
+		},
+		"B2": {"cpe:2.3:a:acme:widget:1.0:*:*:*:*:-:*:*"},
+	})
+
+	assert.Equal(t, map[string][]string{"A1": {"node.js"}}, targetSw)
+	assert.Empty(t, matchedTargetSoftware(nil))
+}
@@ -3,6 +3,7 @@ package nvd
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +12,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cpemap"
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
 	config "github.com/CodeClarityCE/utility-types/config_db"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
@@ -66,7 +68,7 @@ func setLastNVDChangeNumber(db_config *bun.DB, conf config.Config) error {
 // The function uses a progress bar to track the progress of downloading and processing the CVE data.
 // It spawns multiple goroutines to download and process the CVE data concurrently, with a maximum number of goroutines based on the availability of the NVD API key.
 // After downloading and processing each page of CVE data, the function waits for 35 seconds before proceeding to the next page.
-// Finally, the function updates the last modified date in the configuration and, if the restart flag is set, recursively calls itself to continue updating the NVD data.
+// Finally, if every page was stored, the function updates the last modified date in the configuration and, if the restart flag is set, recursively calls itself to continue updating the NVD data.
 // If any error occurs during the update process, the function logs the error and returns it.
 func Update(db *bun.DB, db_config *bun.DB) error {
 	log.Println("Start updating NVD")
@@ -97,17 +99,20 @@ func Update(db *bun.DB, db_config *bun.DB) error {
 		restart = true
 	}
 
-	apiKey, ok := os.LookupEnv("NVD_API_KEY")
-	if !ok || apiKey == "" {
-		log.Println("NVD_API_KEY environment variable not set")
-		apiKey = ""
+	// Curated CPE to package mappings are refreshed before linking packages
+	err = seedPackageMappings(db)
+	if err != nil {
+		log.Println("Can't seed CPE package mappings", err)
+	}
+	// GitHub repositories without a package, shared by the relinking and the pages of this run
+	var repositoryMisses sync.Map
+	err = relinkUpdatedMappings(db, &repositoryMisses)
+	if err != nil {
+		log.Println("Can't link the CVEs of the updated CPE package mappings", err)
 	}
 
+	apiKey := getAPIKey()
 	element_page := 2000
-	maxRequests := 50
-	if apiKey == "" {
-		maxRequests = 5
-	}
 
 	urlTemplate := "https://services.nvd.nist.gov/rest/json/cves/2.0/?resultsPerPage=%d&startIndex=%d&lastModStartDate=%s&lastModEndDate=%s"
 
@@ -128,22 +133,17 @@ func Update(db *bun.DB, db_config *bun.DB) error {
 	bar := progressbar.Default(int64(n_page))
 
 	var wg sync.WaitGroup
-	rateLimiter := make(chan struct{}, maxRequests)
-
-	// Simplify rate limiter logic
-	rateLimiterSleep := 60 * time.Second / time.Duration(maxRequests)
-	if apiKey != "" {
-		rateLimiterSleep = 30 * time.Second / time.Duration(maxRequests)
+	rateLimiter := getRateLimiter(apiKey)
+
+	// Errors of the pages that couldn't be stored; the cursor is not advanced if there are any
+	var mu sync.Mutex
+	var errs []error
+	record := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
 	}
 
-	// Fill rate limiter tokens
-	go func() {
-		for {
-			rateLimiter <- struct{}{}
-			time.Sleep(rateLimiterSleep)
-		}
-	}()
-
 	for i := 0; i < n_page; i++ {
 		wg.Add(1)
 		go func(wg *sync.WaitGroup, i int) {
@@ -153,35 +153,48 @@ func Update(db *bun.DB, db_config *bun.DB) error {
 			vulns, err := downloadBatch(i, element_page, urlTemplate, since, now_string, apiKey, rateLimiter)
 			if err != nil {
 				log.Println(err)
+				record(err)
 				return
 			}
 
-			// Step 1: Insert NVD records
-			pgsql.UpdateNvd(db, vulns)
+			// Step 1: Insert NVD records and withdraw the ones rejected since the last run
+			if err := pgsql.UpdateNvd(db, vulns); err != nil {
+				log.Println(err)
+				record(err)
+				return
+			}
+			if err := retractRejected(db, vulns); err != nil {
+				log.Println(err)
+				record(err)
+			}
 
 			// Step 2: Get UUIDs for inserted NVD records
 			nvdIds := make([]string, len(vulns))
 			for j, v := range vulns {
 				nvdIds[j] = v.NVDId
 			}
-			nvdIdToUUID, err := pgsql.GetNvdUUIDsByNvdIds(db, nvdIds)
-			if err != nil {
-				log.Printf("Error getting NVD UUIDs: %v", err)
-				return
+
+			// Store normalized severity scores
+			if err := pgsql.ReplaceVulnerabilityScores(db, "nvd", nvdIds, extractScores(vulns)); err != nil {
+				log.Printf("Error storing NVD scores: %v", err)
+				record(err)
 			}
 
-			// Step 3: Extract and insert package-vulnerability relationships with FK
-			pkgVulns := extractPackageVulnerabilitiesFromNVD(vulns, nvdIdToUUID)
-			if len(pkgVulns) > 0 {
-				if err := pgsql.BatchInsertNvdPackageVulnerabilities(db, pkgVulns); err != nil {
-					log.Printf("Error inserting package vulnerabilities from NVD: %v", err)
-				}
+			// Step 3: Map CPE products to packages and replace the package-vulnerability relationships
+			if err := linkPackages(db, vulns, &repositoryMisses); err != nil {
+				log.Printf("Error linking NVD records to packages: %v", err)
+				record(err)
 			}
 		}(&wg, i)
 	}
 
 	wg.Wait()
 
+	if len(errs) > 0 {
+		log.Printf("%d NVD pages failed, keeping last date %s", len(errs), since)
+		return errors.Join(errs...)
+	}
+
 	conf.NvdLast = now
 	err = setLastNVDChangeNumber(db_config, conf)
 	if err != nil {
@@ -236,8 +249,9 @@ func fetchNVDStats(urlTemplate string, element_page int, since, now_string, apiK
 
 // extractPackageVulnerabilitiesFromNVD extracts package-vulnerability relationships from NVD items.
 // Uses the nvdIdToUUID map to set the FK reference to the NVD table.
-// Note: NVD uses CPE which doesn't map directly to npm/packagist packages.
-func extractPackageVulnerabilitiesFromNVD(nvdItems []knowledge.NVDItem, nvdIdToUUID map[string]uuid.UUID) []knowledge.PackageVulnerability {
+// CPE products found in mappings are linked to their npm/Packagist package, the others are
+// not packages and are not linked.
+func extractPackageVulnerabilitiesFromNVD(nvdItems []knowledge.NVDItem, nvdIdToUUID map[string]uuid.UUID, mappings map[string]cpemap.Mapping) []knowledge.PackageVulnerability {
 	var pkgVulns []knowledge.PackageVulnerability
 	seen := make(map[string]bool) // Deduplicate within batch
 
@@ -253,16 +267,22 @@ func extractPackageVulnerabilitiesFromNVD(nvdItems []knowledge.NVDItem, nvdIdToU
 				continue
 			}
 
+			mapping, ok := mappings[cpemap.Key(source.CriteriaDict.Vendor, source.CriteriaDict.Product)]
+			if !ok {
+				continue
+			}
+			name, ecosystem := mapping.Name, mapping.Ecosystem
+
 			// Create a unique key for deduplication
-			key := fmt.Sprintf("%s:%s", source.CriteriaDict.Product, nvd.NVDId)
+			key := fmt.Sprintf("%s:%s:%s", ecosystem, name, nvd.NVDId)
 			if seen[key] {
 				continue
 			}
 			seen[key] = true
 
 			pkgVuln := knowledge.PackageVulnerability{
-				PackageName:      source.CriteriaDict.Product,
-				PackageEcosystem: "nvd", // NVD uses CPE, doesn't map directly to npm/packagist
+				PackageName:      name,
+				PackageEcosystem: ecosystem,
 				NvdId:            &nvdUUID,
 			}
 			pkgVulns = append(pkgVulns, pkgVuln)
@@ -272,12 +292,108 @@ func extractPackageVulnerabilitiesFromNVD(nvdItems []knowledge.NVDItem, nvdIdToU
 	return pkgVulns
 }
 
+// retractRejected withdraws the previously imported CVEs that the NVD has rejected,
+// restores the imported ones that are published again, and notifies downstream services of the retractions.
+// Deferred CVEs are left as they are, see pgsql.IsNvdImported.
+func retractRejected(db *bun.DB, vulns []knowledge.NVDItem) error {
+	var rejected, published []string
+	for _, vuln := range vulns {
+		if vuln.VulnStatus == "Rejected" {
+			rejected = append(rejected, vuln.NVDId)
+		} else if pgsql.IsNvdImported(vuln.VulnStatus) {
+			published = append(published, vuln.NVDId)
+		}
+	}
+
+	if err := pgsql.RestoreNvd(db, published); err != nil {
+		return fmt.Errorf("error restoring NVD records: %w", err)
+	}
+
+	retractions, err := pgsql.WithdrawNvd(db, rejected)
+	if err != nil {
+		return fmt.Errorf("error withdrawing rejected NVD records: %w", err)
+	}
+	if len(retractions) > 0 {
+		log.Printf("Withdrew %d rejected NVD records", len(retractions))
+		pgsql.SendRetractionNotifications(retractions)
+	}
+	return nil
+}
+
 func downloadBatch(i, element_page int, urlTemplate, since, now_string, apiKey string, rateLimiter chan struct{}) ([]knowledge.NVDItem, error) {
 	index := i * element_page
 	url := fmt.Sprintf(urlTemplate, element_page, index, since, now_string)
 
+	body, err := fetchPage(url, apiKey, rateLimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	var result knowledge.NVD
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling response body: %w", err)
+	}
+
+	return knowledge.GetVulns(result), nil
+}
+
+// getAPIKey returns the NVD API key from the NVD_API_KEY environment variable,
+// or an empty string when the mirrors have to run with the public rate limit.
+func getAPIKey() string {
+	apiKey, ok := os.LookupEnv("NVD_API_KEY")
+	if !ok || apiKey == "" {
+		log.Println("NVD_API_KEY environment variable not set")
+		return ""
+	}
+	return apiKey
+}
+
+var (
+	rateLimiterOnce sync.Once
+	rateLimiter     chan struct{}
+)
+
+// getRateLimiter returns the rate limiter shared by every NVD mirror, so that the
+// CVE, CPE and CPE match mirrors stay within the same API quota.
+func getRateLimiter(apiKey string) chan struct{} {
+	rateLimiterOnce.Do(func() {
+		rateLimiter = newRateLimiter(apiKey)
+	})
+	return rateLimiter
+}
+
+// newRateLimiter returns a channel handing out one token per allowed request.
+// The NVD allows 5 requests per rolling 30 seconds without an API key and 50 with one.
+func newRateLimiter(apiKey string) chan struct{} {
+	maxRequests := 50
+	if apiKey == "" {
+		maxRequests = 5
+	}
+
+	rateLimiter := make(chan struct{}, maxRequests)
+
+	// Simplify rate limiter logic
+	rateLimiterSleep := 60 * time.Second / time.Duration(maxRequests)
+	if apiKey != "" {
+		rateLimiterSleep = 30 * time.Second / time.Duration(maxRequests)
+	}
+
+	// Fill rate limiter tokens
+	go func() {
+		for {
+			rateLimiter <- struct{}{}
+			time.Sleep(rateLimiterSleep)
+		}
+	}()
+
+	return rateLimiter
+}
+
+// fetchPage downloads one page of an NVD 2.0 API, waiting for a rate limiter token before each attempt.
+// Network errors and 429 responses are retried with an exponential backoff.
+func fetchPage(url, apiKey string, rateLimiter chan struct{}) ([]byte, error) {
 	var resp *http.Response
-	var err error
 	var retries int
 	maxRetries := 5
 
@@ -300,12 +416,14 @@ func downloadBatch(i, element_page int, urlTemplate, since, now_string, apiKey s
 		}
 
 		if resp.StatusCode == http.StatusTooManyRequests {
+			resp.Body.Close()
 			log.Printf("rate limit exceeded, retrying... (%d/%d)", retries+1, maxRetries)
 			time.Sleep(time.Duration(2<<retries) * time.Second) // Exponential backoff
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
 			return nil, fmt.Errorf("unexpected response status: %s", resp.Status)
 		}
 
@@ -323,11 +441,5 @@ func downloadBatch(i, element_page int, urlTemplate, since, now_string, apiKey s
 		return nil, fmt.Errorf("error reading response body: %w", err)
 	}
 
-	var result knowledge.NVD
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling response body: %w", err)
-	}
-
-	return knowledge.GetVulns(result), nil
+	return body, nil
 }
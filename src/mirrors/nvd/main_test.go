@@ -5,6 +5,10 @@ import (
 	"testing"
 
 	"github.com/CodeClarityCE/service-knowledge/src/testhelper"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cpemap"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestUpdate(t *testing.T) {
@@ -21,3 +25,29 @@ func TestUpdate(t *testing.T) {
 		t.Fatalf("Update failed: %v", err)
 	}
 }
+
+func TestExtractPackageVulnerabilitiesFromNVD(t *testing.T) {
+	affected := func(vendor, product string) knowledge.Sources {
+		return knowledge.Sources{CriteriaDict: knowledge.CriteriaDict{Vendor: vendor, Product: product}}
+	}
+	vulns := []knowledge.NVDItem{
+		{NVDId: "CVE-2021-23337", AffectedFlattened: []knowledge.Sources{
+			affected("lodash", "lodash"),
+			affected("lodash", "lodash"),
+			affected("oracle", "communications_cloud_native_core_binding_support_function"),
+			affected("*", "*"),
+		}},
+		{NVDId: "CVE-2024-0001"},
+	}
+	id := uuid.New()
+	mappings := map[string]cpemap.Mapping{
+		cpemap.Key("lodash", "lodash"): {Ecosystem: cpemap.EcosystemNpm, Name: "lodash"},
+	}
+
+	// Products without a mapping are not packages
+	pkgVulns := extractPackageVulnerabilitiesFromNVD(vulns, map[string]uuid.UUID{"CVE-2021-23337": id}, mappings)
+	assert.Len(t, pkgVulns, 1)
+	assert.Equal(t, "lodash", pkgVulns[0].PackageName)
+	assert.Equal(t, cpemap.EcosystemNpm, pkgVulns[0].PackageEcosystem)
+	assert.Equal(t, &id, pkgVulns[0].NvdId)
+}
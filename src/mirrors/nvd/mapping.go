@@ -0,0 +1,245 @@
+package nvd
+
+import (
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cpemap"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/uptrace/bun"
+)
+
+// seedPackageMappings loads the curated CPE to package mappings into the mapping table.
+func seedPackageMappings(db *bun.DB) error {
+	mappings, err := cpemap.Seed()
+	if err != nil {
+		return err
+	}
+	return pgsql.SeedCPEPackageMappings(db, mappings)
+}
+
+// relinkState is the mirror state of the CVEs linked to the packages of the CPE package mappings.
+const relinkState = "cpe_package_mapping"
+
+// relinkBatchSize is the number of NVD records linked to their packages again at once.
+const relinkBatchSize = 500
+
+// relinkUpdatedMappings links the CVEs of the CPE products whose mapping was created or changed
+// since the previous run to their packages again, e.g. after a mapping was edited by hand.
+// The first run relinks the CVEs of every mapped product and removes the links to bare CPE
+// products imported before the mappings existed.
+func relinkUpdatedMappings(db *bun.DB, misses *sync.Map) error {
+	state, err := pgsql.GetMirrorState(db, relinkState)
+	if err != nil {
+		return err
+	}
+	syncStart := time.Now()
+
+	if state.LastSync.IsZero() {
+		deleted, err := pgsql.DeleteUnmappedNvdPackageVulnerabilities(db)
+		if err != nil {
+			return err
+		}
+		log.Printf("Removed %d NVD links to unmapped CPE products", deleted)
+	}
+
+	mappings, err := pgsql.GetCPEPackageMappingsUpdatedSince(db, state.LastSync)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, len(mappings))
+	for i, mapping := range mappings {
+		keys[i] = cpemap.Key(mapping.Vendor, mapping.Product)
+	}
+	ids, err := pgsql.GetNvdIdsByCPEProducts(db, keys)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(ids); start += relinkBatchSize {
+		vulns, err := pgsql.GetNvdByIds(db, ids[start:min(start+relinkBatchSize, len(ids))])
+		if err != nil {
+			return err
+		}
+		if err := linkPackages(db, vulns, misses); err != nil {
+			return err
+		}
+	}
+	if len(ids) > 0 {
+		log.Printf("Linked %d CVEs of %d updated CPE package mappings to their packages again", len(ids), len(mappings))
+	}
+
+	state.LastSync = syncStart
+	return pgsql.SetMirrorState(db, state)
+}
+
+// linkPackages maps the CPE products affected by NVD records to packages and replaces the
+// package links of the records. Records that are not imported are skipped, the links of the
+// rejected ones are removed by retractRejected.
+func linkPackages(db *bun.DB, vulns []knowledge.NVDItem, misses *sync.Map) error {
+	vulns = slices.DeleteFunc(slices.Clone(vulns), func(vuln knowledge.NVDItem) bool {
+		return !pgsql.IsNvdImported(vuln.VulnStatus)
+	})
+	nvdIds := make([]string, len(vulns))
+	for i, vuln := range vulns {
+		nvdIds[i] = vuln.NVDId
+	}
+	nvdIdToUUID, err := pgsql.GetNvdUUIDsByNvdIds(db, nvdIds)
+	if err != nil {
+		return err
+	}
+
+	mappings := resolvePackageMappings(db, vulns, misses)
+	pkgVulns := extractPackageVulnerabilitiesFromNVD(vulns, nvdIdToUUID, mappings)
+	return pgsql.ReplaceNvdPackageVulnerabilities(db, slices.Collect(maps.Values(nvdIdToUUID)), pkgVulns)
+}
+
+// resolvePackageMappings maps the CPE products affected by a page of CVEs to packages.
+// Stored mappings are used first; the remaining products go through the cpemap heuristics
+// and what they find is stored for the next runs. Products that can't be mapped are absent
+// from the returned map, keyed by cpemap.Key. Repositories without a package are added to misses,
+// so that they are looked up once per run.
+func resolvePackageMappings(db *bun.DB, vulns []knowledge.NVDItem, misses *sync.Map) map[string]cpemap.Mapping {
+	type candidate struct {
+		vendor     string
+		product    string
+		targetSw   []string
+		references []string
+	}
+
+	candidates := make(map[string]*candidate)
+	var keys []string
+	// matchCriteriaIds of the products whose criteria don't name a target software
+	wildcards := make(map[string][]string)
+	var matchCriteriaIds []string
+	for _, vuln := range vulns {
+		var references []string
+		for _, reference := range vuln.References {
+			references = append(references, reference.URL)
+		}
+
+		for _, source := range vuln.AffectedFlattened {
+			if source.CriteriaDict.Product == "" || source.CriteriaDict.Product == "*" {
+				continue
+			}
+			key := cpemap.Key(source.CriteriaDict.Vendor, source.CriteriaDict.Product)
+			c, ok := candidates[key]
+			if !ok {
+				c = &candidate{vendor: source.CriteriaDict.Vendor, product: source.CriteriaDict.Product}
+				candidates[key] = c
+				keys = append(keys, key)
+			}
+			c.targetSw = append(c.targetSw, source.CriteriaDict.TargetSw)
+			c.references = append(c.references, references...)
+			if isWildcard(source.CriteriaDict.TargetSw) && source.MatchCriteriaID != "" {
+				wildcards[key] = append(wildcards[key], source.MatchCriteriaID)
+				matchCriteriaIds = append(matchCriteriaIds, source.MatchCriteriaID)
+			}
+		}
+	}
+
+	// The CPE names matched by a criteria tell which target software it covers
+	if len(matchCriteriaIds) > 0 {
+		names, err := pgsql.GetCPENamesByMatchCriteriaIds(db, matchCriteriaIds)
+		if err != nil {
+			log.Printf("Error expanding CPE match criteria: %v", err)
+		}
+		targetSw := matchedTargetSoftware(names)
+		for key, ids := range wildcards {
+			for _, id := range ids {
+				candidates[key].targetSw = append(candidates[key].targetSw, targetSw[id]...)
+			}
+		}
+	}
+
+	result := make(map[string]cpemap.Mapping, len(keys))
+
+	stored, err := pgsql.GetCPEPackageMappings(db, keys)
+	if err != nil {
+		log.Printf("Error getting CPE package mappings: %v", err)
+		return result
+	}
+
+	var found []types.CPEPackageMapping
+	for _, key := range keys {
+		if mapping, ok := stored[key]; ok {
+			if mapping.Ecosystem != cpemap.EcosystemNVD {
+				result[key] = cpemap.Mapping{Ecosystem: mapping.Ecosystem, Name: mapping.PackageName, Evidence: mapping.Evidence}
+			}
+			continue
+		}
+
+		c := candidates[key]
+		var repositories []cpemap.Repository
+		mapping, ok := cpemap.Mapping{}, false
+		for _, targetSw := range c.targetSw {
+			mapping, repositories, ok = cpemap.Guess(c.vendor, c.product, targetSw, c.references)
+			if ok {
+				break
+			}
+		}
+
+		for _, repository := range repositories {
+			if ok {
+				break
+			}
+			if _, missed := misses.Load(repository); missed {
+				continue
+			}
+			mapping, ok, err = pgsql.GetPackageByRepository(db, repository)
+			if err != nil {
+				log.Printf("%v", err)
+			} else if !ok {
+				misses.Store(repository, struct{}{})
+			}
+		}
+
+		if !ok {
+			continue
+		}
+
+		result[key] = mapping
+		found = append(found, types.CPEPackageMapping{
+			Vendor:      strings.ToLower(cpemap.Unescape(c.vendor)),
+			Product:     strings.ToLower(cpemap.Unescape(c.product)),
+			Ecosystem:   mapping.Ecosystem,
+			PackageName: mapping.Name,
+			Purl:        cpemap.Purl(mapping.Ecosystem, mapping.Name),
+			Source:      types.MappingSourceHeuristic,
+			Evidence:    mapping.Evidence,
+		})
+	}
+
+	if err := pgsql.InsertCPEPackageMappings(db, found); err != nil {
+		log.Printf("Error storing CPE package mappings: %v", err)
+	}
+
+	return result
+}
+
+// matchedTargetSoftware returns the target software of the CPE names matched by each matchCriteriaId,
+// without duplicates or wildcards.
+func matchedTargetSoftware(names map[string][]string) map[string][]string {
+	result := make(map[string][]string, len(names))
+	for id, cpeNames := range names {
+		for _, name := range cpeNames {
+			parts := splitCPE(name)
+			if len(parts) <= 10 || isWildcard(parts[10]) || slices.Contains(result[id], parts[10]) {
+				continue
+			}
+			result[id] = append(result[id], parts[10])
+		}
+	}
+	return result
+}
+
+// isWildcard reports whether a CPE attribute matches any value (*) or none (-).
+func isWildcard(value string) bool {
+	return value == "" || value == "*" || value == "-"
+}
@@ -0,0 +1,41 @@
+package nvd
+
+import (
+	"log"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cvss"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// extractScores normalizes the CVSS v2, v3.0 and v3.1 metrics of NVD items.
+// Each metric is attributed to the organisation that published it (NVD or a CNA).
+func extractScores(nvdItems []knowledge.NVDItem) []types.VulnerabilityScore {
+	var scores []types.VulnerabilityScore
+
+	add := func(nvdId, provider, vector string, baseScore float64) {
+		if vector == "" {
+			return
+		}
+		score, err := cvss.NewScore("nvd", nvdId, provider, vector, &baseScore)
+		if err != nil {
+			log.Printf("Skipping CVSS vector of %s: %v", nvdId, err)
+			return
+		}
+		scores = append(scores, score)
+	}
+
+	for _, nvd := range nvdItems {
+		for _, metric := range nvd.Metrics.CvssMetricV2 {
+			add(nvd.NVDId, metric.Source, metric.CvssData.VectorString, metric.CvssData.BaseScore)
+		}
+		for _, metric := range nvd.Metrics.CvssMetricV30 {
+			add(nvd.NVDId, metric.Source, metric.CvssData.VectorString, metric.CvssData.BaseScore)
+		}
+		for _, metric := range nvd.Metrics.CvssMetricV31 {
+			add(nvd.NVDId, metric.Source, metric.CvssData.VectorString, metric.CvssData.BaseScore)
+		}
+	}
+
+	return scores
+}
@@ -264,5 +264,10 @@ func processBatch(db *bun.DB, osvBatch []knowledge.OSVItem, ecosystem string) er
 		}
 	}
 
+	// Step 4: Store normalized severity scores
+	if err := pgsql.ReplaceVulnerabilityScores(db, "osv", osvIds, extractScores(osvBatch)); err != nil {
+		log.Printf("Error storing OSV scores for ecosystem %s: %v", ecosystem, err)
+	}
+
 	return nil
 }
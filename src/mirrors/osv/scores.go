@@ -0,0 +1,48 @@
+package osv
+
+import (
+	"log"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cvss"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// extractScores normalizes the CVSS vectors of OSV items, including the ones given per affected package.
+// Advisories without any vector fall back to the qualitative severity of their database_specific field,
+// as published by GitHub advisories.
+func extractScores(osvItems []knowledge.OSVItem) []types.VulnerabilityScore {
+	var scores []types.VulnerabilityScore
+
+	for _, osv := range osvItems {
+		severities := osv.Severity
+		for _, affected := range osv.Affected {
+			severities = append(severities, affected.Severity...)
+		}
+
+		found := false
+		for _, severity := range severities {
+			if !strings.HasPrefix(severity.Type, "CVSS_") {
+				continue
+			}
+			score, err := cvss.NewScore("osv", osv.OSVId, "osv", severity.Score, nil)
+			if err != nil {
+				log.Printf("Skipping CVSS vector of %s: %v", osv.OSVId, err)
+				continue
+			}
+			scores = append(scores, score)
+			found = true
+		}
+
+		if !found {
+			if label, ok := osv.DatabaseSpecific["severity"].(string); ok {
+				if score, ok := cvss.NewQualitativeScore("osv", osv.OSVId, "osv", label); ok {
+					scores = append(scores, score)
+				}
+			}
+		}
+	}
+
+	return scores
+}
@@ -0,0 +1,39 @@
+package osv
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cvss"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractScores(t *testing.T) {
+	scores := extractScores([]knowledge.OSVItem{
+		{
+			OSVId:    "GHSA-v4-only",
+			Severity: []knowledge.Severity{{Type: "CVSS_V4", Score: "CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"}},
+			// The computed score wins over the label
+			DatabaseSpecific: map[string]any{"severity": "LOW"},
+		},
+		{
+			OSVId:    "GHSA-v3",
+			Severity: []knowledge.Severity{{Type: "CVSS_V3", Score: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"}},
+			// The computed score wins over the label
+			DatabaseSpecific: map[string]any{"severity": "LOW"},
+		},
+		{
+			OSVId:            "GHSA-label",
+			DatabaseSpecific: map[string]any{"severity": "moderate"},
+		},
+	})
+
+	assert.Len(t, scores, 3)
+	assert.Equal(t, cvss.Version40, scores[0].Version)
+	assert.Equal(t, 9.3, *scores[0].BaseScore)
+	assert.True(t, scores[0].Computed)
+	assert.Equal(t, cvss.SeverityCritical, scores[0].Severity)
+	assert.Equal(t, cvss.SeverityCritical, scores[1].Severity)
+	assert.Equal(t, "", scores[2].Vector)
+	assert.Equal(t, cvss.SeverityMedium, scores[2].Severity)
+}
@@ -234,6 +234,29 @@ func NormalizeDependencies(deps interface{}) map[string]string {
 	}
 }
 
+// NormalizeLicense converts the license of a composer.json, a string or a list of strings, to a list
+func NormalizeLicense(license interface{}) []string {
+	switch l := license.(type) {
+	case string:
+		if l == "" {
+			return nil
+		}
+		return []string{l}
+	case []interface{}:
+		var licenses []string
+		for _, value := range l {
+			if str, ok := value.(string); ok {
+				licenses = append(licenses, str)
+			}
+		}
+		return licenses
+	case []string:
+		return l
+	default:
+		return nil
+	}
+}
+
 // NormalizeFunding converts various funding formats to a consistent structure
 func NormalizeFunding(funding interface{}) interface{} {
 	if funding == nil {
@@ -12,6 +12,8 @@ import (
 	"time"
 
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/tools"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/google/uuid"
 	"github.com/schollz/progressbar/v3"
@@ -471,19 +473,7 @@ func convertPackagistToKnowledge(packagist *PackagistPackage) knowledge.Package
 	pack.Versions = make([]knowledge.Version, 0, len(packagist.Package.Versions))
 	for version, info := range packagist.Package.Versions {
 		// Convert flexible fields to consistent format
-		var licenses []string
-		switch lic := info.License.(type) {
-		case string:
-			licenses = []string{lic}
-		case []interface{}:
-			for _, l := range lic {
-				if str, ok := l.(string); ok {
-					licenses = append(licenses, str)
-				}
-			}
-		case []string:
-			licenses = lic
-		}
+		licenses := NormalizeLicense(info.License)
 
 		v := knowledge.Version{
 			Version: version,
@@ -492,7 +482,6 @@ func convertPackagistToKnowledge(packagist *PackagistPackage) knowledge.Package
 				"time":     info.Time,
 				"source":   info.Source,
 				"dist":     info.Dist,
-				"license":  licenses,
 				"authors":  info.Authors,
 				"autoload": info.Autoload,
 				"support":  info.Support,
@@ -505,6 +494,8 @@ func convertPackagistToKnowledge(packagist *PackagistPackage) knowledge.Package
 			},
 		}
 
+		tools.SetVersionLicense(&v, licenses)
+
 		// Convert dependencies - normalize to handle flexible types
 		v.Dependencies = NormalizeDependencies(info.Require)
 		v.DevDependencies = NormalizeDependencies(info.RequireDev)
@@ -531,20 +522,7 @@ func convertPackagistToKnowledge(packagist *PackagistPackage) knowledge.Package
 		for _, info := range packagist.Package.Versions {
 			if info.Version == latestVersion {
 				// Handle flexible license type
-				var licenses []string
-				switch lic := info.License.(type) {
-				case string:
-					licenses = []string{lic}
-				case []interface{}:
-					for _, l := range lic {
-						if str, ok := l.(string); ok {
-							licenses = append(licenses, str)
-						}
-					}
-				case []string:
-					licenses = lic
-				}
-
+				licenses := NormalizeLicense(info.License)
 				if len(licenses) > 0 {
 					pack.License = strings.Join(licenses, ", ")
 					pack.Licenses = make([]knowledge.LicenseNpm, len(licenses))
@@ -560,6 +538,14 @@ func convertPackagistToKnowledge(packagist *PackagistPackage) knowledge.Package
 		}
 	}
 
+	// The license of the package is the one of its latest version, as published and normalized
+	licenses := make([]string, len(pack.Licenses))
+	for i, license := range pack.Licenses {
+		licenses[i] = license.Type
+	}
+	pack.Extra = map[string]any{}
+	tools.SetLicense(pack.Extra, spdx.NormalizeList(licenses))
+
 	return pack
 }
 
@@ -10,7 +10,9 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cvss"
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -144,6 +146,8 @@ func fetchBatchAdvisories(db *bun.DB, packages []string) error {
 
 	// Step 1: Insert advisories and collect advisory IDs with their package names
 	var advisoryInfos []advisoryInfo
+	var scores []types.VulnerabilityScore
+	var advisorySourceIds []types.FriendsOfPHPSourceIds
 	totalAdvisories := 0
 
 	for packageName, advisories := range response.Advisories {
@@ -153,10 +157,19 @@ func fetchBatchAdvisories(db *bun.DB, packages []string) error {
 				log.Printf("Error inserting advisory %s: %v", advisory.AdvisoryID, err)
 				continue
 			}
+			advisorySourceIds = append(advisorySourceIds, types.FriendsOfPHPSourceIds{
+				AdvisoryId: advisory.AdvisoryID,
+				SourceIds:  sourceIds(advisory),
+			})
 			advisoryInfos = append(advisoryInfos, advisoryInfo{
 				advisoryId:  advisory.AdvisoryID,
 				packageName: packageName,
 			})
+			if advisory.Severity != nil {
+				if score, ok := cvss.NewQualitativeScore("friends_of_php", advisory.AdvisoryID, "packagist", *advisory.Severity); ok {
+					scores = append(scores, score)
+				}
+			}
 		}
 		if len(advisories) > 0 {
 			log.Printf("  - %s: %d advisories", packageName, len(advisories))
@@ -167,6 +180,9 @@ func fetchBatchAdvisories(db *bun.DB, packages []string) error {
 	if totalAdvisories > 0 {
 		log.Printf("Total advisories in batch: %d", totalAdvisories)
 	}
+	if err := pgsql.SetFriendsOfPhpSourceIds(db, advisorySourceIds); err != nil {
+		log.Printf("Error storing source ids of advisories: %v", err)
+	}
 
 	// Step 2: Get UUIDs for inserted advisories
 	if len(advisoryInfos) > 0 {
@@ -175,6 +191,11 @@ func fetchBatchAdvisories(db *bun.DB, packages []string) error {
 			advisoryIds[i] = info.advisoryId
 		}
 
+		// Packagist only rates advisories qualitatively
+		if err := pgsql.ReplaceVulnerabilityScores(db, "friends_of_php", advisoryIds, scores); err != nil {
+			log.Printf("Error storing FriendsOfPHP scores: %v", err)
+		}
+
 		advisoryIdToUUID, err := pgsql.GetFriendsOfPhpUUIDsByAdvisoryIds(db, advisoryIds)
 		if err != nil {
 			log.Printf("Error getting FriendsOfPHP UUIDs: %v", err)
@@ -222,6 +243,18 @@ func extractPackageVulnerabilitiesFromFriendsOfPhp(advisoryInfos []advisoryInfo,
 	return pkgVulns
 }
 
+// sourceIds returns the ids of an advisory at its sources, e.g. its GHSA id for GitHub.
+// They are aliases of the advisory.
+func sourceIds(advisory PackagistAdvisory) []string {
+	ids := []string{}
+	for _, source := range advisory.Sources {
+		if source.RemoteID != "" && source.RemoteID != advisory.AdvisoryID {
+			ids = append(ids, source.RemoteID)
+		}
+	}
+	return ids
+}
+
 // convertPackagistToDBModel converts a Packagist advisory to database model
 func convertPackagistToDBModel(advisory PackagistAdvisory) knowledge.FriendsOfPHPAdvisory {
 	// Parse affected versions into branches format
@@ -0,0 +1,198 @@
+package advisory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/google/uuid"
+)
+
+// Advisory is what a source says about a vulnerability, reduced to the merged fields.
+type Advisory struct {
+	Source      string
+	Id          string
+	Summary     string
+	Description string
+	Cwes        []string
+	References  []string
+	Affected    json.RawMessage
+}
+
+// Input is everything known about a vulnerability.
+type Input struct {
+	Vulnerability types.Vulnerability
+	Advisories    []Advisory
+	Scores        []types.VulnerabilityScore
+	KEV           bool
+}
+
+// Inputs gathers the inputs of the given vulnerabilities from the records of their sources.
+func Inputs(vulnerabilities []types.Vulnerability, sources pgsql.AdvisorySources) []Input {
+	aliases := make(map[uuid.UUID][]types.VulnerabilityAlias)
+	for _, alias := range sources.Aliases {
+		aliases[alias.VulnerabilityId] = append(aliases[alias.VulnerabilityId], alias)
+	}
+
+	inputs := make([]Input, 0, len(vulnerabilities))
+	for _, vulnerability := range vulnerabilities {
+		input := Input{Vulnerability: vulnerability}
+
+		for _, alias := range aliases[vulnerability.Id] {
+			if alias.RecordId == nil {
+				continue
+			}
+			var advisory Advisory
+			var ok bool
+			switch alias.Source {
+			case "osv":
+				var item knowledge.OSVItem
+				if item, ok = sources.Osv[*alias.RecordId]; ok {
+					advisory = fromOSV(item)
+				}
+			case "nvd":
+				var item knowledge.NVDItem
+				if item, ok = sources.Nvd[*alias.RecordId]; ok {
+					advisory = fromNVD(item)
+				}
+			case "gcve":
+				var item knowledge.GCVEItem
+				if item, ok = sources.Gcve[*alias.RecordId]; ok {
+					advisory = fromGCVE(item)
+				}
+			case "friends_of_php":
+				var item knowledge.FriendsOfPHPAdvisory
+				if item, ok = sources.FriendsOfPhp[*alias.RecordId]; ok {
+					advisory = fromFriendsOfPHP(item)
+				}
+			}
+			if ok {
+				input.Advisories = append(input.Advisories, advisory)
+			}
+		}
+		// Stable order between runs, so that the fingerprint only changes with the data
+		slices.SortFunc(input.Advisories, func(a, b Advisory) int {
+			return strings.Compare(a.Source+"|"+a.Id, b.Source+"|"+b.Id)
+		})
+
+		advisoryIds := make(map[string]bool, len(input.Advisories))
+		for _, advisory := range input.Advisories {
+			advisoryIds[advisory.Source+"|"+advisory.Id] = true
+		}
+		for _, score := range sources.Scores {
+			if advisoryIds[score.Source+"|"+score.VulnerabilityId] {
+				input.Scores = append(input.Scores, score)
+			}
+		}
+
+		for _, id := range append([]string{vulnerability.CanonicalId}, vulnerability.Aliases...) {
+			if _, ok := sources.KEV[id]; ok {
+				input.KEV = true
+			}
+		}
+
+		inputs = append(inputs, input)
+	}
+	return inputs
+}
+
+// Merge builds the merged advisory of a vulnerability, taking every field from the first source
+// of its precedence that has it.
+func Merge(precedence Precedence, input Input) types.MergedAdvisory {
+	merged := types.MergedAdvisory{
+		VulnerabilityId: input.Vulnerability.Id,
+		CanonicalId:     input.Vulnerability.CanonicalId,
+		Aliases:         input.Vulnerability.Aliases,
+		KEV:             input.KEV,
+	}
+
+	if advisory, ok := pick(precedence[FieldSummary], input.Advisories, func(a Advisory) bool { return a.Summary != "" }); ok {
+		merged.Summary, merged.SummarySource = advisory.Summary, advisory.Source
+	}
+	if advisory, ok := pick(precedence[FieldDescription], input.Advisories, func(a Advisory) bool { return a.Description != "" }); ok {
+		merged.Description, merged.DescriptionSource = advisory.Description, advisory.Source
+	}
+	if advisory, ok := pick(precedence[FieldCwes], input.Advisories, func(a Advisory) bool { return len(a.Cwes) > 0 }); ok {
+		merged.Cwes, merged.CwesSource = advisory.Cwes, advisory.Source
+	}
+	if advisory, ok := pick(precedence[FieldReferences], input.Advisories, func(a Advisory) bool { return len(a.References) > 0 }); ok {
+		merged.References, merged.ReferencesSource = advisory.References, advisory.Source
+	}
+	if advisory, ok := pick(precedence[FieldAffected], input.Advisories, func(a Advisory) bool { return len(a.Affected) > 0 }); ok {
+		merged.Affected, merged.AffectedSource = advisory.Affected, advisory.Source
+	}
+
+	if score, ok := pickScore(precedence[FieldSeverity], input.Scores); ok {
+		merged.Severity = score.Severity
+		merged.BaseScore = score.BaseScore
+		merged.CVSSVersion = score.Version
+		merged.CVSSVector = score.Vector
+		merged.SeveritySource = score.Source
+		merged.SeverityProvider = score.Provider
+	}
+
+	// The score itself is joined when read, it changes daily
+	if slices.Contains(precedence[FieldEPSS], "epss") {
+		merged.EPSSSource = "epss"
+	}
+
+	merged.Fingerprint = fingerprint(merged)
+	return merged
+}
+
+// pick returns the first advisory having a field, following the precedence of the field.
+func pick(sources []string, advisories []Advisory, has func(Advisory) bool) (Advisory, bool) {
+	for _, source := range sources {
+		for _, advisory := range advisories {
+			if advisory.Source == source && has(advisory) {
+				return advisory, true
+			}
+		}
+	}
+	return Advisory{}, false
+}
+
+// pickScore returns the score of the first source of the precedence rating the vulnerability.
+// Within a source, computed scores and the most recent CVSS version are preferred.
+func pickScore(sources []string, scores []types.VulnerabilityScore) (types.VulnerabilityScore, bool) {
+	for _, source := range sources {
+		var best *types.VulnerabilityScore
+		for i, score := range scores {
+			if score.Source != source {
+				continue
+			}
+			if best == nil || betterScore(score, *best) {
+				best = &scores[i]
+			}
+		}
+		if best != nil {
+			return *best, true
+		}
+	}
+	return types.VulnerabilityScore{}, false
+}
+
+func betterScore(a, b types.VulnerabilityScore) bool {
+	if (a.BaseScore != nil) != (b.BaseScore != nil) {
+		return a.BaseScore != nil
+	}
+	if a.Version != b.Version {
+		return a.Version > b.Version
+	}
+	return a.Provider+a.Vector < b.Provider+b.Vector
+}
+
+// fingerprint hashes the merged fields of an advisory.
+func fingerprint(merged types.MergedAdvisory) string {
+	merged.Fingerprint = ""
+	merged.UpdatedAt = time.Time{}
+	data, _ := json.Marshal(merged)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
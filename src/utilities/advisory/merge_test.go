@@ -0,0 +1,120 @@
+package advisory
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func lodashInput() Input {
+	vulnerability := types.Vulnerability{
+		Id:          uuid.New(),
+		CanonicalId: "CVE-2021-23337",
+		Aliases:     []string{"CVE-2021-23337", "GCVE-0-2021-23337", "GHSA-35jh-r3h4-6jhm"},
+	}
+	osvId, nvdId, gcveId := uuid.New(), uuid.New(), uuid.New()
+	nvdScore, osvScore := 7.2, 7.2
+
+	sources := pgsql.AdvisorySources{
+		Aliases: []types.VulnerabilityAlias{
+			{VulnerabilityId: vulnerability.Id, Alias: "GHSA-35jh-r3h4-6jhm", Source: "osv", RecordId: &osvId},
+			{VulnerabilityId: vulnerability.Id, Alias: "CVE-2021-23337", Source: "nvd", RecordId: &nvdId},
+			{VulnerabilityId: vulnerability.Id, Alias: "GCVE-0-2021-23337", Source: "gcve", RecordId: &gcveId},
+		},
+		Osv: map[uuid.UUID]knowledge.OSVItem{osvId: {
+			Id:         osvId,
+			OSVId:      "GHSA-35jh-r3h4-6jhm",
+			Summary:    "Command Injection in lodash",
+			Details:    "lodash versions prior to 4.17.21 are vulnerable to Command Injection via the template function.",
+			Cwes:       []string{"CWE-77", "CWE-94"},
+			References: []knowledge.Reference{{Type: "ADVISORY", Url: "https://github.com/advisories/GHSA-35jh-r3h4-6jhm"}},
+			Affected:   []knowledge.Affected{{Package: knowledge.OSVPackage{Ecosystem: "npm", Name: "lodash"}}},
+		}},
+		Nvd: map[uuid.UUID]knowledge.NVDItem{nvdId: {
+			Id:           nvdId,
+			NVDId:        "CVE-2021-23337",
+			Descriptions: []knowledge.Descriptions{{Lang: "en", Value: "Lodash versions prior to 4.17.21 are vulnerable to Command Injection via the template function."}},
+			Weaknesses:   []knowledge.Weaknesses{{Descriptions: []knowledge.Descriptions{{Lang: "en", Value: "CWE-94"}, {Lang: "en", Value: "NVD-CWE-Other"}}}},
+		}},
+		Gcve: map[uuid.UUID]knowledge.GCVEItem{gcveId: {
+			Id:           gcveId,
+			GCVEId:       "GCVE-0-2021-23337",
+			CVEId:        "CVE-2021-23337",
+			Descriptions: []knowledge.GCVEDescription{{Lang: "en", Value: "Lodash versions prior to 4.17.21 are vulnerable to Command Injection via the template function (CNA)."}},
+		}},
+		Scores: []types.VulnerabilityScore{
+			{Source: "osv", VulnerabilityId: "GHSA-35jh-r3h4-6jhm", Provider: "osv", Version: "3.1", Vector: "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", BaseScore: &osvScore, Severity: "HIGH"},
+			{Source: "nvd", VulnerabilityId: "CVE-2021-23337", Provider: "nvd@nist.gov", Version: "2.0", Vector: "AV:N/AC:L/Au:S/C:P/I:P/A:P", Severity: "MEDIUM"},
+			{Source: "nvd", VulnerabilityId: "CVE-2021-23337", Provider: "nvd@nist.gov", Version: "3.1", Vector: "CVSS:3.1/AV:N/AC:L/PR:H/UI:N/S:U/C:H/I:H/A:H", BaseScore: &nvdScore, Severity: "HIGH"},
+			// Another vulnerability
+			{Source: "nvd", VulnerabilityId: "CVE-2020-8203", Provider: "nvd@nist.gov", Version: "3.1", Severity: "HIGH"},
+		},
+		KEV: map[string]types.KEVEntry{},
+	}
+
+	inputs := Inputs([]types.Vulnerability{vulnerability}, sources)
+	return inputs[0]
+}
+
+func TestMerge(t *testing.T) {
+	input := lodashInput()
+	assert.Len(t, input.Advisories, 3)
+	assert.Len(t, input.Scores, 3)
+
+	merged := Merge(DefaultPrecedence(), input)
+
+	assert.Equal(t, "CVE-2021-23337", merged.CanonicalId)
+	assert.Equal(t, "Command Injection in lodash", merged.Summary)
+	assert.Equal(t, "osv", merged.SummarySource)
+	assert.Equal(t, "gcve", merged.DescriptionSource)
+	assert.Equal(t, "nvd", merged.SeveritySource)
+	assert.Equal(t, "3.1", merged.CVSSVersion)
+	assert.Equal(t, 7.2, *merged.BaseScore)
+	assert.Equal(t, []string{"CWE-94"}, merged.Cwes)
+	assert.Equal(t, "nvd", merged.CwesSource)
+	assert.Equal(t, "osv", merged.AffectedSource)
+	assert.Equal(t, "osv", merged.ReferencesSource)
+	assert.Equal(t, "epss", merged.EPSSSource)
+	assert.Nil(t, merged.EPSSScore)
+	assert.False(t, merged.KEV)
+	assert.NotEmpty(t, merged.Fingerprint)
+
+	// Same data, same fingerprint
+	assert.Equal(t, merged.Fingerprint, Merge(DefaultPrecedence(), input).Fingerprint)
+}
+
+func TestMergeWithPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "precedence.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"description": ["osv", "nvd"], "severity": ["osv"], "cwes": ["osv"]}`), 0o644))
+
+	precedence, err := LoadPrecedence(path)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPrecedence()[FieldSummary], precedence[FieldSummary])
+
+	input := lodashInput()
+	merged := Merge(precedence, input)
+	assert.Equal(t, "osv", merged.DescriptionSource)
+	assert.Equal(t, "osv", merged.SeveritySource)
+	assert.Equal(t, []string{"CWE-77", "CWE-94"}, merged.Cwes)
+
+	// The fingerprint changes with the merged fields
+	assert.NotEqual(t, Merge(DefaultPrecedence(), input).Fingerprint, merged.Fingerprint)
+
+	// Every advisory is merged again when the precedence changes
+	assert.Equal(t, precedenceHash(DefaultPrecedence()), precedenceHash(DefaultPrecedence()))
+	assert.NotEqual(t, precedenceHash(DefaultPrecedence()), precedenceHash(precedence))
+}
+
+func TestLoadPrecedenceUnknownField(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "precedence.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"title": ["osv"]}`), 0o644))
+
+	_, err := LoadPrecedence(path)
+	assert.Error(t, err)
+}
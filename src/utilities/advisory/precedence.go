@@ -0,0 +1,61 @@
+// Package advisory merges the advisories of every source describing a vulnerability into a single
+// merged advisory. Each field is taken from the first source of its precedence list that has it.
+package advisory
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Fields of a merged advisory whose source is picked by precedence
+const (
+	FieldSummary     = "summary"
+	FieldDescription = "description"
+	FieldSeverity    = "severity"
+	FieldCwes        = "cwes"
+	FieldReferences  = "references"
+	FieldAffected    = "affected"
+	FieldEPSS        = "epss"
+)
+
+//go:embed precedence.json
+var defaultPrecedenceFile []byte
+
+// Precedence lists, for every field, the sources it's taken from in order of preference.
+type Precedence map[string][]string
+
+// DefaultPrecedence returns the built-in precedence.
+func DefaultPrecedence() Precedence {
+	var precedence Precedence
+	if err := json.Unmarshal(defaultPrecedenceFile, &precedence); err != nil {
+		panic(fmt.Sprintf("invalid embedded precedence.json: %v", err))
+	}
+	return precedence
+}
+
+// LoadPrecedence returns the default precedence overridden by the fields of the JSON file at path,
+// e.g. {"description": ["nvd", "gcve"]}. An empty path returns the default precedence.
+func LoadPrecedence(path string) (Precedence, error) {
+	precedence := DefaultPrecedence()
+	if path == "" {
+		return precedence, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read precedence file: %w", err)
+	}
+	var overrides Precedence
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse precedence file %s: %w", path, err)
+	}
+	for field, sources := range overrides {
+		if _, ok := precedence[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q in precedence file %s", field, path)
+		}
+		precedence[field] = sources
+	}
+	return precedence, nil
+}
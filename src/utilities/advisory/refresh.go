@@ -0,0 +1,135 @@
+package advisory
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/pgsql"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// refreshBatchSize is the number of vulnerabilities merged at once.
+const refreshBatchSize = 500
+
+// refreshState is the mirror state of the merged advisories, its cursor is the hash of the
+// precedence they were merged with.
+const refreshState = "merged_advisory"
+
+// Refresh merges the advisories of the vulnerabilities that changed since the previous refresh,
+// with the precedence of the MERGED_ADVISORY_PRECEDENCE file if set. The changes are recorded in
+// advisory_change by triggers on the source tables. Every vulnerability is merged again on the
+// first refresh and when the precedence changed. Only the advisories whose merged fields changed
+// are written.
+func Refresh(db *bun.DB) error {
+	precedence, err := LoadPrecedence(os.Getenv("MERGED_ADVISORY_PRECEDENCE"))
+	if err != nil {
+		return err
+	}
+	state, err := pgsql.GetMirrorState(db, refreshState)
+	if err != nil {
+		return err
+	}
+
+	// Changes recorded while merging are left for the next refresh
+	until, err := pgsql.GetLastAdvisoryChange(db)
+	if err != nil {
+		return err
+	}
+
+	var total, written int64
+	hash := precedenceHash(precedence)
+	if state.LastSync.IsZero() || state.Cursor != hash {
+		log.Println("Merging the advisories of every vulnerability")
+		after := uuid.Nil
+		for {
+			vulnerabilities, err := pgsql.GetVulnerabilities(db, after, refreshBatchSize)
+			if err != nil {
+				return err
+			}
+			if len(vulnerabilities) == 0 {
+				break
+			}
+			after = vulnerabilities[len(vulnerabilities)-1].Id
+
+			count, err := mergeBatch(db, precedence, vulnerabilities)
+			if err != nil {
+				return err
+			}
+			total += int64(len(vulnerabilities))
+			written += count
+		}
+	} else if !until.IsZero() {
+		ids, err := pgsql.GetChangedVulnerabilityIds(db, until)
+		if err != nil {
+			return err
+		}
+		for start := 0; start < len(ids); start += refreshBatchSize {
+			vulnerabilities, err := pgsql.GetVulnerabilitiesByIds(db, ids[start:min(start+refreshBatchSize, len(ids))])
+			if err != nil {
+				return err
+			}
+			count, err := mergeBatch(db, precedence, vulnerabilities)
+			if err != nil {
+				return err
+			}
+			total += int64(len(vulnerabilities))
+			written += count
+		}
+	}
+
+	removed, err := pgsql.DeleteOrphanMergedAdvisories(db)
+	if err != nil {
+		return err
+	}
+	if !until.IsZero() {
+		if err := pgsql.DeleteAdvisoryChanges(db, until); err != nil {
+			return err
+		}
+	}
+
+	state.LastSync = time.Now()
+	state.Cursor = hash
+	if err := pgsql.SetMirrorState(db, state); err != nil {
+		return err
+	}
+
+	log.Printf("Merged advisories: %d vulnerabilities, %d changed, %d removed", total, written, removed)
+	return nil
+}
+
+// mergeBatch merges the advisories of vulnerabilities and stores the ones that changed.
+// It returns the number of advisories written.
+func mergeBatch(db *bun.DB, precedence Precedence, vulnerabilities []types.Vulnerability) (int64, error) {
+	ids := make([]uuid.UUID, len(vulnerabilities))
+	for i, vulnerability := range vulnerabilities {
+		ids[i] = vulnerability.Id
+	}
+	sources, err := pgsql.GetAdvisorySources(db, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	inputs := Inputs(vulnerabilities, sources)
+	merged := make([]types.MergedAdvisory, 0, len(inputs))
+	for _, input := range inputs {
+		advisory := Merge(precedence, input)
+		advisory.UpdatedAt = now
+		merged = append(merged, advisory)
+	}
+
+	return pgsql.UpsertMergedAdvisories(db, merged)
+}
+
+// precedenceHash hashes a precedence, the merged advisories must be merged again when it changes.
+func precedenceHash(precedence Precedence) string {
+	data, _ := json.Marshal(precedence)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
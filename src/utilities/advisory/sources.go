@@ -0,0 +1,90 @@
+package advisory
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+func fromOSV(item knowledge.OSVItem) Advisory {
+	advisory := Advisory{
+		Source:      "osv",
+		Id:          item.OSVId,
+		Summary:     item.Summary,
+		Description: item.Details,
+		Cwes:        item.Cwes,
+	}
+	for _, reference := range item.References {
+		advisory.References = appendURL(advisory.References, reference.Url)
+	}
+	if len(item.Affected) > 0 {
+		advisory.Affected, _ = json.Marshal(item.Affected)
+	}
+	return advisory
+}
+
+func fromNVD(item knowledge.NVDItem) Advisory {
+	advisory := Advisory{
+		Source: "nvd",
+		Id:     item.NVDId,
+	}
+	for _, description := range item.Descriptions {
+		if description.Lang == "en" {
+			advisory.Description = description.Value
+			break
+		}
+	}
+	for _, weakness := range item.Weaknesses {
+		for _, description := range weakness.Descriptions {
+			// NVD-CWE-Other and NVD-CWE-noinfo aren't weaknesses
+			if strings.HasPrefix(description.Value, "CWE-") && !slices.Contains(advisory.Cwes, description.Value) {
+				advisory.Cwes = append(advisory.Cwes, description.Value)
+			}
+		}
+	}
+	for _, reference := range item.References {
+		advisory.References = appendURL(advisory.References, reference.URL)
+	}
+	return advisory
+}
+
+func fromGCVE(item knowledge.GCVEItem) Advisory {
+	advisory := Advisory{
+		Source: "gcve",
+		Id:     item.GCVEId,
+		Cwes:   item.Cwes,
+	}
+	for _, description := range item.Descriptions {
+		if strings.HasPrefix(description.Lang, "en") {
+			advisory.Description = description.Value
+			break
+		}
+	}
+	for _, reference := range item.References {
+		advisory.References = appendURL(advisory.References, reference.URL)
+	}
+	if len(item.Affected) > 0 {
+		advisory.Affected, _ = json.Marshal(item.Affected)
+	}
+	return advisory
+}
+
+func fromFriendsOfPHP(item knowledge.FriendsOfPHPAdvisory) Advisory {
+	advisory := Advisory{
+		Source:      "friends_of_php",
+		Id:          item.AdvisoryId,
+		Summary:     item.Title,
+		Description: item.Description,
+	}
+	advisory.References = appendURL(advisory.References, item.Link)
+	return advisory
+}
+
+func appendURL(urls []string, url string) []string {
+	if url == "" || slices.Contains(urls, url) {
+		return urls
+	}
+	return append(urls, url)
+}
@@ -0,0 +1,202 @@
+// Package aliasgraph groups the advisories of the different sources (OSV, NVD, GCVE, FriendsOfPHP)
+// describing the same real-world vulnerability. Advisories are linked through their aliases and
+// the groups are the connected components of the alias graph, computed with a union-find.
+// A group never holds two CVE ids: an advisory aliasing several CVEs is only linked to the first.
+package aliasgraph
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Record is an advisory of a source along with the identifiers it's known by.
+type Record struct {
+	Source   string
+	RecordId uuid.UUID
+	Id       string
+	Aliases  []string
+}
+
+// Group is a real-world vulnerability: every identifier and advisory linked together.
+type Group struct {
+	// Canonical is the identifier the vulnerability is known by, see Canonical
+	Canonical string
+	Ids       []string
+	Records   []Record
+}
+
+// UnionFind is a disjoint-set of identifiers, with path compression and union by rank.
+// It keeps track of the CVE id of every set, so that two different CVEs are never merged.
+type UnionFind struct {
+	parent map[string]string
+	rank   map[string]int
+	// cve is the CVE id of the set of a representative, if it has one
+	cve map[string]string
+}
+
+func NewUnionFind() *UnionFind {
+	return &UnionFind{
+		parent: make(map[string]string),
+		rank:   make(map[string]int),
+		cve:    make(map[string]string),
+	}
+}
+
+// Add adds an identifier in its own set, if it's not known yet.
+func (u *UnionFind) Add(id string) {
+	if _, ok := u.parent[id]; !ok {
+		u.parent[id] = id
+		if strings.HasPrefix(id, "CVE-") {
+			u.cve[id] = id
+		}
+	}
+}
+
+// Find returns the representative of the set of an identifier, adding it if needed.
+func (u *UnionFind) Find(id string) string {
+	u.Add(id)
+	root := id
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+	for u.parent[id] != root {
+		next := u.parent[id]
+		u.parent[id] = root
+		id = next
+	}
+	return root
+}
+
+// Union merges the sets of two identifiers, unless they hold different CVE ids.
+// It reports whether the identifiers are in the same set.
+func (u *UnionFind) Union(a, b string) bool {
+	rootA, rootB := u.Find(a), u.Find(b)
+	if rootA == rootB {
+		return true
+	}
+	cveA, cveB := u.cve[rootA], u.cve[rootB]
+	if cveA != "" && cveB != "" && cveA != cveB {
+		return false
+	}
+	if u.rank[rootA] < u.rank[rootB] {
+		rootA, rootB = rootB, rootA
+	}
+	u.parent[rootB] = rootA
+	if u.rank[rootA] == u.rank[rootB] {
+		u.rank[rootA]++
+	}
+	if cveA == "" {
+		cveA = cveB
+	}
+	if cveA != "" {
+		u.cve[rootA] = cveA
+	}
+	delete(u.cve, rootB)
+	return true
+}
+
+// Normalize returns the identifier as used in the graph: trimmed and upper-cased,
+// except for GHSA ids whose suffix is lower case.
+func Normalize(id string) string {
+	id = strings.TrimSpace(id)
+	if len(id) > 5 && strings.EqualFold(id[:5], "GHSA-") {
+		return "GHSA-" + strings.ToLower(id[5:])
+	}
+	return strings.ToUpper(id)
+}
+
+// Build groups the records linked by their identifiers and aliases.
+// Groups and their identifiers are sorted, so that the result is stable. The aliases that
+// would merge two CVEs are left out of the group of the record.
+func Build(records []Record) []Group {
+	// Which of two CVEs an advisory is linked to depends on the order of the unions,
+	// records and aliases are sorted so that it doesn't change from one build to the next
+	sorted := slices.Clone(records)
+	slices.SortFunc(sorted, func(a, b Record) int {
+		if c := strings.Compare(Normalize(a.Id), Normalize(b.Id)); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Source, b.Source); c != 0 {
+			return c
+		}
+		return strings.Compare(a.RecordId.String(), b.RecordId.String())
+	})
+
+	uf := NewUnionFind()
+	for _, record := range sorted {
+		id := Normalize(record.Id)
+		if id == "" {
+			continue
+		}
+		uf.Add(id)
+		aliases := make([]string, 0, len(record.Aliases))
+		for _, alias := range record.Aliases {
+			if alias = Normalize(alias); alias != "" {
+				aliases = append(aliases, alias)
+			}
+		}
+		slices.Sort(aliases)
+		for _, alias := range aliases {
+			uf.Union(id, alias)
+		}
+	}
+
+	groups := make(map[string]*Group)
+	for id := range uf.parent {
+		root := uf.Find(id)
+		group, ok := groups[root]
+		if !ok {
+			group = &Group{}
+			groups[root] = group
+		}
+		group.Ids = append(group.Ids, id)
+	}
+	for _, record := range sorted {
+		id := Normalize(record.Id)
+		if id == "" {
+			continue
+		}
+		group := groups[uf.Find(id)]
+		group.Records = append(group.Records, record)
+	}
+
+	result := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		slices.Sort(group.Ids)
+		group.Canonical = Canonical(group.Ids)
+		result = append(result, *group)
+	}
+	slices.SortFunc(result, func(a, b Group) int {
+		return strings.Compare(a.Canonical, b.Canonical)
+	})
+	return result
+}
+
+// Canonical picks the identifier a group of aliases is known by: a CVE id if any,
+// otherwise a GHSA id, a GCVE id, or the first identifier in lexical order.
+func Canonical(ids []string) string {
+	best := ""
+	bestRank := 0
+	for _, id := range ids {
+		rank := idRank(id)
+		if best == "" || rank < bestRank || (rank == bestRank && id < best) {
+			best, bestRank = id, rank
+		}
+	}
+	return best
+}
+
+func idRank(id string) int {
+	switch {
+	case strings.HasPrefix(id, "CVE-"):
+		return 0
+	case strings.HasPrefix(id, "GHSA-"):
+		return 1
+	case strings.HasPrefix(id, "GCVE-"):
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,70 @@
+package aliasgraph
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnionFind(t *testing.T) {
+	uf := NewUnionFind()
+	uf.Union("a", "b")
+	uf.Union("c", "d")
+	assert.NotEqual(t, uf.Find("a"), uf.Find("c"))
+
+	uf.Union("b", "d")
+	assert.Equal(t, uf.Find("a"), uf.Find("c"))
+	assert.Equal(t, "e", uf.Find("e"))
+
+	// Sets of different CVEs are never merged
+	assert.True(t, uf.Union("CVE-2024-0001", "a"))
+	assert.False(t, uf.Union("CVE-2024-0002", "c"))
+	assert.NotEqual(t, uf.Find("CVE-2024-0001"), uf.Find("CVE-2024-0002"))
+	assert.True(t, uf.Union("CVE-2024-0001", "d"))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "CVE-2021-23337", Normalize(" cve-2021-23337 "))
+	assert.Equal(t, "GHSA-35jh-r3h4-6jhm", Normalize("GHSA-35JH-R3H4-6JHM"))
+}
+
+func TestBuild(t *testing.T) {
+	osv := Record{Source: "osv", RecordId: uuid.New(), Id: "GHSA-35jh-r3h4-6jhm", Aliases: []string{"CVE-2021-23337"}}
+	nvd := Record{Source: "nvd", RecordId: uuid.New(), Id: "CVE-2021-23337"}
+	gcve := Record{Source: "gcve", RecordId: uuid.New(), Id: "GCVE-0-2021-23337", Aliases: []string{"CVE-2021-23337"}}
+	fop := Record{Source: "friends_of_php", RecordId: uuid.New(), Id: "PKSA-1234-abcd", Aliases: []string{"GHSA-35JH-R3H4-6JHM"}}
+	other := Record{Source: "nvd", RecordId: uuid.New(), Id: "CVE-2024-0001"}
+
+	groups := Build([]Record{osv, nvd, gcve, fop, other})
+	assert.Len(t, groups, 2)
+
+	assert.Equal(t, "CVE-2021-23337", groups[0].Canonical)
+	assert.Equal(t, []string{"CVE-2021-23337", "GCVE-0-2021-23337", "GHSA-35jh-r3h4-6jhm", "PKSA-1234-ABCD"}, groups[0].Ids)
+	assert.Len(t, groups[0].Records, 4)
+
+	assert.Equal(t, "CVE-2024-0001", groups[1].Canonical)
+	assert.Equal(t, []Record{other}, groups[1].Records)
+}
+
+func TestBuildSeparatesCVEs(t *testing.T) {
+	// An advisory covering two CVEs must not merge them
+	osv := Record{Source: "osv", RecordId: uuid.New(), Id: "GHSA-aaaa-bbbb-cccc", Aliases: []string{"CVE-2024-0002", "CVE-2024-0001"}}
+	first := Record{Source: "nvd", RecordId: uuid.New(), Id: "CVE-2024-0001"}
+	second := Record{Source: "gcve", RecordId: uuid.New(), Id: "GCVE-0-2024-0002", Aliases: []string{"CVE-2024-0002"}}
+
+	groups := Build([]Record{second, osv, first})
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "CVE-2024-0001", groups[0].Canonical)
+	assert.Equal(t, []string{"CVE-2024-0001", "GHSA-aaaa-bbbb-cccc"}, groups[0].Ids)
+	assert.Equal(t, "CVE-2024-0002", groups[1].Canonical)
+	assert.Equal(t, []string{"CVE-2024-0002", "GCVE-0-2024-0002"}, groups[1].Ids)
+
+	// The same groups whatever the order of the records
+	assert.Equal(t, groups, Build([]Record{first, second, osv}))
+}
+
+func TestCanonical(t *testing.T) {
+	assert.Equal(t, "GHSA-aaaa-bbbb-cccc", Canonical([]string{"PYSEC-2024-1", "GHSA-aaaa-bbbb-cccc", "GCVE-1-2024-0001"}))
+	assert.Equal(t, "CVE-2024-0001", Canonical([]string{"CVE-2024-0002", "CVE-2024-0001"}))
+}
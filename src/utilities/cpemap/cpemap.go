@@ -0,0 +1,183 @@
+// Package cpemap resolves CPE vendor:product pairs to packages of the ecosystems followed by CodeClarity.
+// Mappings come from a curated seed file and from heuristics on the CPE target software and the
+// references of the vulnerabilities using the CPE.
+package cpemap
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+)
+
+//go:embed seed.json
+var seedFile []byte
+
+// Ecosystems as written in package_vulnerability.package_ecosystem
+const (
+	EcosystemNpm       = "npm"
+	EcosystemPackagist = "packagist"
+	EcosystemPyPI      = "pypi"
+	EcosystemMaven     = "maven"
+	// EcosystemNVD pins a CPE product as not being a package in the mapping table.
+	// It was also the ecosystem of the NVD links to bare CPE products.
+	EcosystemNVD = "nvd"
+)
+
+// Mapping is a resolved package for a CPE vendor:product pair.
+type Mapping struct {
+	Ecosystem string
+	Name      string
+	Evidence  string
+}
+
+// Repository is a GitHub repository referenced by a vulnerability. It only becomes a mapping
+// once a package whose source points at that repository has been found.
+type Repository struct {
+	Owner string
+	Name  string
+}
+
+type seedEntry struct {
+	Vendor    string `json:"vendor"`
+	Product   string `json:"product"`
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+// Key returns the lookup key of a vendor:product pair.
+func Key(vendor, product string) string {
+	return strings.ToLower(Unescape(vendor)) + ":" + strings.ToLower(Unescape(product))
+}
+
+// Unescape removes the CPE 2.3 quoting of special characters ("node\.js" -> "node.js").
+func Unescape(value string) string {
+	return strings.ReplaceAll(value, "\\", "")
+}
+
+// Seed returns the curated mappings shipped with the service.
+func Seed() ([]types.CPEPackageMapping, error) {
+	var entries []seedEntry
+	if err := json.Unmarshal(seedFile, &entries); err != nil {
+		return nil, err
+	}
+
+	mappings := make([]types.CPEPackageMapping, 0, len(entries))
+	for _, entry := range entries {
+		mappings = append(mappings, types.CPEPackageMapping{
+			Vendor:      strings.ToLower(entry.Vendor),
+			Product:     strings.ToLower(entry.Product),
+			Ecosystem:   entry.Ecosystem,
+			PackageName: entry.Name,
+			Purl:        Purl(entry.Ecosystem, entry.Name),
+			Source:      types.MappingSourceSeed,
+			Evidence:    "seed.json",
+		})
+	}
+	return mappings, nil
+}
+
+// Guess applies the mapping heuristics to a CPE product.
+// References to npmjs.com and packagist.org take precedence over the target software of the CPE.
+// When nothing matches, the GitHub repositories named after the product are returned so the
+// caller can look them up in the package sources.
+func Guess(vendor, product, targetSw string, references []string) (Mapping, []Repository, bool) {
+	product = Unescape(product)
+	targetSw = strings.ToLower(Unescape(targetSw))
+
+	var repositories []Repository
+	for _, reference := range references {
+		u, err := url.Parse(strings.TrimSpace(reference))
+		if err != nil {
+			continue
+		}
+		host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+		switch host {
+		case "npmjs.com", "npmjs.org":
+			if len(segments) < 2 || segments[0] != "package" {
+				continue
+			}
+			name := segments[1]
+			if strings.HasPrefix(name, "@") && len(segments) > 2 {
+				name += "/" + segments[2]
+			}
+			if sameName(name, product) {
+				return Mapping{Ecosystem: EcosystemNpm, Name: strings.ToLower(name), Evidence: reference}, nil, true
+			}
+		case "packagist.org":
+			if len(segments) < 3 || segments[0] != "packages" {
+				continue
+			}
+			if sameName(segments[2], product) {
+				name := strings.ToLower(segments[1] + "/" + segments[2])
+				return Mapping{Ecosystem: EcosystemPackagist, Name: name, Evidence: reference}, nil, true
+			}
+		case "github.com":
+			if len(segments) < 2 {
+				continue
+			}
+			repository := Repository{Owner: strings.ToLower(segments[0]), Name: strings.ToLower(strings.TrimSuffix(segments[1], ".git"))}
+			if sameName(repository.Name, product) && !containsRepository(repositories, repository) {
+				repositories = append(repositories, repository)
+			}
+		}
+	}
+
+	switch targetSw {
+	case "node.js", "nodejs":
+		return Mapping{Ecosystem: EcosystemNpm, Name: strings.ToLower(product), Evidence: "target_sw:" + targetSw}, nil, true
+	case "wordpress":
+		return Mapping{Ecosystem: EcosystemPackagist, Name: "wpackagist-plugin/" + strings.ToLower(product), Evidence: "target_sw:" + targetSw}, nil, true
+	}
+
+	return Mapping{}, repositories, false
+}
+
+// Purl returns the package URL of a package.
+// https://github.com/package-url/purl-spec
+func Purl(ecosystem, name string) string {
+	switch ecosystem {
+	case EcosystemNpm:
+		return "pkg:npm/" + strings.Replace(name, "@", "%40", 1)
+	case EcosystemPackagist:
+		return "pkg:composer/" + name
+	case EcosystemPyPI:
+		return "pkg:pypi/" + name
+	case EcosystemMaven:
+		return "pkg:maven/" + strings.Replace(name, ":", "/", 1)
+	default:
+		return ""
+	}
+}
+
+// sameName compares a package or repository name with a CPE product, ignoring case, the npm scope
+// and punctuation ("node-fetch" matches "node_fetch").
+func sameName(name, product string) bool {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return normalize(name) != "" && normalize(name) == normalize(product)
+}
+
+func normalize(value string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(value) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func containsRepository(repositories []Repository, repository Repository) bool {
+	for _, r := range repositories {
+		if r == repository {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package cpemap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeed(t *testing.T) {
+	mappings, err := Seed()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, mappings)
+	for _, m := range mappings {
+		assert.NotEmpty(t, m.PackageName)
+		assert.NotEmpty(t, m.Purl, "%s:%s has no purl", m.Vendor, m.Product)
+	}
+}
+
+func TestGuessTargetSw(t *testing.T) {
+	mapping, _, ok := Guess("minimist_project", "minimist", "node.js", nil)
+	assert.True(t, ok)
+	assert.Equal(t, EcosystemNpm, mapping.Ecosystem)
+	assert.Equal(t, "minimist", mapping.Name)
+
+	mapping, _, ok = Guess("acme", "contact-form-7", "wordpress", nil)
+	assert.True(t, ok)
+	assert.Equal(t, EcosystemPackagist, mapping.Ecosystem)
+	assert.Equal(t, "wpackagist-plugin/contact-form-7", mapping.Name)
+}
+
+func TestGuessReferences(t *testing.T) {
+	mapping, _, ok := Guess("acme", "parser", "*", []string{"https://www.npmjs.com/package/@acme/parser"})
+	assert.True(t, ok)
+	assert.Equal(t, "@acme/parser", mapping.Name)
+	assert.Equal(t, "pkg:npm/%40acme/parser", Purl(mapping.Ecosystem, mapping.Name))
+
+	mapping, _, ok = Guess("thephpleague", "commonmark", "*", []string{"https://packagist.org/packages/league/commonmark"})
+	assert.True(t, ok)
+	assert.Equal(t, EcosystemPackagist, mapping.Ecosystem)
+	assert.Equal(t, "league/commonmark", mapping.Name)
+
+	// References to other packages are ignored
+	_, repositories, ok := Guess("acme", "widget", "*", []string{
+		"https://www.npmjs.com/package/lodash",
+		"https://github.com/acme/widget/security/advisories/GHSA-xxxx",
+		"https://github.com/someone/poc",
+	})
+	assert.False(t, ok)
+	assert.Equal(t, []Repository{{Owner: "acme", Name: "widget"}}, repositories)
+}
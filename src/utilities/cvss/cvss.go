@@ -0,0 +1,221 @@
+// Package cvss parses CVSS v2, v3.0, v3.1 and v4.0 vectors and computes their base scores.
+package cvss
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Supported CVSS versions
+const (
+	Version2  = "2.0"
+	Version30 = "3.0"
+	Version31 = "3.1"
+	Version40 = "4.0"
+)
+
+// Qualitative severity ratings
+const (
+	SeverityNone     = "NONE"
+	SeverityLow      = "LOW"
+	SeverityMedium   = "MEDIUM"
+	SeverityHigh     = "HIGH"
+	SeverityCritical = "CRITICAL"
+)
+
+// ErrScoreUnsupported is returned when the base score of a vector can't be computed.
+var ErrScoreUnsupported = errors.New("cvss: base score computation not supported for this version")
+
+// Vector is a parsed CVSS vector.
+type Vector struct {
+	Version string
+	// Metrics maps metric abbreviations to their value, e.g. "AV" -> "N"
+	Metrics map[string]string
+	// order keeps the metrics in the order they were written
+	order []string
+}
+
+// baseMetrics lists the mandatory base metrics of each version with their allowed values.
+var baseMetrics = map[string][]struct {
+	name   string
+	values string
+}{
+	Version2: {
+		{"AV", "LAN"}, {"AC", "HML"}, {"Au", "MSN"}, {"C", "NPC"}, {"I", "NPC"}, {"A", "NPC"},
+	},
+	Version30: v3BaseMetrics,
+	Version31: v3BaseMetrics,
+	Version40: {
+		{"AV", "NALP"}, {"AC", "LH"}, {"AT", "NP"}, {"PR", "NLH"}, {"UI", "NPA"},
+		{"VC", "HLN"}, {"VI", "HLN"}, {"VA", "HLN"}, {"SC", "HLN"}, {"SI", "HLN"}, {"SA", "HLN"},
+	},
+}
+
+var v3BaseMetrics = []struct {
+	name   string
+	values string
+}{
+	{"AV", "NALP"}, {"AC", "LH"}, {"PR", "NLH"}, {"UI", "NR"}, {"S", "UC"}, {"C", "HLN"}, {"I", "HLN"}, {"A", "HLN"},
+}
+
+// Parse parses a CVSS vector string. Vectors without a "CVSS:x.y/" prefix are read as v2,
+// optionally wrapped in parentheses as found in older NVD data.
+// Every base metric of the version must be present with a valid value; other metrics are kept as is.
+func Parse(vector string) (Vector, error) {
+	raw := strings.TrimSpace(vector)
+	v := Vector{Version: Version2, Metrics: make(map[string]string)}
+
+	if strings.HasPrefix(raw, "CVSS:") {
+		prefix, rest, _ := strings.Cut(raw, "/")
+		v.Version = strings.TrimPrefix(prefix, "CVSS:")
+		raw = rest
+	} else {
+		raw = strings.TrimSuffix(strings.TrimPrefix(raw, "("), ")")
+	}
+
+	metrics, ok := baseMetrics[v.Version]
+	if !ok {
+		return Vector{}, fmt.Errorf("cvss: unsupported version %q in %q", v.Version, vector)
+	}
+
+	for _, part := range strings.Split(raw, "/") {
+		name, value, found := strings.Cut(part, ":")
+		if !found || name == "" || value == "" {
+			return Vector{}, fmt.Errorf("cvss: malformed metric %q in %q", part, vector)
+		}
+		if _, exists := v.Metrics[name]; exists {
+			return Vector{}, fmt.Errorf("cvss: duplicate metric %q in %q", name, vector)
+		}
+		v.Metrics[name] = value
+		v.order = append(v.order, name)
+	}
+
+	for _, metric := range metrics {
+		value, ok := v.Metrics[metric.name]
+		if !ok {
+			return Vector{}, fmt.Errorf("cvss: missing base metric %s in %q", metric.name, vector)
+		}
+		if len(value) != 1 || !strings.Contains(metric.values, value) {
+			return Vector{}, fmt.Errorf("cvss: invalid value %q for metric %s in %q", value, metric.name, vector)
+		}
+	}
+
+	return v, nil
+}
+
+// String returns the vector in its canonical form, with the "CVSS:x.y/" prefix for v3 and v4.
+func (v Vector) String() string {
+	parts := make([]string, 0, len(v.order))
+	for _, name := range v.order {
+		parts = append(parts, name+":"+v.Metrics[name])
+	}
+	vector := strings.Join(parts, "/")
+	if v.Version == Version2 {
+		return vector
+	}
+	return "CVSS:" + v.Version + "/" + vector
+}
+
+// BaseScore computes the base score of the vector. The CVSS v4.0 score is the CVSS-B score:
+// the threat and environmental metrics of the vector are not taken into account.
+func (v Vector) BaseScore() (float64, error) {
+	switch v.Version {
+	case Version2:
+		return v.baseScoreV2(), nil
+	case Version30, Version31:
+		return v.baseScoreV3(), nil
+	case Version40:
+		return v.baseScoreV4(), nil
+	default:
+		return 0, ErrScoreUnsupported
+	}
+}
+
+// Severity returns the qualitative severity rating of a base score.
+// CVSS v2 has no None nor Critical rating, following the NVD scale.
+func Severity(version string, score float64) string {
+	if version == Version2 {
+		switch {
+		case score >= 7.0:
+			return SeverityHigh
+		case score >= 4.0:
+			return SeverityMedium
+		default:
+			return SeverityLow
+		}
+	}
+
+	switch {
+	case score >= 9.0:
+		return SeverityCritical
+	case score >= 7.0:
+		return SeverityHigh
+	case score >= 4.0:
+		return SeverityMedium
+	case score > 0:
+		return SeverityLow
+	default:
+		return SeverityNone
+	}
+}
+
+// NormalizeSeverity maps the qualitative ratings used by advisories ("moderate", "Important", ...)
+// to the CVSS ratings. Unknown ratings return an empty string.
+func NormalizeSeverity(severity string) string {
+	switch strings.ToUpper(strings.TrimSpace(severity)) {
+	case "NONE":
+		return SeverityNone
+	case "LOW":
+		return SeverityLow
+	case "MEDIUM", "MODERATE":
+		return SeverityMedium
+	case "HIGH", "IMPORTANT":
+		return SeverityHigh
+	case "CRITICAL":
+		return SeverityCritical
+	default:
+		return ""
+	}
+}
+
+// Result is the normalized severity of a vector.
+type Result struct {
+	Version   string
+	Vector    string
+	BaseScore *float64
+	Severity  string
+	// Computed is set when the base score was computed from the vector rather than provided by the source
+	Computed bool
+}
+
+// Evaluate parses a vector and resolves its base score and severity.
+// A score provided by the source is kept; otherwise it is computed when the version allows it.
+func Evaluate(vector string, provided *float64) (Result, error) {
+	v, err := Parse(vector)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Version: v.Version, Vector: v.String(), BaseScore: provided}
+	if provided == nil {
+		score, err := v.BaseScore()
+		if err == nil {
+			result.BaseScore = &score
+			result.Computed = true
+		} else if !errors.Is(err, ErrScoreUnsupported) {
+			return Result{}, err
+		}
+	}
+
+	if result.BaseScore != nil {
+		result.Severity = Severity(result.Version, *result.BaseScore)
+	}
+	return result, nil
+}
+
+// roundToOneDecimal rounds half away from zero, as used by CVSS v2.
+func roundToOneDecimal(x float64) float64 {
+	return math.Round(x*10) / 10
+}
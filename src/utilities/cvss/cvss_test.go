@@ -0,0 +1,92 @@
+package cvss
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseScore(t *testing.T) {
+	tests := []struct {
+		vector string
+		score  float64
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10.0},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N", 6.1},
+		{"CVSS:3.1/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N", 5.5},
+		{"CVSS:3.0/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:H", 7.5},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N", 0},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 7.5},
+		{"(AV:N/AC:M/Au:N/C:N/I:P/A:N)", 4.3},
+		{"AV:N/AC:L/Au:N/C:C/I:C/A:C", 10.0},
+		// CVSS v4.0 values of the FIRST calculator
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.3},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:H/SI:H/SA:H", 10.0},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.7},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:H/SC:N/SI:N/SA:N", 8.7},
+		{"CVSS:4.0/AV:L/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 8.5},
+		{"CVSS:4.0/AV:N/AC:H/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N", 9.2},
+		{"CVSS:4.0/AV:N/AC:L/AT:P/PR:N/UI:N/VC:H/VI:N/VA:N/SC:N/SI:N/SA:N", 8.2},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N", 6.9},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:N/VC:L/VI:N/VA:N/SC:N/SI:N/SA:N", 5.3},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:A/VC:N/VI:N/VA:N/SC:L/SI:L/SA:N", 5.1},
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:N/VI:N/VA:N/SC:N/SI:N/SA:N", 0},
+		// Threat and environmental metrics are left out of the base score
+		{"CVSS:4.0/AV:N/AC:L/AT:N/PR:N/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N/E:U/CR:L", 9.3},
+	}
+
+	for _, tt := range tests {
+		v, err := Parse(tt.vector)
+		assert.NoError(t, err, tt.vector)
+		score, err := v.BaseScore()
+		assert.NoError(t, err, tt.vector)
+		assert.Equal(t, tt.score, score, tt.vector)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, vector := range []string{
+		"",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",          // missing A
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",      // invalid value
+		"CVSS:3.1/AV:N/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", // duplicate metric
+		"CVSS:5.0/AV:N",
+	} {
+		_, err := Parse(vector)
+		assert.Error(t, err, vector)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	result, err := Evaluate("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Version31, result.Version)
+	assert.Equal(t, 9.8, *result.BaseScore)
+	assert.Equal(t, SeverityCritical, result.Severity)
+	assert.True(t, result.Computed)
+
+	v4 := "CVSS:4.0/AV:N/AC:L/AT:N/PR:L/UI:N/VC:H/VI:H/VA:H/SC:N/SI:N/SA:N"
+	result, err = Evaluate(v4, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 8.7, *result.BaseScore)
+	assert.Equal(t, SeverityHigh, result.Severity)
+	assert.True(t, result.Computed)
+
+	// A score provided by the source is kept
+	provided := 9.3
+	result, err = Evaluate(v4, &provided)
+	assert.NoError(t, err)
+	assert.Equal(t, 9.3, *result.BaseScore)
+	assert.Equal(t, SeverityCritical, result.Severity)
+	assert.False(t, result.Computed)
+}
+
+func TestSeverity(t *testing.T) {
+	assert.Equal(t, SeverityHigh, Severity(Version2, 10))
+	assert.Equal(t, SeverityLow, Severity(Version2, 0))
+	assert.Equal(t, SeverityNone, Severity(Version31, 0))
+	assert.Equal(t, SeverityMedium, Severity(Version31, 4.0))
+	assert.Equal(t, SeverityMedium, NormalizeSeverity("moderate"))
+	assert.Equal(t, "", NormalizeSeverity("unknown"))
+}
@@ -0,0 +1,41 @@
+package cvss
+
+import (
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+)
+
+// NewScore builds the normalized score of a vector published by provider for a vulnerability of source.
+// provided is the base score given by the source, if any.
+func NewScore(source, vulnerabilityId, provider, vector string, provided *float64) (types.VulnerabilityScore, error) {
+	result, err := Evaluate(vector, provided)
+	if err != nil {
+		return types.VulnerabilityScore{}, err
+	}
+
+	return types.VulnerabilityScore{
+		Source:          source,
+		VulnerabilityId: vulnerabilityId,
+		Provider:        provider,
+		Version:         result.Version,
+		Vector:          result.Vector,
+		BaseScore:       result.BaseScore,
+		Severity:        result.Severity,
+		Computed:        result.Computed,
+	}, nil
+}
+
+// NewQualitativeScore builds the score of an advisory only rated with a severity label.
+// It returns false when the label isn't a known severity.
+func NewQualitativeScore(source, vulnerabilityId, provider, severity string) (types.VulnerabilityScore, bool) {
+	normalized := NormalizeSeverity(severity)
+	if normalized == "" {
+		return types.VulnerabilityScore{}, false
+	}
+
+	return types.VulnerabilityScore{
+		Source:          source,
+		VulnerabilityId: vulnerabilityId,
+		Provider:        provider,
+		Severity:        normalized,
+	}, true
+}
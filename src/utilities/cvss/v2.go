@@ -0,0 +1,29 @@
+package cvss
+
+// CVSS v2 base equation
+// https://www.first.org/cvss/v2/guide#3-2-1-Base-Equation
+
+var v2Weights = map[string]map[string]float64{
+	"AV": {"L": 0.395, "A": 0.646, "N": 1.0},
+	"AC": {"H": 0.35, "M": 0.61, "L": 0.71},
+	"Au": {"M": 0.45, "S": 0.56, "N": 0.704},
+	"C":  {"N": 0.0, "P": 0.275, "C": 0.660},
+	"I":  {"N": 0.0, "P": 0.275, "C": 0.660},
+	"A":  {"N": 0.0, "P": 0.275, "C": 0.660},
+}
+
+func (v Vector) baseScoreV2() float64 {
+	w := func(metric string) float64 {
+		return v2Weights[metric][v.Metrics[metric]]
+	}
+
+	impact := 10.41 * (1 - (1-w("C"))*(1-w("I"))*(1-w("A")))
+	exploitability := 20 * w("AV") * w("AC") * w("Au")
+
+	f := 1.176
+	if impact == 0 {
+		f = 0
+	}
+
+	return roundToOneDecimal(((0.6 * impact) + (0.4 * exploitability) - 1.5) * f)
+}
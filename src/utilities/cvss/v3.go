@@ -0,0 +1,65 @@
+package cvss
+
+import "math"
+
+// CVSS v3.0 and v3.1 base equations
+// https://www.first.org/cvss/v3.1/specification-document#7-1-Base-Metrics-Equations
+
+var v3Weights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"UI": {"N": 0.85, "R": 0.62},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+func (v Vector) baseScoreV3() float64 {
+	w := func(metric string) float64 {
+		return v3Weights[metric][v.Metrics[metric]]
+	}
+
+	scopeChanged := v.Metrics["S"] == "C"
+
+	// Privileges Required weighs more when the scope changes
+	pr := map[string]float64{"N": 0.85, "L": 0.62, "H": 0.27}[v.Metrics["PR"]]
+	if scopeChanged {
+		pr = map[string]float64{"N": 0.85, "L": 0.68, "H": 0.5}[v.Metrics["PR"]]
+	}
+
+	iss := 1 - (1-w("C"))*(1-w("I"))*(1-w("A"))
+	impact := 6.42 * iss
+	if scopeChanged {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	exploitability := 8.22 * w("AV") * w("AC") * pr * w("UI")
+
+	if impact <= 0 {
+		return 0
+	}
+
+	roundup := roundupV31
+	if v.Version == Version30 {
+		roundup = roundupV30
+	}
+
+	if scopeChanged {
+		return roundup(math.Min(1.08*(impact+exploitability), 10))
+	}
+	return roundup(math.Min(impact+exploitability, 10))
+}
+
+// roundupV30 returns the smallest number, specified to one decimal place, that is equal to or higher than its input.
+func roundupV30(x float64) float64 {
+	return math.Ceil(x*10) / 10
+}
+
+// roundupV31 is the v3.1 Roundup function, which avoids the floating point errors of roundupV30.
+// https://www.first.org/cvss/v3.1/specification-document#Appendix-A---Floating-Point-Rounding
+func roundupV31(x float64) float64 {
+	intInput := int64(math.Round(x * 100000))
+	if intInput%10000 == 0 {
+		return float64(intInput) / 100000.0
+	}
+	return float64(intInput/10000+1) / 10.0
+}
@@ -0,0 +1,275 @@
+package cvss
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// CVSS v4.0 base score (CVSS-B)
+// https://www.first.org/cvss/v4.0/specification-document#CVSS-v4-0-Scoring
+//
+// A vector belongs to a macro vector, its level in each of the six equivalence classes (EQ1 to EQ6).
+// The score of the macro vector comes from the lookup table of the specification, and is lowered by
+// the distance of the vector to the most severe vector of its macro vector, proportionally to the
+// score difference with the next lower macro vectors. This follows the FIRST calculator, including
+// its floating point arithmetic. Threat and environmental metrics keep their default value.
+
+// v4Lookup maps a macro vector (EQ1 to EQ6 levels) to its score.
+var v4Lookup = map[string]float64{
+	"000000": 10, "000001": 9.9, "000010": 9.8, "000011": 9.5, "000020": 9.5, "000021": 9.2,
+	"000100": 10, "000101": 9.6, "000110": 9.3, "000111": 8.7, "000120": 9.1, "000121": 8.1,
+	"000200": 9.3, "000201": 9, "000210": 8.9, "000211": 8, "000220": 8.1, "000221": 6.8,
+	"001000": 9.8, "001001": 9.5, "001010": 9.5, "001011": 9.2, "001020": 9, "001021": 8.4,
+	"001100": 9.3, "001101": 9.2, "001110": 8.9, "001111": 8.1, "001120": 8.1, "001121": 6.5,
+	"001200": 8.8, "001201": 8, "001210": 7.8, "001211": 7, "001220": 6.9, "001221": 4.8,
+	"002001": 9.2, "002011": 8.2, "002021": 7.2, "002101": 7.9, "002111": 6.9, "002121": 5,
+	"002201": 6.9, "002211": 5.5, "002221": 2.7,
+	"010000": 9.9, "010001": 9.7, "010010": 9.5, "010011": 9.2, "010020": 9.2, "010021": 8.5,
+	"010100": 9.5, "010101": 9.1, "010110": 9, "010111": 8.3, "010120": 8.4, "010121": 7.1,
+	"010200": 9.2, "010201": 8.1, "010210": 8.2, "010211": 7.1, "010220": 7.2, "010221": 5.3,
+	"011000": 9.5, "011001": 9.3, "011010": 9.2, "011011": 8.5, "011020": 8.5, "011021": 7.3,
+	"011100": 9.2, "011101": 8.2, "011110": 8, "011111": 7.2, "011120": 7, "011121": 5.9,
+	"011200": 8.4, "011201": 7, "011210": 7.1, "011211": 5.2, "011220": 5, "011221": 3,
+	"012001": 8.6, "012011": 7.5, "012021": 5.2, "012101": 7.1, "012111": 5.2, "012121": 2.9,
+	"012201": 6.3, "012211": 2.9, "012221": 1.7,
+	"100000": 9.8, "100001": 9.5, "100010": 9.4, "100011": 8.7, "100020": 9.1, "100021": 8.1,
+	"100100": 9.4, "100101": 8.9, "100110": 8.6, "100111": 7.4, "100120": 7.7, "100121": 6.4,
+	"100200": 8.7, "100201": 7.5, "100210": 7.4, "100211": 6.3, "100220": 6.3, "100221": 4.9,
+	"101000": 9.4, "101001": 8.9, "101010": 8.8, "101011": 7.7, "101020": 7.6, "101021": 6.7,
+	"101100": 8.6, "101101": 7.6, "101110": 7.4, "101111": 5.8, "101120": 5.9, "101121": 5,
+	"101200": 7.2, "101201": 5.7, "101210": 5.7, "101211": 5.2, "101220": 5.2, "101221": 2.5,
+	"102001": 8.3, "102011": 7, "102021": 5.4, "102101": 6.5, "102111": 5.8, "102121": 2.6,
+	"102201": 5.3, "102211": 2.1, "102221": 1.3,
+	"110000": 9.5, "110001": 9, "110010": 8.8, "110011": 7.6, "110020": 7.6, "110021": 7,
+	"110100": 9, "110101": 7.7, "110110": 7.5, "110111": 6.2, "110120": 6.1, "110121": 5.3,
+	"110200": 7.7, "110201": 6.6, "110210": 6.8, "110211": 5.9, "110220": 5.2, "110221": 3,
+	"111000": 8.9, "111001": 7.8, "111010": 7.6, "111011": 6.7, "111020": 6.2, "111021": 5.8,
+	"111100": 7.4, "111101": 5.9, "111110": 5.7, "111111": 5.7, "111120": 4.7, "111121": 2.3,
+	"111200": 6.1, "111201": 5.2, "111210": 5.7, "111211": 2.9, "111220": 2.4, "111221": 1.6,
+	"112001": 7.1, "112011": 5.9, "112021": 3, "112101": 5.8, "112111": 2.6, "112121": 1.5,
+	"112201": 2.3, "112211": 1.3, "112221": 0.6,
+	"200000": 9.3, "200001": 8.7, "200010": 8.6, "200011": 7.2, "200020": 7.5, "200021": 5.8,
+	"200100": 8.6, "200101": 7.4, "200110": 7.4, "200111": 6.1, "200120": 5.6, "200121": 3.4,
+	"200200": 7, "200201": 5.4, "200210": 5.2, "200211": 4, "200220": 4, "200221": 2.2,
+	"201000": 8.5, "201001": 7.5, "201010": 7.4, "201011": 5.5, "201020": 6.2, "201021": 5.1,
+	"201100": 7.2, "201101": 5.7, "201110": 5.5, "201111": 4.1, "201120": 4.6, "201121": 1.9,
+	"201200": 5.3, "201201": 3.6, "201210": 3.4, "201211": 1.9, "201220": 1.9, "201221": 0.8,
+	"202001": 6.4, "202011": 5.1, "202021": 2, "202101": 4.7, "202111": 2.1, "202121": 1.1,
+	"202201": 2.4, "202211": 0.9, "202221": 0.4,
+	"210000": 8.8, "210001": 7.5, "210010": 7.3, "210011": 5.3, "210020": 6, "210021": 5,
+	"210100": 7.3, "210101": 5.5, "210110": 5.9, "210111": 4, "210120": 4.1, "210121": 2,
+	"210200": 5.4, "210201": 4.3, "210210": 4.5, "210211": 2.2, "210220": 2, "210221": 1.1,
+	"211000": 7.5, "211001": 5.5, "211010": 5.8, "211011": 4.5, "211020": 4, "211021": 2.1,
+	"211100": 6.1, "211101": 5.1, "211110": 4.8, "211111": 1.8, "211120": 2, "211121": 0.9,
+	"211200": 4.6, "211201": 1.8, "211210": 1.7, "211211": 0.7, "211220": 0.8, "211221": 0.2,
+	"212001": 5.3, "212011": 2.4, "212021": 1.4, "212101": 2.4, "212111": 1.2, "212121": 0.5,
+	"212201": 1, "212211": 0.3, "212221": 0.1,
+}
+
+// v4Levels is the severity level of each metric value, 0 being the most severe.
+var v4Levels = map[string]map[string]float64{
+	"AV": {"N": 0, "A": 0.1, "L": 0.2, "P": 0.3},
+	"PR": {"N": 0, "L": 0.1, "H": 0.2},
+	"UI": {"N": 0, "P": 0.1, "A": 0.2},
+	"AC": {"L": 0, "H": 0.1},
+	"AT": {"N": 0, "P": 0.1},
+	"VC": {"H": 0, "L": 0.1, "N": 0.2},
+	"VI": {"H": 0, "L": 0.1, "N": 0.2},
+	"VA": {"H": 0, "L": 0.1, "N": 0.2},
+	"SC": {"H": 0.1, "L": 0.2, "N": 0.3},
+	"SI": {"S": 0, "H": 0.1, "L": 0.2, "N": 0.3},
+	"SA": {"S": 0, "H": 0.1, "L": 0.2, "N": 0.3},
+	"CR": {"H": 0, "M": 0.1, "L": 0.2},
+	"IR": {"H": 0, "M": 0.1, "L": 0.2},
+	"AR": {"H": 0, "M": 0.1, "L": 0.2},
+}
+
+// v4Defaults are the values of the threat and environmental metrics left out of the base score.
+var v4Defaults = map[string]string{"E": "A", "CR": "H", "IR": "H", "AR": "H"}
+
+// v4MaxVectors are the most severe vectors of each level of the equivalence classes.
+// EQ3 and EQ6 are combined, indexed by EQ3 then EQ6.
+var (
+	v4MaxEQ1 = [][]string{
+		{"AV:N/PR:N/UI:N"},
+		{"AV:A/PR:N/UI:N", "AV:N/PR:L/UI:N", "AV:N/PR:N/UI:P"},
+		{"AV:P/PR:N/UI:N", "AV:A/PR:L/UI:P"},
+	}
+	v4MaxEQ2 = [][]string{
+		{"AC:L/AT:N"},
+		{"AC:H/AT:N", "AC:L/AT:P"},
+	}
+	v4MaxEQ3EQ6 = [][][]string{
+		{
+			{"VC:H/VI:H/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:H/VI:H/VA:L/CR:M/IR:M/AR:H", "VC:H/VI:H/VA:H/CR:M/IR:M/AR:M"},
+		},
+		{
+			{"VC:L/VI:H/VA:H/CR:H/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:H/AR:H"},
+			{"VC:L/VI:H/VA:H/CR:M/IR:H/AR:M", "VC:L/VI:H/VA:L/CR:M/IR:H/AR:H", "VC:H/VI:L/VA:H/CR:H/IR:M/AR:M", "VC:H/VI:L/VA:L/CR:H/IR:M/AR:H", "VC:L/VI:L/VA:H/CR:H/IR:H/AR:M"},
+		},
+		{
+			nil,
+			{"VC:L/VI:L/VA:L/CR:H/IR:H/AR:H"},
+		},
+	}
+	v4MaxEQ4 = [][]string{
+		{"SC:H/SI:S/SA:S"},
+		{"SC:H/SI:H/SA:H"},
+		{"SC:L/SI:L/SA:L"},
+	}
+)
+
+// v4MaxSeverity is the depth of each level of the equivalence classes, in steps of 0.1.
+var (
+	v4MaxSeverityEQ1    = []float64{1, 4, 5}
+	v4MaxSeverityEQ2    = []float64{1, 2}
+	v4MaxSeverityEQ3EQ6 = [][]float64{{7, 6}, {8, 8}, {0, 10}}
+	v4MaxSeverityEQ4    = []float64{6, 5, 4}
+)
+
+func (v Vector) baseScoreV4() float64 {
+	m := func(metric string) string {
+		if value, ok := v4Defaults[metric]; ok {
+			return value
+		}
+		return v.Metrics[metric]
+	}
+	is := func(metric, value string) bool {
+		return m(metric) == value
+	}
+
+	// Vectors without any impact score 0
+	if is("VC", "N") && is("VI", "N") && is("VA", "N") && is("SC", "N") && is("SI", "N") && is("SA", "N") {
+		return 0
+	}
+
+	var eq1, eq2, eq3, eq4, eq5, eq6 int
+	switch {
+	case is("AV", "N") && is("PR", "N") && is("UI", "N"):
+		eq1 = 0
+	case (is("AV", "N") || is("PR", "N") || is("UI", "N")) && !is("AV", "P"):
+		eq1 = 1
+	default:
+		eq1 = 2
+	}
+	if !is("AC", "L") || !is("AT", "N") {
+		eq2 = 1
+	}
+	switch {
+	case is("VC", "H") && is("VI", "H"):
+		eq3 = 0
+	case is("VC", "H") || is("VI", "H") || is("VA", "H"):
+		eq3 = 1
+	default:
+		eq3 = 2
+	}
+	switch {
+	case is("SI", "S") || is("SA", "S"):
+		eq4 = 0
+	case is("SC", "H") || is("SI", "H") || is("SA", "H"):
+		eq4 = 1
+	default:
+		eq4 = 2
+	}
+	switch m("E") {
+	case "P":
+		eq5 = 1
+	case "U":
+		eq5 = 2
+	}
+	if !(is("CR", "H") && is("VC", "H")) && !(is("IR", "H") && is("VI", "H")) && !(is("AR", "H") && is("VA", "H")) {
+		eq6 = 1
+	}
+
+	// Scores of the next lower macro vector of each equivalence class, NaN when there is none
+	lookup := func(eq1, eq2, eq3, eq4, eq5, eq6 int) float64 {
+		score, ok := v4Lookup[strconv.Itoa(eq1)+strconv.Itoa(eq2)+strconv.Itoa(eq3)+strconv.Itoa(eq4)+strconv.Itoa(eq5)+strconv.Itoa(eq6)]
+		if !ok {
+			return math.NaN()
+		}
+		return score
+	}
+	value := lookup(eq1, eq2, eq3, eq4, eq5, eq6)
+	lowerEQ1 := lookup(eq1+1, eq2, eq3, eq4, eq5, eq6)
+	lowerEQ2 := lookup(eq1, eq2+1, eq3, eq4, eq5, eq6)
+	var lowerEQ3EQ6 float64
+	switch {
+	case eq3 == 0 && eq6 == 0:
+		// Two lower macro vectors, the higher one is used
+		left := lookup(eq1, eq2, eq3, eq4, eq5, eq6+1)
+		right := lookup(eq1, eq2, eq3+1, eq4, eq5, eq6)
+		lowerEQ3EQ6 = right
+		if left > right {
+			lowerEQ3EQ6 = left
+		}
+	case eq3 == 1 && eq6 == 0:
+		lowerEQ3EQ6 = lookup(eq1, eq2, eq3, eq4, eq5, eq6+1)
+	default:
+		lowerEQ3EQ6 = lookup(eq1, eq2, eq3+1, eq4, eq5, eq6)
+	}
+	lowerEQ4 := lookup(eq1, eq2, eq3, eq4+1, eq5, eq6)
+	lowerEQ5 := lookup(eq1, eq2, eq3, eq4, eq5+1, eq6)
+
+	// The first most severe vector of the macro vector that is at least as severe as the vector
+	distance := func(metric string, max map[string]string) float64 {
+		return v4Levels[metric][m(metric)] - v4Levels[metric][max[metric]]
+	}
+	var max map[string]string
+search:
+	for _, maxEQ1 := range v4MaxEQ1[eq1] {
+		for _, maxEQ2 := range v4MaxEQ2[eq2] {
+			for _, maxEQ3EQ6 := range v4MaxEQ3EQ6[eq3][eq6] {
+				for _, maxEQ4 := range v4MaxEQ4[eq4] {
+					candidate := make(map[string]string, len(v4Levels))
+					for _, part := range strings.Split(maxEQ1+"/"+maxEQ2+"/"+maxEQ3EQ6+"/"+maxEQ4, "/") {
+						name, value, _ := strings.Cut(part, ":")
+						candidate[name] = value
+					}
+					severe := true
+					for metric := range v4Levels {
+						if distance(metric, candidate) < 0 {
+							severe = false
+							break
+						}
+					}
+					if severe {
+						max = candidate
+						break search
+					}
+				}
+			}
+		}
+	}
+
+	const step = 0.1
+	distanceEQ1 := distance("AV", max) + distance("PR", max) + distance("UI", max)
+	distanceEQ2 := distance("AC", max) + distance("AT", max)
+	distanceEQ3EQ6 := distance("VC", max) + distance("VI", max) + distance("VA", max) + distance("CR", max) + distance("IR", max) + distance("AR", max)
+	distanceEQ4 := distance("SC", max) + distance("SI", max) + distance("SA", max)
+
+	// Every class with a lower macro vector lowers the score by the share of the score difference
+	// matching the share of the depth of the class the vector is at
+	var total float64
+	lower := 0
+	for _, class := range []struct{ available, percent float64 }{
+		{value - lowerEQ1, distanceEQ1 / (v4MaxSeverityEQ1[eq1] * step)},
+		{value - lowerEQ2, distanceEQ2 / (v4MaxSeverityEQ2[eq2] * step)},
+		{value - lowerEQ3EQ6, distanceEQ3EQ6 / (v4MaxSeverityEQ3EQ6[eq3][eq6] * step)},
+		{value - lowerEQ4, distanceEQ4 / (v4MaxSeverityEQ4[eq4] * step)},
+		// EQ5 has a single value per level
+		{value - lowerEQ5, 0},
+	} {
+		if math.IsNaN(class.available) {
+			continue
+		}
+		lower++
+		total += class.available * class.percent
+	}
+	if lower > 0 {
+		value -= total / float64(lower)
+	}
+
+	return roundToOneDecimal(math.Min(math.Max(value, 0), 10))
+}
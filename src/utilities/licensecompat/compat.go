@@ -0,0 +1,324 @@
+// Package licensecompat evaluates whether the licenses of the dependencies of a project are compatible
+// with the license of the project, e.g. an Apache-2.0 dependency in a GPL-3.0 project is, an AGPL-3.0
+// dependency in an MIT project isn't. Verdicts come from an embedded, curated matrix of rules between
+// licenses, exceptions and the classifications of the license policy catalog.
+package licensecompat
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensepolicy"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
+)
+
+// Verdicts of a dependency, from the best to the worst
+const (
+	Compatible   = "compatible"
+	Review       = "review"
+	Unknown      = "unknown"
+	Incompatible = "incompatible"
+)
+
+var verdicts = []string{Compatible, Review, Unknown, Incompatible}
+
+// Any matches every license in a rule
+const Any = "*"
+
+//go:embed rules.json
+var defaultMatrixFile []byte
+
+// Matrix is the curated list of compatibility rules. Version is bumped whenever the embedded matrix changes.
+type Matrix struct {
+	Version int    `json:"version"`
+	Rules   []Rule `json:"rules"`
+}
+
+// Rule gives the verdict of using a dependency license in a project license. Both sides list SPDX
+// license or exception ids, license classifications or Any. The explanation may refer to the
+// licenses as {project} and {dependency}.
+type Rule struct {
+	Project     []string `json:"project"`
+	Dependency  []string `json:"dependency"`
+	Verdict     string   `json:"verdict"`
+	Explanation string   `json:"explanation"`
+}
+
+// Dependency is a dependency of a project and its license expression, as declared by its package.
+type Dependency struct {
+	Name    string `json:"name"`
+	License string `json:"license"`
+}
+
+// Result is the verdict of a dependency.
+type Result struct {
+	Name        string `json:"name"`
+	License     string `json:"license"`
+	Expression  string `json:"expression,omitempty"`
+	Verdict     string `json:"verdict"`
+	Explanation string `json:"explanation"`
+}
+
+// Report is the verdict of every dependency of a project. Its verdict is the worst of them.
+type Report struct {
+	Project      string   `json:"project"`
+	Verdict      string   `json:"verdict"`
+	Dependencies []Result `json:"dependencies"`
+}
+
+// Engine evaluates licenses with a matrix, and classifies the licenses it doesn't name with a catalog.
+type Engine struct {
+	matrix  *Matrix
+	catalog *licensepolicy.Catalog
+}
+
+// DefaultMatrix returns the embedded matrix. It is parsed once and shared, it must not be modified.
+var DefaultMatrix = sync.OnceValue(func() *Matrix {
+	var matrix Matrix
+	if err := json.Unmarshal(defaultMatrixFile, &matrix); err != nil {
+		panic(fmt.Sprintf("invalid embedded rules.json: %v", err))
+	}
+	if err := matrix.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid embedded rules.json: %v", err))
+	}
+	return &matrix
+})
+
+// Validate checks that every rule has both sides, a known verdict and an explanation.
+func (m *Matrix) Validate() error {
+	var errs []error
+	for i, rule := range m.Rules {
+		if len(rule.Project) == 0 || len(rule.Dependency) == 0 {
+			errs = append(errs, fmt.Errorf("rule %d has no project or dependency license", i))
+		}
+		if !slices.Contains(verdicts, rule.Verdict) {
+			errs = append(errs, fmt.Errorf("unknown verdict %q of rule %d", rule.Verdict, i))
+		}
+		if rule.Explanation == "" {
+			errs = append(errs, fmt.Errorf("rule %d has no explanation", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewEngine returns an engine of the rules of a matrix and of the classifications of a catalog.
+func NewEngine(matrix *Matrix, catalog *licensepolicy.Catalog) *Engine {
+	return &Engine{matrix: matrix, catalog: catalog}
+}
+
+// Evaluate evaluates the dependencies of a project with the embedded matrix and license policy catalog.
+func Evaluate(project string, dependencies []Dependency) (Report, error) {
+	return NewEngine(DefaultMatrix(), licensepolicy.DefaultCatalog()).Evaluate(project, dependencies)
+}
+
+// Evaluate returns the verdict of every dependency of a project, in the order of the dependencies.
+//
+// A dependency whose expression offers a choice (OR) gets the verdict of its best license, one that
+// requires several licenses (AND) the verdict of its worst. A project under several licenses must
+// be distributable under each of them, so a dependency gets its worst verdict against them.
+// LicenseRef- licenses are considered proprietary. Returns an error if the license of the project
+// isn't an SPDX expression.
+func (e *Engine) Evaluate(project string, dependencies []Dependency) (Report, error) {
+	normalized := spdx.Normalize(project)
+	if !normalized.Resolved() {
+		return Report{}, fmt.Errorf("project license %q is not an SPDX expression", project)
+	}
+	node, err := spdx.Parse(normalized.Expression)
+	if err != nil {
+		return Report{}, err
+	}
+	var projectLicenses []*spdx.License
+	leaves(node, func(license *spdx.License) {
+		projectLicenses = append(projectLicenses, license)
+	})
+
+	report := Report{
+		Project:      normalized.Expression,
+		Verdict:      Compatible,
+		Dependencies: make([]Result, 0, len(dependencies)),
+	}
+	for _, dependency := range dependencies {
+		result := e.evaluateDependency(projectLicenses, dependency)
+		report.Dependencies = append(report.Dependencies, result)
+		report.Verdict = worst(report.Verdict, result.Verdict)
+	}
+	return report, nil
+}
+
+// assessment is the verdict of a license expression, with the explanations it is based on.
+type assessment struct {
+	verdict      string
+	explanations []string
+}
+
+func (e *Engine) evaluateDependency(projectLicenses []*spdx.License, dependency Dependency) Result {
+	result := Result{Name: dependency.Name, License: dependency.License}
+
+	normalized := spdx.Normalize(dependency.License)
+	if !normalized.Resolved() {
+		result.Verdict = Unknown
+		result.Explanation = fmt.Sprintf("%q is not an SPDX license expression, its license must be reviewed.", dependency.License)
+		if strings.TrimSpace(dependency.License) == "" {
+			result.Explanation = "The dependency doesn't declare a license, its license must be reviewed."
+		}
+		return result
+	}
+	result.Expression = normalized.Expression
+
+	node, err := spdx.Parse(normalized.Expression)
+	if err != nil {
+		result.Verdict = Unknown
+		result.Explanation = err.Error()
+		return result
+	}
+	assessed := e.evaluateNode(projectLicenses, node)
+	result.Verdict = assessed.verdict
+	result.Explanation = strings.Join(assessed.explanations, " ")
+	return result
+}
+
+func (e *Engine) evaluateNode(projectLicenses []*spdx.License, node spdx.Node) assessment {
+	switch n := node.(type) {
+	case *spdx.License:
+		return e.evaluateLicense(projectLicenses, n)
+	case *spdx.Compound:
+		assessments := make([]assessment, len(n.Terms))
+		for i, term := range n.Terms {
+			assessments[i] = e.evaluateNode(projectLicenses, term)
+		}
+		if n.Operator == spdx.Or {
+			// The dependency can be used under its best license
+			best := assessments[0]
+			for _, a := range assessments[1:] {
+				if rank(a.verdict) < rank(best.verdict) {
+					best = a
+				}
+			}
+			return best
+		}
+		return combine(assessments)
+	}
+	return assessment{verdict: Unknown}
+}
+
+// evaluateLicense returns the worst verdict of a dependency license against the licenses of the project.
+func (e *Engine) evaluateLicense(projectLicenses []*spdx.License, dependency *spdx.License) assessment {
+	assessments := make([]assessment, len(projectLicenses))
+	for i, project := range projectLicenses {
+		verdict, explanation := e.verdict(project, dependency)
+		assessments[i] = assessment{verdict: verdict, explanations: []string{explanation}}
+	}
+	return combine(assessments)
+}
+
+// combine returns the worst verdict of assessments, with the explanations of the ones that have it.
+func combine(assessments []assessment) assessment {
+	combined := assessment{verdict: Compatible}
+	for _, a := range assessments {
+		combined.verdict = worst(combined.verdict, a.verdict)
+	}
+	for _, a := range assessments {
+		if a.verdict != combined.verdict {
+			continue
+		}
+		for _, explanation := range a.explanations {
+			if !slices.Contains(combined.explanations, explanation) {
+				combined.explanations = append(combined.explanations, explanation)
+			}
+		}
+	}
+	return combined
+}
+
+// verdict returns the verdict of the most specific rule matching a project and a dependency license.
+// The dependency side is the most important: a rule naming the exception of the dependency license
+// wins over a rule naming the license, then its classification, then Any. The project side then
+// decides in the same way, and the first rule of the matrix wins ties.
+func (e *Engine) verdict(project *spdx.License, dependency *spdx.License) (string, string) {
+	explain := strings.NewReplacer("{project}", project.String(), "{dependency}", dependency.String())
+	if project.String() == dependency.String() {
+		return Compatible, explain.Replace("{dependency} is the license of the project.")
+	}
+	// The exception of the project doesn't cover the dependency, e.g. a GPL-2.0-only library in a
+	// GPL-2.0-only WITH Classpath-exception-2.0 project takes away the permission the exception grants
+	if project.ID == dependency.ID && project.Exception != "" && dependency.Exception == "" {
+		return Review, explain.Replace("{dependency} doesn't grant the exception of the project license {project}, the project may not be distributable under its exception.")
+	}
+
+	var match *Rule
+	bestDependency, bestProject := -1, -1
+	for i := range e.matrix.Rules {
+		rule := &e.matrix.Rules[i]
+		dependencyScore := e.score(rule.Dependency, dependency)
+		projectScore := e.score(rule.Project, project)
+		if dependencyScore < 0 || projectScore < 0 {
+			continue
+		}
+		if dependencyScore > bestDependency || (dependencyScore == bestDependency && projectScore > bestProject) {
+			match, bestDependency, bestProject = rule, dependencyScore, projectScore
+		}
+	}
+	if match == nil {
+		return Unknown, explain.Replace("No compatibility rule covers {dependency} in a {project} project, its license must be reviewed.")
+	}
+	return match.Verdict, explain.Replace(match.Explanation)
+}
+
+// score returns how specifically a side of a rule matches a license: 3 for its exception, which
+// changes the terms of the license, 2 for its id, 1 for its classification, 0 for Any and -1 if it
+// doesn't match.
+func (e *Engine) score(keys []string, license *spdx.License) int {
+	score := -1
+	classification := e.classification(license.ID)
+	for _, key := range keys {
+		switch {
+		case license.Exception != "" && strings.EqualFold(key, license.Exception):
+			score = max(score, 3)
+		case strings.EqualFold(key, license.ID):
+			score = max(score, 2)
+		case classification != "" && key == classification:
+			score = max(score, 1)
+		case key == Any:
+			score = max(score, 0)
+		}
+	}
+	return score
+}
+
+func (e *Engine) classification(id string) string {
+	if strings.HasPrefix(id, "LicenseRef-") || strings.HasPrefix(id, "DocumentRef-") {
+		return licensepolicy.Proprietary
+	}
+	entry, ok := e.catalog.Lookup(id)
+	if !ok {
+		return ""
+	}
+	return entry.Classification
+}
+
+func leaves(node spdx.Node, fn func(*spdx.License)) {
+	switch n := node.(type) {
+	case *spdx.License:
+		fn(n)
+	case *spdx.Compound:
+		for _, term := range n.Terms {
+			leaves(term, fn)
+		}
+	}
+}
+
+func rank(verdict string) int {
+	return slices.Index(verdicts, verdict)
+}
+
+func worst(a, b string) string {
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
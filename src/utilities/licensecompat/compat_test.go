@@ -0,0 +1,149 @@
+package licensecompat
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensepolicy"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultMatrix(t *testing.T) {
+	matrix := DefaultMatrix()
+	assert.Equal(t, 1, matrix.Version)
+	assert.NotEmpty(t, matrix.Rules)
+
+	// Every rule names SPDX licenses, exceptions or classifications
+	classifications := []string{Any, licensepolicy.Permissive, licensepolicy.WeakCopyleft, licensepolicy.Copyleft, licensepolicy.Proprietary}
+	for _, rule := range matrix.Rules {
+		for _, key := range append(append([]string{}, rule.Project...), rule.Dependency...) {
+			if slices.Contains(classifications, key) {
+				continue
+			}
+			license := spdx.Normalize(key)
+			exception := spdx.Normalize("MIT WITH " + key)
+			assert.True(t, license.Expression == key || exception.Expression == "MIT WITH "+key, "unknown license or exception %s", key)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	report, err := Evaluate("GPL-3.0", []Dependency{
+		{Name: "apache", License: "Apache-2.0"},
+		{Name: "mit", License: "MIT"},
+		{Name: "lgpl", License: "LGPL-2.1-or-later"},
+		{Name: "gpl2", License: "GPL-2.0"},
+		{Name: "gpl2+", License: "GPL-2.0+"},
+		{Name: "agpl", License: "AGPL-3.0-only"},
+		{Name: "self", License: "GPL-3.0-only"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "GPL-3.0-only", report.Project)
+	assert.Equal(t, Incompatible, report.Verdict)
+
+	verdicts := make(map[string]string)
+	for _, result := range report.Dependencies {
+		verdicts[result.Name] = result.Verdict
+		assert.NotEmpty(t, result.Explanation, result.Name)
+	}
+	assert.Equal(t, map[string]string{
+		"apache": Compatible,
+		"mit":    Compatible,
+		"lgpl":   Compatible,
+		"gpl2":   Incompatible,
+		"gpl2+":  Compatible,
+		"agpl":   Compatible,
+		"self":   Compatible,
+	}, verdicts)
+	assert.Equal(t, "GPL-2.0-only", report.Dependencies[3].Expression)
+	assert.Contains(t, report.Dependencies[3].Explanation, "version 3 of the GPL")
+}
+
+func TestEvaluatePermissiveProject(t *testing.T) {
+	report, err := Evaluate("MIT", []Dependency{
+		{Name: "agpl", License: "AGPL-3.0-or-later"},
+		{Name: "mpl", License: "MPL-2.0"},
+		{Name: "busl", License: "BUSL-1.1"},
+		{Name: "custom", License: "LicenseRef-Acme"},
+		{Name: "classpath", License: "GPL-2.0-only WITH Classpath-exception-2.0"},
+		{Name: "unlicensed", License: "UNLICENSED"},
+		{Name: "none", License: ""},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Incompatible, report.Verdict)
+
+	results := report.Dependencies
+	assert.Equal(t, Incompatible, results[0].Verdict)
+	assert.Equal(t, "AGPL-3.0-or-later is a strong copyleft license: distributing a work that includes it requires to license the whole work under AGPL-3.0-or-later, not MIT.", results[0].Explanation)
+	assert.Equal(t, Compatible, results[1].Verdict)
+	assert.Equal(t, Review, results[2].Verdict)
+	assert.Equal(t, Review, results[3].Verdict)
+	assert.Equal(t, Compatible, results[4].Verdict)
+	assert.Contains(t, results[4].Explanation, "linking exception")
+	assert.Equal(t, Unknown, results[5].Verdict)
+	assert.Empty(t, results[5].Expression)
+	assert.Equal(t, Unknown, results[6].Verdict)
+}
+
+func TestEvaluateExpressions(t *testing.T) {
+	report, err := Evaluate("GPL-2.0-only", []Dependency{
+		// A choice gets the verdict of the best license
+		{Name: "dual", License: "Apache-2.0 OR MIT"},
+		{Name: "epl", License: "EPL-2.0 OR GPL-2.0-or-later"},
+		// A conjunction the verdict of the worst
+		{Name: "both", License: "MIT AND Apache-2.0"},
+		{Name: "apache", License: "Apache-2.0"},
+		{Name: "llvm", License: "Apache-2.0 WITH LLVM-exception"},
+		{Name: "bsd4", License: "BSD-4-Clause"},
+	})
+	assert.NoError(t, err)
+
+	results := report.Dependencies
+	assert.Equal(t, Compatible, results[0].Verdict)
+	assert.Equal(t, Compatible, results[1].Verdict)
+	assert.Equal(t, Incompatible, results[2].Verdict)
+	assert.Contains(t, results[2].Explanation, "patent termination")
+	assert.Equal(t, Incompatible, results[3].Verdict)
+	assert.Equal(t, Compatible, results[4].Verdict)
+	assert.Equal(t, Incompatible, results[5].Verdict)
+
+	// A dual licensed project must be distributable under each of its licenses
+	report, err = Evaluate("MIT OR GPL-2.0-only", []Dependency{{Name: "apache", License: "Apache-2.0"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Incompatible, report.Dependencies[0].Verdict)
+
+	// The dependency must grant the exception of the project
+	report, err = Evaluate("GPL-2.0-only WITH Classpath-exception-2.0", []Dependency{
+		{Name: "gpl", License: "GPL-2.0-only"},
+		{Name: "classpath", License: "GPL-2.0-only WITH Classpath-exception-2.0"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Review, report.Dependencies[0].Verdict)
+	assert.Equal(t, Compatible, report.Dependencies[1].Verdict)
+
+	// Without an exception, only the + differs
+	report, err = Evaluate("MPL-1.1+", []Dependency{{Name: "mpl", License: "MPL-1.1"}})
+	assert.NoError(t, err)
+	assert.Equal(t, Compatible, report.Dependencies[0].Verdict)
+	assert.NotContains(t, report.Dependencies[0].Explanation, "exception")
+
+	_, err = Evaluate("UNLICENSED", nil)
+	assert.Error(t, err)
+
+	report, err = Evaluate("Apache-2.0", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Compatible, report.Verdict)
+	assert.Empty(t, report.Dependencies)
+}
+
+func TestValidate(t *testing.T) {
+	matrix := &Matrix{Rules: []Rule{
+		{Project: []string{Any}, Dependency: []string{"MIT"}, Verdict: "maybe", Explanation: "x"},
+		{Project: []string{Any}, Verdict: Compatible},
+	}}
+	err := matrix.Validate()
+	assert.ErrorContains(t, err, `unknown verdict "maybe" of rule 0`)
+	assert.ErrorContains(t, err, "rule 1 has no project or dependency license")
+	assert.ErrorContains(t, err, "rule 1 has no explanation")
+}
@@ -0,0 +1,171 @@
+package licensematch
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MinConfidence is the confidence below which a text is not considered to be a license.
+const MinConfidence = 0.8
+
+// Template is the standard template of a license, with the SPDX markup of its optional and
+// variable parts, or its plain text when it has no template.
+type Template struct {
+	ID       string
+	Name     string
+	Template string
+}
+
+// Match is a license identified in a text. Confidence goes from 0 to 1, an exact match scores 1.
+type Match struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Matcher scores texts against the templates of licenses.
+type Matcher struct {
+	licenses []compiled
+}
+
+// compiled are the word pairs of a template: the required ones and the ones the text may contain.
+type compiled struct {
+	id       string
+	name     string
+	required map[string]struct{}
+	allowed  map[string]struct{}
+}
+
+// NewMatcher compiles the templates of licenses. Templates without any text are ignored.
+func NewMatcher(templates []Template) *Matcher {
+	m := &Matcher{}
+	for _, template := range templates {
+		license := compile(template)
+		if len(license.required) > 0 {
+			m.licenses = append(m.licenses, license)
+		}
+	}
+	return m
+}
+
+// Match returns the license that best matches a text, and whether its confidence reaches MinConfidence.
+func (m *Matcher) Match(text string) (Match, bool) {
+	candidates := m.Candidates(text, 1)
+	if len(candidates) == 0 {
+		return Match{}, false
+	}
+	return candidates[0], candidates[0].Confidence >= MinConfidence
+}
+
+// Candidates returns the n licenses that best match a text, the best first.
+//
+// The confidence is the F1 score of the word pairs of the text and of the template: how much of
+// the required text of the template is found in the text, and how much of the text is explained
+// by the template, optional parts included. Word pairs keep some of the order of the words, so that
+// licenses sharing the same vocabulary are told apart.
+func (m *Matcher) Candidates(text string, n int) []Match {
+	pairs := wordPairs(Normalize(text))
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	matches := make([]Match, 0, len(m.licenses))
+	for _, license := range m.licenses {
+		found := 0
+		for pair := range license.required {
+			if _, ok := pairs[pair]; ok {
+				found++
+			}
+		}
+		explained := 0
+		for pair := range pairs {
+			if _, ok := license.allowed[pair]; ok {
+				explained++
+			}
+		}
+		if found == 0 {
+			continue
+		}
+
+		recall := float64(found) / float64(len(license.required))
+		precision := float64(explained) / float64(len(pairs))
+		matches = append(matches, Match{
+			ID:         license.id,
+			Name:       license.name,
+			Confidence: 2 * precision * recall / (precision + recall),
+		})
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		if c := cmp.Compare(b.Confidence, a.Confidence); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return matches[:min(n, len(matches))]
+}
+
+// markup matches the SPDX template markup: <<beginOptional>>, <<endOptional>> and <<var;...>>
+var markup = regexp.MustCompile(`<<(beginOptional|endOptional|var)[^>]*>>`)
+
+// compile splits a template into its required and optional parts. Variable parts, like the
+// copyright notice, are skipped. Word pairs spanning two parts are optional.
+func compile(template Template) compiled {
+	license := compiled{
+		id:       template.ID,
+		name:     template.Name,
+		required: make(map[string]struct{}),
+		allowed:  make(map[string]struct{}),
+	}
+
+	var previous string
+	depth := 0
+	addPart := func(part string) {
+		words := Normalize(part)
+		if len(words) == 0 {
+			return
+		}
+		// The pair joining the previous part to this one
+		if previous != "" {
+			license.allowed[previous+" "+words[0]] = struct{}{}
+		}
+		for pair := range wordPairs(words) {
+			license.allowed[pair] = struct{}{}
+			if depth == 0 {
+				license.required[pair] = struct{}{}
+			}
+		}
+		previous = words[len(words)-1]
+	}
+
+	position := 0
+	for _, loc := range markup.FindAllStringSubmatchIndex(template.Template, -1) {
+		addPart(template.Template[position:loc[0]])
+		position = loc[1]
+		switch template.Template[loc[2]:loc[3]] {
+		case "beginOptional":
+			depth++
+		case "endOptional":
+			depth = max(depth-1, 0)
+		case "var":
+			// The words around a variable are not a pair
+			previous = ""
+		}
+	}
+	addPart(template.Template[position:])
+	return license
+}
+
+// wordPairs returns the consecutive pairs of words, or the word of a single word text.
+func wordPairs(words []string) map[string]struct{} {
+	pairs := make(map[string]struct{}, len(words))
+	if len(words) == 1 {
+		pairs[words[0]] = struct{}{}
+	}
+	for i := 1; i < len(words); i++ {
+		pairs[words[i-1]+" "+words[i]] = struct{}{}
+	}
+	return pairs
+}
@@ -0,0 +1,124 @@
+package licensematch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const mitTemplate = `<<beginOptional>>MIT License<<endOptional>>
+
+<<var;name="copyright";original="Copyright (c) <year> <copyright holders>";match=".{0,5000}">>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.`
+
+const mit0Template = `<<beginOptional>>MIT No Attribution<<endOptional>>
+
+<<var;name="copyright";original="Copyright <year> <copyright holders>";match=".{0,5000}">>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.`
+
+const iscTemplate = `<<beginOptional>>ISC License<<endOptional>>
+
+<<var;name="copyright";original="Copyright (c) 2004-2010 by Internet Systems Consortium, Inc. (\"ISC\")";match=".{0,5000}">>
+
+Permission to use, copy, modify, and<<var;name="distribute";original="/or";match="/or|">> distribute this software for any purpose with or without fee is hereby granted, provided that the above copyright notice and this permission notice appear in all copies.
+
+THE SOFTWARE IS PROVIDED "AS IS" AND ISC DISCLAIMS ALL WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL ISC BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.`
+
+func testMatcher() *Matcher {
+	return NewMatcher([]Template{
+		{ID: "MIT", Name: "MIT License", Template: mitTemplate},
+		{ID: "MIT-0", Name: "MIT No Attribution", Template: mit0Template},
+		{ID: "ISC", Name: "ISC License", Template: iscTemplate},
+		{ID: "Empty", Name: "Empty", Template: "<<var;name=\"copyright\";original=\"\";match=\".*\">>"},
+	})
+}
+
+func TestMatch(t *testing.T) {
+	matcher := testMatcher()
+
+	mit := `The MIT License (MIT)
+
+Copyright © 2021 Jane Doe <jane@example.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the “Software”), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sub-license, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+`
+	match, ok := matcher.Match(mit)
+	assert.True(t, ok)
+	assert.Equal(t, "MIT", match.ID)
+	assert.Equal(t, "MIT License", match.Name)
+	assert.Greater(t, match.Confidence, 0.95)
+
+	mit0 := `Copyright 2020 Example Corp.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy of this software and associated documentation files (the "Software"), to deal in the Software without restriction, including without limitation the rights to use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of the Software, and to permit persons to whom the Software is furnished to do so.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.`
+	match, ok = matcher.Match(mit0)
+	assert.True(t, ok)
+	assert.Equal(t, "MIT-0", match.ID)
+
+	isc := `ISC License
+
+Copyright (c) 2015, Someone
+
+Permission to use, copy, modify, and distribute this software for any
+purpose with or without fee is hereby granted, provided that the above
+copyright notice and this permission notice appear in all copies.
+
+THE SOFTWARE IS PROVIDED "AS IS" AND ISC DISCLAIMS ALL WARRANTIES WITH REGARD TO THIS SOFTWARE INCLUDING ALL IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS. IN NO EVENT SHALL ISC BE LIABLE FOR ANY SPECIAL, DIRECT, INDIRECT, OR CONSEQUENTIAL DAMAGES OR ANY DAMAGES WHATSOEVER RESULTING FROM LOSS OF USE, DATA OR PROFITS, WHETHER IN AN ACTION OF CONTRACT, NEGLIGENCE OR OTHER TORTIOUS ACTION, ARISING OUT OF OR IN CONNECTION WITH THE USE OR PERFORMANCE OF THIS SOFTWARE.`
+	match, ok = matcher.Match(isc)
+	assert.True(t, ok)
+	assert.Equal(t, "ISC", match.ID)
+
+	// A readme that happens to mention the software and its license
+	_, ok = matcher.Match("This software is a library to parse dates. See the license for the warranties.")
+	assert.False(t, ok)
+
+	_, ok = matcher.Match("")
+	assert.False(t, ok)
+}
+
+func TestCandidates(t *testing.T) {
+	matcher := testMatcher()
+
+	candidates := matcher.Candidates(mitTemplate, 2)
+	assert.Len(t, candidates, 2)
+	assert.Equal(t, "MIT", candidates[0].ID)
+	assert.Equal(t, "MIT-0", candidates[1].ID)
+	assert.Greater(t, candidates[0].Confidence, candidates[1].Confidence)
+
+	assert.Empty(t, matcher.Candidates("", 3))
+}
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t,
+		[]string{"the", "licensor", "is", "authorized", "to", "sublicense", "this", "program"},
+		Normalize("Copyright (c) 2020 Someone\n  1. The Licensor is authorised to sub-license this “Programme”."),
+	)
+	assert.Equal(t, []string{"see", "http", "example", "com"}, Normalize("See HTTPS://example.com"))
+	assert.Empty(t, Normalize("© 2020 Someone"))
+}
@@ -0,0 +1,80 @@
+// Package licensematch identifies the SPDX license of a license text, like the content of a LICENSE file.
+// Texts are normalized following the SPDX matching guidelines and scored against the standard
+// templates of the SPDX licenses.
+// https://spdx.github.io/spdx-spec/v2.3/license-matching-guidelines-and-templates/
+package licensematch
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Guideline 8: the copyright notices are ignored
+	copyrightLine = regexp.MustCompile(`(?m)^\s*(copyright|\(c\)|©).*$`)
+	// Guideline 7: the bullets and numbering of lists are ignored
+	listMarker = regexp.MustCompile(`(?m)^\s*(\d{1,3}|[a-z]|[ivx]{1,5})?[.)]\s+|^\s*[*•·-]\s+`)
+	// Guideline 5: the punctuation is ignored, so are the differences of dashes and quotes
+	separators = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+)
+
+// equivalentWords are the varietal spellings of the SPDX matching guidelines (guideline 9), mapped to
+// the spelling used by the templates. https://spdx.org/licenses/equivalentwords.txt
+var equivalentWords = strings.NewReplacer(
+	"acknowledgment", "acknowledgement",
+	"analogue", "analog",
+	"analyse", "analyze",
+	"artefact", "artifact",
+	"authorisation", "authorization",
+	"authorised", "authorized",
+	"calibre", "caliber",
+	"cancelled", "canceled",
+	"capitalisations", "capitalizations",
+	"catalogue", "catalog",
+	"categorise", "categorize",
+	"centre", "center",
+	"emphasised", "emphasized",
+	"favourite", "favorite",
+	"favour", "favor",
+	"fulfilment", "fulfillment",
+	"fulfil ", "fulfill ",
+	"initialise", "initialize",
+	"judgment", "judgement",
+	"labelling", "labeling",
+	"labour", "labor",
+	"licence", "license",
+	"maximise", "maximize",
+	"modelled", "modeled",
+	"modelling", "modeling",
+	"offence", "offense",
+	"optimise", "optimize",
+	"organisation", "organization",
+	"organise", "organize",
+	"practise", "practice",
+	"programme", "program",
+	"realise", "realize",
+	"recognise", "recognize",
+	"signalling", "signaling",
+	"sub-license", "sublicense",
+	"sub license", "sublicense",
+	"utilisation", "utilization",
+	"whilst", "while",
+	"wilful", "willful",
+	"non-commercial", "noncommercial",
+	"per cent", "percent",
+	"copyright owner", "copyright holder",
+	// Guideline 6: "©", "(c)" and "copyright" are equivalent
+	"©", "copyright",
+	"(c)", "copyright",
+	// Guideline 10: the protocol of URLs is ignored
+	"https://", "http://",
+)
+
+// Normalize applies the SPDX matching guidelines to a license text and returns its words.
+func Normalize(text string) []string {
+	text = strings.ToLower(text)
+	text = copyrightLine.ReplaceAllString(text, "")
+	text = listMarker.ReplaceAllString(text, "")
+	text = equivalentWords.Replace(text)
+	return strings.Fields(separators.ReplaceAllString(text, " "))
+}
@@ -0,0 +1,201 @@
+// Package licensepolicy classifies licenses with an embedded, versioned catalog of every SPDX license
+// (permissive, weak copyleft, copyleft or proprietary, with choosealicense.com style permissions,
+// conditions and limitations). Organizations can overlay their own classifications on top of it.
+package licensepolicy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// Classifications of a license
+const (
+	Permissive   = "permissive"
+	WeakCopyleft = "weak-copyleft"
+	Copyleft     = "copyleft"
+	Proprietary  = "proprietary"
+)
+
+var classifications = []string{Permissive, WeakCopyleft, Copyleft, Proprietary}
+
+// Properties of a license, from https://choosealicense.com/appendix/
+var (
+	permissions = []string{"commercial-use", "distribution", "modifications", "patent-use", "private-use"}
+	conditions  = []string{"include-copyright", "include-copyright-source", "document-changes", "disclose-source", "network-use-disclose", "same-license", "same-license-file", "same-license-library"}
+	limitations = []string{"liability", "patent-use", "trademark-use", "warranty"}
+)
+
+//go:embed catalog.json
+var defaultCatalogFile []byte
+
+// Catalog is the classification of licenses. Version is bumped whenever the embedded catalog changes,
+// SPDXListVersion is the version of the SPDX license list it covers.
+type Catalog struct {
+	Version         int     `json:"version"`
+	SPDXListVersion string  `json:"spdxListVersion"`
+	Licenses        []Entry `json:"licenses"`
+}
+
+// Entry classifies a license. Only the classification is required.
+type Entry struct {
+	ID             string   `json:"id"`
+	Classification string   `json:"classification"`
+	Description    string   `json:"description,omitempty"`
+	Permissions    []string `json:"permissions,omitempty"`
+	Conditions     []string `json:"conditions,omitempty"`
+	Limitations    []string `json:"limitations,omitempty"`
+}
+
+// DefaultCatalog returns the embedded catalog. It is parsed once and shared, it must not be modified.
+var DefaultCatalog = sync.OnceValue(func() *Catalog {
+	var catalog Catalog
+	if err := json.Unmarshal(defaultCatalogFile, &catalog); err != nil {
+		panic(fmt.Sprintf("invalid embedded catalog.json: %v", err))
+	}
+	if err := catalog.Validate(); err != nil {
+		panic(fmt.Sprintf("invalid embedded catalog.json: %v", err))
+	}
+	return &catalog
+})
+
+// LoadCatalog returns the embedded catalog overlaid with the licenses of the JSON file at path,
+// e.g. {"licenses": [{"id": "LGPL-2.1-only", "classification": "copyleft"}]}. The fields set by an
+// overriding entry replace the ones of the catalog, licenses missing from it are added.
+// An empty path returns the embedded catalog.
+func LoadCatalog(path string) (*Catalog, error) {
+	if path == "" {
+		return DefaultCatalog(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read license policy file: %w", err)
+	}
+	var overrides Catalog
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("failed to parse license policy file %s: %w", path, err)
+	}
+
+	// Overlay a copy, the embedded catalog is shared
+	catalog := *DefaultCatalog()
+	catalog.Licenses = slices.Clone(catalog.Licenses)
+
+	for _, override := range overrides.Licenses {
+		idx := catalog.index(override.ID)
+		if idx < 0 {
+			catalog.Licenses = append(catalog.Licenses, override)
+			continue
+		}
+		entry := &catalog.Licenses[idx]
+		if override.Classification != "" {
+			entry.Classification = override.Classification
+		}
+		if override.Description != "" {
+			entry.Description = override.Description
+		}
+		if override.Permissions != nil {
+			entry.Permissions = override.Permissions
+		}
+		if override.Conditions != nil {
+			entry.Conditions = override.Conditions
+		}
+		if override.Limitations != nil {
+			entry.Limitations = override.Limitations
+		}
+	}
+
+	if err := catalog.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid license policy file %s: %w", path, err)
+	}
+	return &catalog, nil
+}
+
+// Validate checks that every license has a unique id, a known classification and known properties.
+func (c *Catalog) Validate() error {
+	var errs []error
+	seen := make(map[string]bool, len(c.Licenses))
+	for _, entry := range c.Licenses {
+		if entry.ID == "" {
+			errs = append(errs, errors.New("license without id"))
+			continue
+		}
+		key := strings.ToLower(entry.ID)
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("duplicate license %s", entry.ID))
+		}
+		seen[key] = true
+
+		if !slices.Contains(classifications, entry.Classification) {
+			errs = append(errs, fmt.Errorf("unknown classification %q of license %s", entry.Classification, entry.ID))
+		}
+		errs = append(errs, validateProperties(entry.ID, "permission", entry.Permissions, permissions)...)
+		errs = append(errs, validateProperties(entry.ID, "condition", entry.Conditions, conditions)...)
+		errs = append(errs, validateProperties(entry.ID, "limitation", entry.Limitations, limitations)...)
+	}
+	return errors.Join(errs...)
+}
+
+func validateProperties(id string, kind string, values []string, known []string) []error {
+	var errs []error
+	for _, value := range values {
+		if !slices.Contains(known, value) {
+			errs = append(errs, fmt.Errorf("unknown %s %q of license %s", kind, value, id))
+		}
+	}
+	return errs
+}
+
+// Lookup returns the entry of a license. SPDX ids are matched case-insensitively.
+func (c *Catalog) Lookup(id string) (Entry, bool) {
+	idx := c.index(id)
+	if idx < 0 {
+		return Entry{}, false
+	}
+	return c.Licenses[idx], true
+}
+
+func (c *Catalog) index(id string) int {
+	return slices.IndexFunc(c.Licenses, func(entry Entry) bool {
+		return strings.EqualFold(entry.ID, id)
+	})
+}
+
+// Apply sets the classification, description and properties of the licenses known to the catalog.
+// The description of a license is only replaced when the catalog has one.
+func (c *Catalog) Apply(licenses []knowledge.License) []knowledge.License {
+	entries := make(map[string]Entry, len(c.Licenses))
+	for _, entry := range c.Licenses {
+		entries[strings.ToLower(entry.ID)] = entry
+	}
+
+	for i := range licenses {
+		entry, ok := entries[strings.ToLower(licenses[i].LicenseID)]
+		if !ok {
+			continue
+		}
+		details := &licenses[i].Details
+		details.Classification = entry.Classification
+		if entry.Description != "" {
+			details.Description = entry.Description
+		}
+		details.LicenseProperties.Permissions = nonNil(entry.Permissions)
+		details.LicenseProperties.Conditions = nonNil(entry.Conditions)
+		details.LicenseProperties.Limitations = nonNil(entry.Limitations)
+	}
+	return licenses
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,103 @@
+package licensepolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog := DefaultCatalog()
+	assert.NoError(t, catalog.Validate())
+	assert.Greater(t, len(catalog.Licenses), 600)
+
+	for id, classification := range map[string]string{
+		"MIT":              Permissive,
+		"Apache-2.0":       Permissive,
+		"MPL-2.0":          WeakCopyleft,
+		"LGPL-2.1-only":    WeakCopyleft,
+		"GPL-3.0-or-later": Copyleft,
+		"AGPL-3.0-only":    Copyleft,
+		"BUSL-1.1":         Proprietary,
+		"CC-BY-NC-4.0":     Proprietary,
+	} {
+		entry, ok := catalog.Lookup(id)
+		assert.True(t, ok, id)
+		assert.Equal(t, classification, entry.Classification, id)
+	}
+
+	entry, ok := catalog.Lookup("apache-2.0")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"trademark-use", "liability", "warranty"}, entry.Limitations)
+
+	_, ok = catalog.Lookup("LicenseRef-Unknown")
+	assert.False(t, ok)
+}
+
+func TestLoadCatalogOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"licenses": [
+		{"id": "LGPL-2.1-only", "classification": "copyleft"},
+		{"id": "LicenseRef-Acme", "classification": "proprietary", "description": "Acme internal license"}
+	]}`), 0o644))
+
+	catalog, err := LoadCatalog(path)
+	assert.NoError(t, err)
+
+	entry, _ := catalog.Lookup("LGPL-2.1-only")
+	assert.Equal(t, Copyleft, entry.Classification)
+	// Fields the override doesn't set are kept
+	assert.NotEmpty(t, entry.Description)
+	assert.Contains(t, entry.Conditions, "same-license-library")
+
+	entry, ok := catalog.Lookup("LicenseRef-Acme")
+	assert.True(t, ok)
+	assert.Equal(t, Proprietary, entry.Classification)
+
+	// The shared embedded catalog is left untouched
+	entry, _ = DefaultCatalog().Lookup("LGPL-2.1-only")
+	assert.Equal(t, WeakCopyleft, entry.Classification)
+	_, ok = DefaultCatalog().Lookup("LicenseRef-Acme")
+	assert.False(t, ok)
+}
+
+func TestLoadCatalogInvalidOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"licenses": [
+		{"id": "MIT", "classification": "public-domain"},
+		{"id": "LicenseRef-Acme", "classification": "proprietary", "permissions": ["resale"]}
+	]}`), 0o644))
+
+	_, err := LoadCatalog(path)
+	assert.ErrorContains(t, err, `unknown classification "public-domain" of license MIT`)
+	assert.ErrorContains(t, err, `unknown permission "resale" of license LicenseRef-Acme`)
+}
+
+func TestValidateDuplicate(t *testing.T) {
+	catalog := Catalog{Licenses: []Entry{
+		{ID: "MIT", Classification: Permissive},
+		{ID: "mit", Classification: Permissive},
+	}}
+	assert.ErrorContains(t, catalog.Validate(), "duplicate license mit")
+}
+
+func TestApply(t *testing.T) {
+	licenses := DefaultCatalog().Apply([]knowledge.License{
+		{LicenseID: "MPL-2.0"},
+		{LicenseID: "Glide", Details: knowledge.Details{Description: "SPDX description"}},
+		{LicenseID: "LicenseRef-Unknown"},
+	})
+
+	assert.Equal(t, WeakCopyleft, licenses[0].Details.Classification)
+	assert.Contains(t, licenses[0].Details.LicenseProperties.Conditions, "disclose-source")
+
+	// Licenses without curated metadata keep their description and get empty properties
+	assert.Equal(t, Permissive, licenses[1].Details.Classification)
+	assert.Equal(t, "SPDX description", licenses[1].Details.Description)
+	assert.Equal(t, []string{}, licenses[1].Details.LicenseProperties.Permissions)
+
+	assert.Empty(t, licenses[2].Details.Classification)
+}
@@ -0,0 +1,103 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/uptrace/bun"
+)
+
+// UpdateCAPEC replaces the CAPEC attack patterns and their CWE links with the given catalog.
+// Attack patterns are upserted on their id, the ones no longer in the catalog are removed
+// and the links are rebuilt.
+func UpdateCAPEC(db *bun.DB, entries []types.CAPECEntry, links []types.CWECAPEC) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	capecIds := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		capecIds = append(capecIds, entry.CAPECId)
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for CAPEC update: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NewInsert().
+		Model(&entries).
+		On("CONFLICT (capec_id) DO UPDATE SET name = EXCLUDED.name, abstraction = EXCLUDED.abstraction, status = EXCLUDED.status, description = EXCLUDED.description, extended_description = EXCLUDED.extended_description, likelihood_of_attack = EXCLUDED.likelihood_of_attack, typical_severity = EXCLUDED.typical_severity, prerequisites = EXCLUDED.prerequisites, mitigations = EXCLUDED.mitigations, related_attack_patterns = EXCLUDED.related_attack_patterns, catalog_version = EXCLUDED.catalog_version").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert %d CAPEC entries: %w", len(entries), err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*types.CAPECEntry)(nil)).
+		Where("capec_id NOT IN (?)", bun.In(capecIds)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove CAPEC entries dropped from the catalog: %w", err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*types.CWECAPEC)(nil)).
+		Where("TRUE").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear CWE CAPEC links: %w", err)
+	}
+
+	if len(links) > 0 {
+		_, err = tx.NewInsert().
+			Model(&links).
+			On("CONFLICT DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert %d CWE CAPEC links: %w", len(links), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for CAPEC update: %w", err)
+	}
+	return nil
+}
+
+// GetCAPECByCWE returns the attack patterns exploiting a weakness, the most severe first.
+// The CWE id is given without its CWE- prefix.
+func GetCAPECByCWE(db *bun.DB, cweId string) ([]types.CAPECEntry, error) {
+	var entries []types.CAPECEntry
+	err := db.NewSelect().
+		Model(&entries).
+		Join("JOIN cwe_capec AS cc ON cc.capec_id = capec.capec_id").
+		Where("cc.cwe_id = ?", cweId).
+		OrderExpr("CASE capec.typical_severity WHEN 'Very High' THEN 0 WHEN 'High' THEN 1 WHEN 'Medium' THEN 2 WHEN 'Low' THEN 3 WHEN 'Very Low' THEN 4 ELSE 5 END").
+		Order("capec.capec_id").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the CAPEC entries of CWE-%s: %w", cweId, err)
+	}
+	return entries, nil
+}
+
+// GetCWEsByCAPEC returns the weaknesses an attack pattern exploits.
+// The CAPEC id is given without its CAPEC- prefix.
+func GetCWEsByCAPEC(db *bun.DB, capecId string) ([]knowledge.CWEEntry, error) {
+	var entries []knowledge.CWEEntry
+	err := db.NewSelect().
+		Model(&entries).
+		Join("JOIN cwe_capec AS cc ON cc.cwe_id = c.cwe_id").
+		Where("cc.capec_id = ?", capecId).
+		Order("c.cwe_id").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the CWE entries of CAPEC-%s: %w", capecId, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,106 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// cpeBatchSize bounds the number of rows sent in a single upsert statement.
+const cpeBatchSize = 1000
+
+// BatchUpdateCPEProducts upserts CPE Dictionary entries keyed on their cpeNameId.
+func BatchUpdateCPEProducts(db *bun.DB, items []types.CPEProduct) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	deduplicated := dedupeBy(items, func(item types.CPEProduct) string { return item.CPENameId })
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for CPE update: %w", err)
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(deduplicated); start += cpeBatchSize {
+		end := min(start+cpeBatchSize, len(deduplicated))
+		chunk := deduplicated[start:end]
+		_, err = tx.NewInsert().
+			Model(&chunk).
+			On("CONFLICT (cpe_name_id) DO UPDATE SET cpe_name = EXCLUDED.cpe_name, part = EXCLUDED.part, vendor = EXCLUDED.vendor, product = EXCLUDED.product, version = EXCLUDED.version, target_sw = EXCLUDED.target_sw, title = EXCLUDED.title, titles = EXCLUDED.titles, refs = EXCLUDED.refs, deprecated = EXCLUDED.deprecated, deprecated_by = EXCLUDED.deprecated_by, created = EXCLUDED.created, last_modified = EXCLUDED.last_modified").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to batch upsert %d CPE records: %w", len(chunk), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for CPE update: %w", err)
+	}
+	return nil
+}
+
+// BatchUpdateCPEMatchCriteria upserts CPE match criteria keyed on their matchCriteriaId.
+func BatchUpdateCPEMatchCriteria(db *bun.DB, items []types.CPEMatchCriteria) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	deduplicated := dedupeBy(items, func(item types.CPEMatchCriteria) string { return item.MatchCriteriaId })
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for CPE match update: %w", err)
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(deduplicated); start += cpeBatchSize {
+		end := min(start+cpeBatchSize, len(deduplicated))
+		chunk := deduplicated[start:end]
+		_, err = tx.NewInsert().
+			Model(&chunk).
+			On("CONFLICT (match_criteria_id) DO UPDATE SET criteria = EXCLUDED.criteria, version_start_including = EXCLUDED.version_start_including, version_start_excluding = EXCLUDED.version_start_excluding, version_end_including = EXCLUDED.version_end_including, version_end_excluding = EXCLUDED.version_end_excluding, status = EXCLUDED.status, matches = EXCLUDED.matches, created = EXCLUDED.created, last_modified = EXCLUDED.last_modified, cpe_last_modified = EXCLUDED.cpe_last_modified").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to batch upsert %d CPE match records: %w", len(chunk), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for CPE match update: %w", err)
+	}
+	return nil
+}
+
+// GetCPENamesByMatchCriteriaIds expands NVD matchCriteriaIds into the concrete CPE names they match.
+// Returns a map from matchCriteriaId to CPE names; unknown ids are absent from the map.
+func GetCPENamesByMatchCriteriaIds(db *bun.DB, matchCriteriaIds []string) (map[string][]string, error) {
+	if len(matchCriteriaIds) == 0 {
+		return make(map[string][]string), nil
+	}
+
+	var criteria []types.CPEMatchCriteria
+	err := db.NewSelect().
+		Model(&criteria).
+		Column("match_criteria_id", "matches").
+		Where("match_criteria_id IN (?)", bun.In(matchCriteriaIds)).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve CPE match criteria: %w", err)
+	}
+
+	result := make(map[string][]string, len(criteria))
+	for _, c := range criteria {
+		names := make([]string, 0, len(c.Matches))
+		for _, match := range c.Matches {
+			names = append(names, match.CPEName)
+		}
+		result[c.MatchCriteriaId] = names
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cpemap"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// SeedCPEPackageMappings writes the curated mappings. Seeded rows replace heuristic ones
+// but never override rows marked as manual. Unchanged rows are left untouched, so that their
+// CVEs are not linked to their packages again.
+func SeedCPEPackageMappings(db *bun.DB, mappings []types.CPEPackageMapping) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	_, err := db.NewInsert().
+		Model(&mappings).
+		On("CONFLICT (vendor, product) DO UPDATE").
+		Set("ecosystem = EXCLUDED.ecosystem, package_name = EXCLUDED.package_name, purl = EXCLUDED.purl, source = EXCLUDED.source, evidence = EXCLUDED.evidence, updated_at = ?", time.Now()).
+		Where("cpm.source <> ?", types.MappingSourceManual).
+		Where("(cpm.ecosystem, cpm.package_name, cpm.purl, cpm.source, cpm.evidence) IS DISTINCT FROM (EXCLUDED.ecosystem, EXCLUDED.package_name, EXCLUDED.purl, EXCLUDED.source, EXCLUDED.evidence)").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to seed %d CPE package mappings: %w", len(mappings), err)
+	}
+	return nil
+}
+
+// InsertCPEPackageMappings stores mappings found by the heuristics.
+// Existing rows, whatever their origin, are left untouched.
+func InsertCPEPackageMappings(db *bun.DB, mappings []types.CPEPackageMapping) error {
+	if len(mappings) == 0 {
+		return nil
+	}
+
+	_, err := db.NewInsert().
+		Model(&mappings).
+		On("CONFLICT (vendor, product) DO NOTHING").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to insert %d CPE package mappings: %w", len(mappings), err)
+	}
+	return nil
+}
+
+// GetCPEPackageMappings returns the stored mappings of the given vendor:product keys (see cpemap.Key).
+func GetCPEPackageMappings(db *bun.DB, keys []string) (map[string]types.CPEPackageMapping, error) {
+	if len(keys) == 0 {
+		return make(map[string]types.CPEPackageMapping), nil
+	}
+
+	var mappings []types.CPEPackageMapping
+	err := db.NewSelect().
+		Model(&mappings).
+		Where("lower(vendor || ':' || product) IN (?)", bun.In(keys)).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve CPE package mappings: %w", err)
+	}
+
+	result := make(map[string]types.CPEPackageMapping, len(mappings))
+	for _, m := range mappings {
+		result[cpemap.Key(m.Vendor, m.Product)] = m
+	}
+	return result, nil
+}
+
+// GetCPEPackageMappingsUpdatedSince returns the mappings created or changed after since, every
+// mapping when since is zero.
+func GetCPEPackageMappingsUpdatedSince(db *bun.DB, since time.Time) ([]types.CPEPackageMapping, error) {
+	var mappings []types.CPEPackageMapping
+	query := db.NewSelect().Model(&mappings)
+	if !since.IsZero() {
+		query = query.Where("cpm.updated_at > ?", since)
+	}
+	err := query.Order("vendor", "product").Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the CPE package mappings updated since %s: %w", since, err)
+	}
+	return mappings, nil
+}
+
+// packageRepository is the lowercase owner/name of the GitHub repository in the source URL of a
+// package, e.g. "lodash/lodash" for git+https://github.com/lodash/lodash.git
+const packageRepository = `lower(regexp_replace(substring(lower(source->>'Url') from 'github\.com[/:]([^/]+/[^/]+)/?$'), '\.git$', ''))`
+
+// GetPackageByRepository looks for an npm or Packagist package whose source points at the given GitHub repository.
+func GetPackageByRepository(db *bun.DB, repository cpemap.Repository) (cpemap.Mapping, bool, error) {
+	var pack struct {
+		Name     string `bun:"name"`
+		Language string `bun:"language"`
+	}
+
+	// Same expression and predicate as package_github_repository_idx
+	err := db.NewSelect().
+		TableExpr("package").
+		Column("name", "language").
+		Where("language IN ('javascript', 'php')").
+		Where(packageRepository+" = ?", strings.ToLower(repository.Owner+"/"+repository.Name)).
+		OrderExpr("name").
+		Limit(1).
+		Scan(context.Background(), &pack)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return cpemap.Mapping{}, false, nil
+		}
+		return cpemap.Mapping{}, false, fmt.Errorf("failed to look up package for github.com/%s/%s: %w", repository.Owner, repository.Name, err)
+	}
+
+	ecosystem := cpemap.EcosystemNpm
+	if pack.Language == "php" {
+		ecosystem = cpemap.EcosystemPackagist
+	}
+	return cpemap.Mapping{
+		Ecosystem: ecosystem,
+		Name:      pack.Name,
+		Evidence:  "https://github.com/" + repository.Owner + "/" + repository.Name,
+	}, true, nil
+}
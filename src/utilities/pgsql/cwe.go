@@ -2,67 +2,86 @@ package pgsql
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/uptrace/bun"
 )
 
-// UpdateCWE updates the CWE (Common Weakness Enumeration) entries in the graph database.
-// It takes a graph driver and a slice of CWEEntry structs as input.
-// For each CWEEntry in the slice, it tries to update the corresponding document in the "CWE" vertex collection.
-// If the document exists and is successfully updated, it generates a changelog and creates a new document in the "REVISIONS" vertex collection.
-// If the document doesn't exist, it creates a new document in the "CWE" vertex collection.
-// Returns an error if any operation fails.
-func UpdateCWE(db *bun.DB, cwes []knowledge.CWEEntry) error {
-	ctx := context.Background()
-
-	to_insert := []knowledge.CWEEntry{}
-	to_update := []knowledge.CWEEntry{}
-
-	// Create a map to track existing CWE IDs in the database
-	existingCWEIds := make(map[string]bool)
-
-	// Fetch all existing CWE IDs in one query
-	var dbCWEIds []string
-	err := db.NewSelect().Model((*knowledge.CWEEntry)(nil)).Column("cwe_id").Scan(ctx, &dbCWEIds)
-	if err != nil {
-		return err
+// UpdateCWE upserts the CWE (Common Weakness Enumeration) entries with a single
+// INSERT ... ON CONFLICT (cwe_id) statement and applies their lifecycle: entry type,
+// deprecation and replacements. Entries no longer in the catalog are kept and marked
+// as removed, a removed entry coming back is unmarked.
+func UpdateCWE(db *bun.DB, cwes []knowledge.CWEEntry, statuses []types.CWEStatus) error {
+	if len(cwes) == 0 {
+		return nil
 	}
 
-	// Populate the map with existing CWE IDs
-	for _, cweId := range dbCWEIds {
-		existingCWEIds[cweId] = true
+	deduplicated := dedupeBy(cwes, func(cwe knowledge.CWEEntry) string { return cwe.CWEId })
+
+	cweIds := make([]string, 0, len(deduplicated))
+	for _, cwe := range deduplicated {
+		cweIds = append(cweIds, cwe.CWEId)
 	}
 
-	// Separate records into insert and update lists
-	for _, cwe_element := range cwes {
-		if existingCWEIds[cwe_element.CWEId] {
-			to_update = append(to_update, cwe_element)
-		} else {
-			to_insert = append(to_insert, cwe_element)
-		}
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for CWE update: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Bulk insert
-	if len(to_insert) > 0 {
-		_, err := db.NewInsert().Model(&to_insert).Exec(ctx)
-		if err != nil {
-			panic(err)
-		}
+	_, err = tx.NewInsert().
+		Model(&deduplicated).
+		On("CONFLICT (cwe_id) DO UPDATE SET name = EXCLUDED.name, abstraction = EXCLUDED.abstraction, structure = EXCLUDED.structure, status = EXCLUDED.status, description = EXCLUDED.description, extended_description = EXCLUDED.extended_description, related_weaknesses = EXCLUDED.related_weaknesses, modes_of_introduction = EXCLUDED.modes_of_introduction, common_consequences = EXCLUDED.common_consequences, detection_methods = EXCLUDED.detection_methods, potential_mitigations = EXCLUDED.potential_mitigations, taxonomy_mappings = EXCLUDED.taxonomy_mappings, likelihood_of_exploit = EXCLUDED.likelihood_of_exploit, observed_examples = EXCLUDED.observed_examples, alternate_terms = EXCLUDED.alternate_terms, affected_resources = EXCLUDED.affected_resources, functional_areas = EXCLUDED.functional_areas, categories = EXCLUDED.categories, applicable_platforms = EXCLUDED.applicable_platforms").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert %d CWE entries: %w", len(deduplicated), err)
 	}
 
-	if len(to_update) > 0 {
-		// Bulk update
-		_, err := db.NewUpdate().
-			Model(&to_update).
+	if len(statuses) > 0 {
+		_, err = tx.NewUpdate().
+			With("_data", tx.NewValues(&statuses)).
+			TableExpr("cwe AS c").
+			TableExpr("_data").
+			Set("entry_type = _data.entry_type").
+			Set("deprecated = _data.deprecated").
+			Set("replaced_by = _data.replaced_by").
+			Set("removed_at = NULL").
 			Where("c.cwe_id = _data.cwe_id").
-			Bulk().
 			Exec(ctx)
 		if err != nil {
-			panic(err)
+			return fmt.Errorf("failed to update the status of %d CWE entries: %w", len(statuses), err)
 		}
+	}
 
+	_, err = tx.NewUpdate().
+		TableExpr("cwe").
+		Set("removed_at = now()").
+		Where("cwe_id NOT IN (?)", bun.In(cweIds)).
+		Where("removed_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to mark the CWE entries removed from the catalog: %w", err)
 	}
 
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for CWE update: %w", err)
+	}
 	return nil
 }
+
+// GetCWEStatus retrieves the lifecycle of a CWE entry, given without its CWE- prefix.
+func GetCWEStatus(db *bun.DB, cweId string) (*types.CWEStatus, error) {
+	status := &types.CWEStatus{}
+	err := db.NewSelect().
+		TableExpr("cwe").
+		Column("cwe_id", "entry_type", "deprecated", "replaced_by").
+		Where("cwe_id = ?", cweId).
+		Scan(context.Background(), status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the status of CWE-%s: %w", cweId, err)
+	}
+	return status, nil
+}
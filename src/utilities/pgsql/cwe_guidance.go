@@ -0,0 +1,92 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// ReplaceCWEGuidance replaces the remediation guidance of the weaknesses: guidance is upserted
+// on the CWE id and the guidance of weaknesses no longer having any is removed.
+func ReplaceCWEGuidance(db *bun.DB, guidance []types.CWEGuidance) error {
+	if len(guidance) == 0 {
+		return nil
+	}
+
+	cweIds := make([]string, 0, len(guidance))
+	for _, entry := range guidance {
+		cweIds = append(cweIds, entry.CWEId)
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for CWE guidance update: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NewInsert().
+		Model(&guidance).
+		On("CONFLICT (cwe_id) DO UPDATE SET mitigations = EXCLUDED.mitigations, detection_methods = EXCLUDED.detection_methods, demonstrative_examples = EXCLUDED.demonstrative_examples").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert the guidance of %d CWE entries: %w", len(guidance), err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*types.CWEGuidance)(nil)).
+		Where("cwe_id NOT IN (?)", bun.In(cweIds)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove stale CWE guidance: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for CWE guidance update: %w", err)
+	}
+	return nil
+}
+
+// GetCWEGuidance retrieves the remediation guidance of a weakness, given without its CWE- prefix.
+func GetCWEGuidance(db *bun.DB, cweId string) (*types.CWEGuidance, error) {
+	guidance := &types.CWEGuidance{}
+	err := db.NewSelect().
+		Model(guidance).
+		Where("cwe_id = ?", cweId).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the guidance of CWE-%s: %w", cweId, err)
+	}
+	return guidance, nil
+}
+
+// GetCWEMitigations returns the mitigations of the given weaknesses applicable during a phase,
+// keyed by CWE id. An empty phase returns every mitigation.
+func GetCWEMitigations(db *bun.DB, cweIds []string, phase string) (map[string][]types.CWEMitigation, error) {
+	result := make(map[string][]types.CWEMitigation, len(cweIds))
+	if len(cweIds) == 0 {
+		return result, nil
+	}
+
+	var guidance []types.CWEGuidance
+	err := db.NewSelect().
+		Model(&guidance).
+		Column("cwe_id", "mitigations").
+		Where("cwe_id IN (?)", bun.In(cweIds)).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve CWE mitigations: %w", err)
+	}
+
+	for _, entry := range guidance {
+		for _, mitigation := range entry.Mitigations {
+			if phase == "" || slices.Contains(mitigation.Phases, phase) {
+				result[entry.CWEId] = append(result[entry.CWEId], mitigation)
+			}
+		}
+	}
+	return result, nil
+}
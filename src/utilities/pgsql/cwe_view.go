@@ -0,0 +1,170 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// cweRelationshipBatchSize bounds the number of relationships sent in a single insert statement.
+const cweRelationshipBatchSize = 1000
+
+// ReplaceCWEViews replaces the CWE views and the relationships of their hierarchies with the given catalog.
+func ReplaceCWEViews(db *bun.DB, views []types.CWEView, relationships []types.CWERelationship) error {
+	if len(views) == 0 {
+		return nil
+	}
+
+	viewIds := make([]string, 0, len(views))
+	for _, view := range views {
+		viewIds = append(viewIds, view.ViewId)
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for CWE views update: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NewInsert().
+		Model(&views).
+		On("CONFLICT (view_id) DO UPDATE SET name = EXCLUDED.name, type = EXCLUDED.type, status = EXCLUDED.status, objective = EXCLUDED.objective, members = EXCLUDED.members").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert %d CWE views: %w", len(views), err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*types.CWEView)(nil)).
+		Where("view_id NOT IN (?)", bun.In(viewIds)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove CWE views dropped from the catalog: %w", err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*types.CWERelationship)(nil)).
+		Where("TRUE").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear CWE relationships: %w", err)
+	}
+
+	for start := 0; start < len(relationships); start += cweRelationshipBatchSize {
+		batch := relationships[start:min(start+cweRelationshipBatchSize, len(relationships))]
+		_, err = tx.NewInsert().
+			Model(&batch).
+			On("CONFLICT DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert %d CWE relationships: %w", len(batch), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for CWE views update: %w", err)
+	}
+	return nil
+}
+
+// GetCWEView retrieves a CWE view by its id, given without its CWE- prefix.
+func GetCWEView(db *bun.DB, viewId string) (*types.CWEView, error) {
+	view := &types.CWEView{}
+	err := db.NewSelect().
+		Model(view).
+		Where("view_id = ?", viewId).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve CWE view %s: %w", viewId, err)
+	}
+	return view, nil
+}
+
+// GetLatestCWETop25View retrieves the view listing the weaknesses of the latest CWE Top 25,
+// MITRE publishes a new view every year.
+func GetLatestCWETop25View(db *bun.DB) (*types.CWEView, error) {
+	view := &types.CWEView{}
+	err := db.NewSelect().
+		Model(view).
+		Where("name LIKE ?", "%Top 25%").
+		Where("status <> ?", "Deprecated").
+		OrderExpr("view_id::integer DESC").
+		Limit(1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the CWE Top 25 view: %w", err)
+	}
+	return view, nil
+}
+
+// GetCWEAncestors walks the hierarchy of a view up from a CWE and returns its ancestors,
+// the closest first. The view itself is the last ancestor.
+func GetCWEAncestors(db *bun.DB, viewId string, cweId string) ([]types.CWEHierarchyNode, error) {
+	return walkCWEHierarchy(db, viewId, cweId, "child_id", "parent_id")
+}
+
+// GetCWEDescendants walks the hierarchy of a view down from a CWE, or from the view itself,
+// and returns its descendants, the closest first.
+func GetCWEDescendants(db *bun.DB, viewId string, cweId string) ([]types.CWEHierarchyNode, error) {
+	return walkCWEHierarchy(db, viewId, cweId, "parent_id", "child_id")
+}
+
+// walkCWEHierarchy follows the relationships of a view from the from column to the to column.
+// Entries reachable through several paths are returned once at their shortest depth, and the
+// path guards against cycles.
+func walkCWEHierarchy(db *bun.DB, viewId string, cweId string, from string, to string) ([]types.CWEHierarchyNode, error) {
+	var nodes []types.CWEHierarchyNode
+	err := db.NewRaw(`WITH RECURSIVE hierarchy (cwe_id, depth, path) AS (
+			SELECT r.?1, 1, ARRAY[r.?0, r.?1]
+			FROM cwe_relationship AS r
+			WHERE r.view_id = ?2 AND r.?0 = ?3
+			UNION ALL
+			SELECT r.?1, h.depth + 1, h.path || r.?1
+			FROM cwe_relationship AS r
+			JOIN hierarchy AS h ON r.?0 = h.cwe_id
+			WHERE r.view_id = ?2 AND NOT r.?1 = ANY (h.path)
+		)
+		SELECT h.cwe_id, coalesce(c.name, v.name, '') AS name, min(h.depth) AS depth
+		FROM hierarchy AS h
+		LEFT JOIN cwe AS c ON c.cwe_id = h.cwe_id
+		LEFT JOIN cwe_view AS v ON v.view_id = h.cwe_id
+		GROUP BY h.cwe_id, c.name, v.name
+		ORDER BY depth, h.cwe_id`,
+		bun.Ident(from), bun.Ident(to), viewId, cweId).
+		Scan(context.Background(), &nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk the hierarchy of CWE-%s in view %s: %w", cweId, viewId, err)
+	}
+	return nodes, nil
+}
+
+// GetCWEViewMemberships returns the views each of the given CWEs belongs to, keyed by CWE id,
+// for instance to rank findings by their membership of the CWE Top 25.
+func GetCWEViewMemberships(db *bun.DB, cweIds []string) (map[string][]string, error) {
+	result := make(map[string][]string, len(cweIds))
+	if len(cweIds) == 0 {
+		return result, nil
+	}
+
+	var memberships []struct {
+		ChildId string `bun:"child_id"`
+		ViewId  string `bun:"view_id"`
+	}
+	err := db.NewSelect().
+		Model((*types.CWERelationship)(nil)).
+		ColumnExpr("DISTINCT child_id, view_id").
+		Where("child_id IN (?)", bun.In(cweIds)).
+		Order("child_id", "view_id").
+		Scan(context.Background(), &memberships)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve CWE view memberships: %w", err)
+	}
+
+	for _, membership := range memberships {
+		result[membership.ChildId] = append(result[membership.ChildId], membership.ViewId)
+	}
+	return result, nil
+}
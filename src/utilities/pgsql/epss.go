@@ -2,67 +2,83 @@ package pgsql
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"time"
 
-	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
 )
 
-// UpdateCWE updates the CWE (Common Weakness Enumeration) entries in the graph database.
-// It takes a graph driver and a slice of CWEEntry structs as input.
-// For each CWEEntry in the slice, it tries to update the corresponding document in the "CWE" vertex collection.
-// If the document exists and is successfully updated, it generates a changelog and creates a new document in the "REVISIONS" vertex collection.
-// If the document doesn't exist, it creates a new document in the "CWE" vertex collection.
-// Returns an error if any operation fails.
-func UpdateEPSS(db *bun.DB, epssScores []knowledge.EPSS) error {
+// LoadEPSS bulk loads the EPSS scores of one day. writeRows streams "cve,score,percentile" CSV rows,
+// which are copied into a staging table with COPY and then merged into the score history.
+// When current is set, the epss table holding the latest score of every CVE is refreshed as well.
+// It returns the number of rows copied.
+func LoadEPSS(db *bun.DB, scoreDate time.Time, modelVersion string, current bool, writeRows func(w io.Writer) error) (int64, error) {
 	ctx := context.Background()
 
-	toInsert := []knowledge.EPSS{}
-	toUpdate := []knowledge.EPSS{}
+	// COPY and the temporary staging table are bound to a single connection
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get connection for EPSS load: %w", err)
+	}
+	defer conn.Close()
 
-	// Create a map to track existing CVEs in the database
-	existingCVEs := make(map[string]bool)
+	_, err = conn.ExecContext(ctx, `CREATE TEMPORARY TABLE IF NOT EXISTS epss_staging (cve text NOT NULL, score real NOT NULL, percentile real NOT NULL)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create EPSS staging table: %w", err)
+	}
+	defer conn.ExecContext(ctx, `DROP TABLE IF EXISTS epss_staging`)
 
-	// Fetch all existing CVEs in one query
-	var dbCVEs []string
-	err := db.NewSelect().Model((*knowledge.EPSS)(nil)).Column("cve").Scan(ctx, &dbCVEs)
+	_, err = conn.ExecContext(ctx, `TRUNCATE epss_staging`)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("failed to truncate EPSS staging table: %w", err)
 	}
 
-	// Populate the map with existing CVEs
-	for _, cve := range dbCVEs {
-		existingCVEs[cve] = true
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(writeRows(writer))
+	}()
+
+	res, err := pgdriver.CopyFrom(ctx, conn, reader, `COPY epss_staging (cve, score, percentile) FROM STDIN WITH (FORMAT csv)`)
+	reader.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy EPSS scores: %w", err)
 	}
+	copied, _ := res.RowsAffected()
 
-	// Separate records into insert and update lists
-	for _, epss := range epssScores {
-		if existingCVEs[epss.CVE] {
-			toUpdate = append(toUpdate, epss)
-		} else {
-			toInsert = append(toInsert, epss)
-		}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction for EPSS merge: %w", err)
 	}
+	defer tx.Rollback()
 
-	// Bulk insert
-	if len(toInsert) > 0 {
-		_, err := db.NewInsert().Model(&toInsert).Exec(ctx)
-		if err != nil {
-			return err
-		}
+	// A CVE listed twice keeps a single score, ON CONFLICT can't update a row twice
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO epss_history (cve, score_date, score, percentile, model_version)
+		SELECT DISTINCT ON (cve) cve, ?, score, percentile, ?
+		FROM epss_staging
+		ORDER BY cve
+		ON CONFLICT (cve, score_date) DO UPDATE SET score = EXCLUDED.score, percentile = EXCLUDED.percentile, model_version = EXCLUDED.model_version`,
+		scoreDate.Format(time.DateOnly), modelVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to merge EPSS history: %w", err)
 	}
 
-	if len(toUpdate) > 0 {
-		// Bulk update
-		_, err := db.NewUpdate().
-			Model(&toUpdate).
-			Table("epss").
-			Where("epss.cve = _data.cve").
-			Bulk().
-			Exec(ctx)
+	if current {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO epss (cve, score, percentile)
+			SELECT DISTINCT ON (cve) cve, score, percentile
+			FROM epss_staging
+			ORDER BY cve
+			ON CONFLICT (cve) DO UPDATE SET score = EXCLUDED.score, percentile = EXCLUDED.percentile`)
 		if err != nil {
-			return err
+			return 0, fmt.Errorf("failed to merge EPSS scores: %w", err)
 		}
 	}
 
-	return nil
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit EPSS merge: %w", err)
+	}
+	return copied, nil
 }
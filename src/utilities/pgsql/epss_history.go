@@ -0,0 +1,80 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// HasEPSSHistory tells whether the scores of a day have already been stored.
+func HasEPSSHistory(db *bun.DB, scoreDate time.Time) (bool, error) {
+	exists, err := db.NewSelect().
+		Model((*types.EPSSHistory)(nil)).
+		Where("score_date = ?", scoreDate.Format(time.DateOnly)).
+		Exists(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("failed to check EPSS history of %s: %w", scoreDate.Format(time.DateOnly), err)
+	}
+	return exists, nil
+}
+
+// GetEPSSTrend returns the EPSS scores of a CVE since the given day, oldest first.
+func GetEPSSTrend(db *bun.DB, cve string, since time.Time) ([]types.EPSSHistory, error) {
+	var history []types.EPSSHistory
+	err := db.NewSelect().
+		Model(&history).
+		Where("cve = ?", cve).
+		Where("score_date >= ?", since.Format(time.DateOnly)).
+		Order("score_date").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve EPSS trend of %s: %w", cve, err)
+	}
+	return history, nil
+}
+
+// GetEPSSSpikes returns the CVEs whose score rose by at least minIncrease between the latest score date
+// and the latest score date at least window before it, largest increase first.
+func GetEPSSSpikes(db *bun.DB, window time.Duration, minIncrease float32) ([]types.EPSSSpike, error) {
+	ctx := context.Background()
+
+	var current sql.NullTime
+	err := db.NewSelect().Model((*types.EPSSHistory)(nil)).ColumnExpr("max(score_date)").Scan(ctx, &current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve latest EPSS score date: %w", err)
+	}
+	if !current.Valid {
+		return nil, nil
+	}
+
+	var previous sql.NullTime
+	err = db.NewSelect().
+		Model((*types.EPSSHistory)(nil)).
+		ColumnExpr("max(score_date)").
+		Where("score_date <= ?", current.Time.Add(-window).Format(time.DateOnly)).
+		Scan(ctx, &previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve previous EPSS score date: %w", err)
+	}
+	if !previous.Valid {
+		return nil, nil
+	}
+
+	var spikes []types.EPSSSpike
+	err = db.NewSelect().
+		TableExpr("epss_history AS cur").
+		Join("JOIN epss_history AS prev ON prev.cve = cur.cve AND prev.score_date = ?", previous.Time.Format(time.DateOnly)).
+		ColumnExpr("cur.cve, prev.score AS previous_score, cur.score AS current_score, prev.score_date AS previous_date, cur.score_date AS current_date").
+		Where("cur.score_date = ?", current.Time.Format(time.DateOnly)).
+		Where("cur.score - prev.score >= ?", minIncrease).
+		OrderExpr("cur.score - prev.score DESC").
+		Scan(ctx, &spikes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve EPSS spikes: %w", err)
+	}
+	return spikes, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -145,3 +146,22 @@ func GetFriendsOfPhpUUIDsByAdvisoryIds(db *bun.DB, advisoryIds []string) (map[st
 
 	return result, nil
 }
+
+// SetFriendsOfPhpSourceIds stores the ids of advisories at their original sources (e.g. their GHSA id).
+func SetFriendsOfPhpSourceIds(db *bun.DB, sourceIds []types.FriendsOfPHPSourceIds) error {
+	if len(sourceIds) == 0 {
+		return nil
+	}
+
+	_, err := db.NewUpdate().
+		With("_data", db.NewValues(&sourceIds)).
+		TableExpr("friends_of_php AS f").
+		TableExpr("_data").
+		Set("source_ids = _data.source_ids").
+		Where("f.advisory_id = _data.advisory_id").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to store source ids of %d FriendsOfPHP advisories: %w", len(sourceIds), err)
+	}
+	return nil
+}
@@ -134,16 +134,15 @@ func deduplicateGcvePackageVulnerabilities(items []knowledge.PackageVulnerabilit
 	return result
 }
 
-// BatchInsertGcvePackageVulnerabilities inserts GCVE-based package-vulnerability links.
-func BatchInsertGcvePackageVulnerabilities(db *bun.DB, items []knowledge.PackageVulnerability) error {
-	if len(items) == 0 {
+// ReplaceGcvePackageVulnerabilities replaces the package-vulnerability links of the GCVE records
+// gcveIds with items, so that the links of packages no longer affected, or mapped to another
+// ecosystem since, don't linger.
+func ReplaceGcvePackageVulnerabilities(db *bun.DB, gcveIds []uuid.UUID, items []knowledge.PackageVulnerability) error {
+	if len(gcveIds) == 0 {
 		return nil
 	}
 
 	items = deduplicateGcvePackageVulnerabilities(items)
-	if len(items) == 0 {
-		return nil
-	}
 
 	ctx := context.Background()
 
@@ -153,13 +152,22 @@ func BatchInsertGcvePackageVulnerabilities(db *bun.DB, items []knowledge.Package
 	}
 	defer tx.Rollback()
 
-	_, err = tx.NewInsert().
-		Model(&items).
-		On("CONFLICT (package_name, package_ecosystem, gcve_id) WHERE gcve_id IS NOT NULL DO NOTHING").
+	_, err = tx.NewDelete().
+		Model((*knowledge.PackageVulnerability)(nil)).
+		Where("gcve_id IN (?)", bun.In(gcveIds)).
 		Exec(ctx)
-
 	if err != nil {
-		return fmt.Errorf("failed to batch insert %d GCVE package vulnerability records: %w", len(items), err)
+		return fmt.Errorf("failed to delete the package vulnerabilities of %d GCVE records: %w", len(gcveIds), err)
+	}
+
+	if len(items) > 0 {
+		_, err = tx.NewInsert().
+			Model(&items).
+			On("CONFLICT (package_name, package_ecosystem, gcve_id) WHERE gcve_id IS NOT NULL DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to batch insert %d GCVE package vulnerability records: %w", len(items), err)
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -168,3 +176,18 @@ func BatchInsertGcvePackageVulnerabilities(db *bun.DB, items []knowledge.Package
 
 	return nil
 }
+
+// GetGcveByCVEId retrieves the GCVE records of a CVE: the record of the CVE program (GNA 0)
+// and the advisories of other GNAs aliasing it.
+func GetGcveByCVEId(db *bun.DB, cveId string) ([]knowledge.GCVEItem, error) {
+	var items []knowledge.GCVEItem
+	err := db.NewSelect().
+		Model(&items).
+		Where("cve_id = ?", cveId).
+		Order("gcve_id").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve GCVE records of %s: %w", cveId, err)
+	}
+	return items, nil
+}
@@ -0,0 +1,78 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// ReplaceGcveSSVC replaces the SSVC decisions of the given GCVE records, so that decisions
+// dropped by an ADP disappear too.
+func ReplaceGcveSSVC(db *bun.DB, gcveIds []string, decisions []types.GCVESSVC) error {
+	if len(gcveIds) == 0 {
+		return nil
+	}
+
+	// Records are deduplicated per batch, but an ADP could list its decision twice
+	deduplicated := dedupeBy(decisions, func(decision types.GCVESSVC) string {
+		return decision.GCVEId + "|" + decision.Provider
+	})
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for GCVE SSVC decisions: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NewDelete().
+		Model((*types.GCVESSVC)(nil)).
+		Where("gcve_id IN (?)", bun.In(gcveIds)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete GCVE SSVC decisions: %w", err)
+	}
+
+	if len(deduplicated) > 0 {
+		_, err = tx.NewInsert().Model(&deduplicated).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert %d GCVE SSVC decisions: %w", len(deduplicated), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit GCVE SSVC decisions: %w", err)
+	}
+	return nil
+}
+
+// GetGcveSSVC returns the SSVC decisions published for a CVE.
+func GetGcveSSVC(db *bun.DB, cveId string) ([]types.GCVESSVC, error) {
+	var decisions []types.GCVESSVC
+	err := db.NewSelect().
+		Model(&decisions).
+		Where("cve_id = ?", cveId).
+		Order("provider").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve SSVC decisions of %s: %w", cveId, err)
+	}
+	return decisions, nil
+}
+
+// GetGcveSSVCByExploitation returns the SSVC decisions with the given exploitation status
+// (e.g. "active"), most recently assessed first.
+func GetGcveSSVCByExploitation(db *bun.DB, exploitation string) ([]types.GCVESSVC, error) {
+	var decisions []types.GCVESSVC
+	err := db.NewSelect().
+		Model(&decisions).
+		Where("exploitation = ?", exploitation).
+		Order("assessed_at DESC NULLS LAST").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve SSVC decisions with exploitation %s: %w", exploitation, err)
+	}
+	return decisions, nil
+}
@@ -0,0 +1,94 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// UpdateKEV replaces the KEV entries with the given catalog: entries are upserted on their CVE id
+// and the ones no longer in the catalog are removed.
+func UpdateKEV(db *bun.DB, entries []types.KEVEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	cveIds := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		cveIds = append(cveIds, entry.CVEId)
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for KEV update: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NewInsert().
+		Model(&entries).
+		On("CONFLICT (cve_id) DO UPDATE SET vendor_project = EXCLUDED.vendor_project, product = EXCLUDED.product, vulnerability_name = EXCLUDED.vulnerability_name, date_added = EXCLUDED.date_added, short_description = EXCLUDED.short_description, required_action = EXCLUDED.required_action, due_date = EXCLUDED.due_date, known_ransomware_campaign_use = EXCLUDED.known_ransomware_campaign_use, notes = EXCLUDED.notes, cwes = EXCLUDED.cwes, catalog_version = EXCLUDED.catalog_version").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert %d KEV entries: %w", len(entries), err)
+	}
+
+	_, err = tx.NewDelete().
+		Model((*types.KEVEntry)(nil)).
+		Where("cve_id NOT IN (?)", bun.In(cveIds)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove KEV entries dropped from the catalog: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for KEV update: %w", err)
+	}
+	return nil
+}
+
+// GetKEVByCVEIds returns the KEV entries of the given CVEs, keyed by CVE id.
+// CVEs that aren't known to be exploited are absent from the map.
+func GetKEVByCVEIds(db *bun.DB, cveIds []string) (map[string]types.KEVEntry, error) {
+	if len(cveIds) == 0 {
+		return make(map[string]types.KEVEntry), nil
+	}
+
+	var entries []types.KEVEntry
+	err := db.NewSelect().
+		Model(&entries).
+		Where("cve_id IN (?)", bun.In(cveIds)).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve KEV entries: %w", err)
+	}
+
+	result := make(map[string]types.KEVEntry, len(entries))
+	for _, entry := range entries {
+		result[entry.CVEId] = entry
+	}
+	return result, nil
+}
+
+// GetKEVForPackage returns the KEV entries of the vulnerabilities linked to a package,
+// through their NVD or GCVE record.
+func GetKEVForPackage(db *bun.DB, ecosystem, packageName string) ([]types.KEVEntry, error) {
+	var entries []types.KEVEntry
+	err := db.NewSelect().
+		Model(&entries).
+		Where(`kev.cve_id IN (
+			SELECT n.nvd_id FROM package_vulnerability pv JOIN nvd n ON n.id = pv.nvd_id
+			WHERE pv.package_ecosystem = ? AND pv.package_name = ?
+			UNION
+			SELECT g.cve_id FROM package_vulnerability pv JOIN gcve g ON g.id = pv.gcve_id
+			WHERE pv.package_ecosystem = ? AND pv.package_name = ?
+		)`, ecosystem, packageName, ecosystem, packageName).
+		Order("date_added DESC").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve KEV entries of %s package %s: %w", ecosystem, packageName, err)
+	}
+	return entries, nil
+}
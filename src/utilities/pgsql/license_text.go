@@ -0,0 +1,133 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensematch"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// UpdateLicenseHeaders stores the standard headers of the SPDX licenses in the licenses table.
+func UpdateLicenseHeaders(db *bun.DB, headers []types.LicenseHeader) error {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	_, err := db.NewUpdate().
+		With("_data", db.NewValues(&headers)).
+		TableExpr("licenses AS l").
+		TableExpr("_data").
+		Set("standard_license_header = _data.standard_license_header").
+		Set("standard_license_header_template = _data.standard_license_header_template").
+		Where(`l."licenseId" = _data."licenseId"`).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to update the headers of %d licenses: %w", len(headers), err)
+	}
+	return nil
+}
+
+// UpdateLicenseExceptions upserts the exceptions of the SPDX license list
+// and deletes the ones that are no longer listed.
+func UpdateLicenseExceptions(db *bun.DB, exceptions []types.LicenseException) error {
+	if len(exceptions) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(exceptions))
+	for i, exception := range exceptions {
+		ids[i] = exception.ExceptionID
+	}
+
+	return db.RunInTx(context.Background(), nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewInsert().
+			Model(&exceptions).
+			On("CONFLICT (exception_id) DO UPDATE SET name = EXCLUDED.name, reference = EXCLUDED.reference, deprecated = EXCLUDED.deprecated, see_also = EXCLUDED.see_also, text = EXCLUDED.text, text_html = EXCLUDED.text_html, template = EXCLUDED.template, comments = EXCLUDED.comments, list_version = EXCLUDED.list_version").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to batch upsert %d license exceptions: %w", len(exceptions), err)
+		}
+
+		_, err = tx.NewDelete().
+			Model((*types.LicenseException)(nil)).
+			Where("exception_id NOT IN (?)", bun.In(ids)).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to delete the license exceptions no longer listed: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetLicenseExceptionIds returns the ids of the SPDX license exceptions.
+func GetLicenseExceptionIds(db *bun.DB) ([]string, error) {
+	var ids []string
+	err := db.NewSelect().
+		Model((*types.LicenseException)(nil)).
+		Column("exception_id").
+		Order("exception_id").
+		Scan(context.Background(), &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the license exception ids: %w", err)
+	}
+	return ids, nil
+}
+
+// GetLicenseText returns the text of an SPDX license or exception. Ids are matched case-insensitively.
+// It returns sql.ErrNoRows when neither a license nor an exception has this id.
+func GetLicenseText(db *bun.DB, id string) (*types.LicenseText, error) {
+	ctx := context.Background()
+	text := &types.LicenseText{}
+
+	err := db.NewSelect().
+		TableExpr("licenses").
+		ColumnExpr(`"licenseId" AS id, name, false AS exception`).
+		ColumnExpr(`coalesce(details->>'licenseText', '') AS text`).
+		ColumnExpr(`coalesce(standard_license_header, '') AS header`).
+		// seeAlso is stored as JSON by the shared model
+		ColumnExpr(`CASE WHEN jsonb_typeof(to_jsonb("seeAlso")) = 'array' THEN ARRAY(SELECT jsonb_array_elements_text(to_jsonb("seeAlso"))) END AS see_also`).
+		Where(`lower("licenseId") = lower(?)`, id).
+		Limit(1).
+		Scan(ctx, text)
+	if err == nil {
+		return text, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to retrieve the text of license %s: %w", id, err)
+	}
+
+	err = db.NewSelect().
+		Model((*types.LicenseException)(nil)).
+		ColumnExpr(`exception_id AS id, name, true AS exception, text, '' AS header, see_also`).
+		Where("lower(exception_id) = lower(?)", id).
+		Limit(1).
+		Scan(ctx, text)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to retrieve the text of license exception %s: %w", id, err)
+	}
+	return text, nil
+}
+
+// GetLicenseTemplates returns the standard templates of the licenses that aren't deprecated,
+// or their text when they have no template.
+func GetLicenseTemplates(db *bun.DB) ([]licensematch.Template, error) {
+	var templates []licensematch.Template
+	err := db.NewSelect().
+		TableExpr("licenses").
+		ColumnExpr(`"licenseId" AS id, name`).
+		ColumnExpr(`coalesce(nullif(details->>'standardLicenseTemplate', ''), details->>'licenseText', '') AS template`).
+		Where(`NOT "isDeprecatedLicenseId"`).
+		OrderExpr(`"licenseId"`).
+		Scan(context.Background(), &templates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the license templates: %w", err)
+	}
+	return templates, nil
+}
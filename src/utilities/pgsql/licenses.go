@@ -8,41 +8,35 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// UpdateLicenses upserts the SPDX licenses with a single INSERT ... ON CONFLICT ("licenseId") statement.
 func UpdateLicenses(db *bun.DB, licenses []knowledge.License) error {
-	// Start a transaction
-	tx, err := db.Begin()
-	if err != nil {
-		return err
+	if len(licenses) == 0 {
+		return nil
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
 
-	for _, license := range licenses {
-		exists, err := tx.NewSelect().Model(&license).Where("\"licenseId\" = ?", license.LicenseID).Exists(context.Background())
-		if err != nil {
-			return fmt.Errorf("failed to check existence for license %v: %w", license.LicenseID, err)
-		}
+	deduplicated := dedupeBy(licenses, func(license knowledge.License) string { return license.LicenseID })
 
-		if exists {
-			_, err = tx.NewUpdate().Model(&license).Where("\"licenseId\" = ?", license.LicenseID).Exec(context.Background())
-			if err != nil {
-				return fmt.Errorf("failed to update license %v: %w", license.LicenseID, err)
-			}
-		} else {
-			_, err = tx.NewInsert().Model(&license).Exec(context.Background())
-			if err != nil {
-				return fmt.Errorf("failed to insert license %v: %w", license.LicenseID, err)
-			}
-		}
+	_, err := db.NewInsert().
+		Model(&deduplicated).
+		On("CONFLICT (\"licenseId\") DO UPDATE SET reference = EXCLUDED.reference, \"isDeprecatedLicenseId\" = EXCLUDED.\"isDeprecatedLicenseId\", \"detailsUrl\" = EXCLUDED.\"detailsUrl\", details = EXCLUDED.details, \"referenceNumber\" = EXCLUDED.\"referenceNumber\", name = EXCLUDED.name, \"seeAlso\" = EXCLUDED.\"seeAlso\", \"isOsiApproved\" = EXCLUDED.\"isOsiApproved\"").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert %d licenses: %w", len(deduplicated), err)
 	}
 
 	return nil
 }
+
+// GetLicenseIds returns the ids of the SPDX licenses.
+func GetLicenseIds(db *bun.DB) ([]string, error) {
+	var ids []string
+	err := db.NewSelect().
+		Model((*knowledge.License)(nil)).
+		Column("licenseId").
+		Order("licenseId").
+		Scan(context.Background(), &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the license ids: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,257 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/aliasgraph"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// AdvisorySources are the records of every source describing a set of vulnerabilities,
+// keyed by their record id, or CVE id for KEV.
+type AdvisorySources struct {
+	Aliases      []types.VulnerabilityAlias
+	Osv          map[uuid.UUID]knowledge.OSVItem
+	Nvd          map[uuid.UUID]knowledge.NVDItem
+	Gcve         map[uuid.UUID]knowledge.GCVEItem
+	FriendsOfPhp map[uuid.UUID]knowledge.FriendsOfPHPAdvisory
+	Scores       []types.VulnerabilityScore
+	KEV          map[string]types.KEVEntry
+}
+
+// GetVulnerabilities returns a page of vulnerabilities ordered by id, starting after the given id.
+func GetVulnerabilities(db *bun.DB, after uuid.UUID, limit int) ([]types.Vulnerability, error) {
+	var vulnerabilities []types.Vulnerability
+	err := db.NewSelect().
+		Model(&vulnerabilities).
+		Where("id > ?", after).
+		Order("id").
+		Limit(limit).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve vulnerabilities: %w", err)
+	}
+	return vulnerabilities, nil
+}
+
+// GetVulnerabilitiesByIds returns the vulnerabilities of the given ids that still exist.
+func GetVulnerabilitiesByIds(db *bun.DB, ids []uuid.UUID) ([]types.Vulnerability, error) {
+	var vulnerabilities []types.Vulnerability
+	if len(ids) == 0 {
+		return vulnerabilities, nil
+	}
+	err := db.NewSelect().
+		Model(&vulnerabilities).
+		Where("id IN (?)", bun.In(ids)).
+		Order("id").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve vulnerabilities: %w", err)
+	}
+	return vulnerabilities, nil
+}
+
+// GetLastAdvisoryChange returns the time of the last recorded advisory change, or the zero time
+// if there is none.
+func GetLastAdvisoryChange(db *bun.DB) (time.Time, error) {
+	var last sql.NullTime
+	err := db.NewSelect().
+		Model((*types.AdvisoryChange)(nil)).
+		ColumnExpr("max(changed_at)").
+		Scan(context.Background(), &last)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to retrieve the last advisory change: %w", err)
+	}
+	return last.Time, nil
+}
+
+// GetChangedVulnerabilityIds returns the vulnerabilities whose grouping, advisories or KEV entry
+// changed up to the given time.
+func GetChangedVulnerabilityIds(db *bun.DB, until time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := db.NewRaw(`
+		SELECT va.vulnerability_id
+		FROM advisory_change ac
+		JOIN vulnerability_alias va ON va.record_id = ac.key::uuid AND va.source = ac.source
+		WHERE ac.source IN ('osv', 'nvd', 'gcve', 'friends_of_php') AND ac.changed_at <= ?0
+		UNION
+		SELECT va.vulnerability_id
+		FROM advisory_change ac
+		JOIN vulnerability_alias va ON va.alias = ac.key
+		WHERE ac.source = 'kev' AND ac.changed_at <= ?0
+		UNION
+		SELECT ac.key::uuid
+		FROM advisory_change ac
+		WHERE ac.source = 'vulnerability' AND ac.changed_at <= ?0`, until).
+		Scan(context.Background(), &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the changed vulnerabilities: %w", err)
+	}
+	return ids, nil
+}
+
+// DeleteAdvisoryChanges removes the advisory changes recorded up to the given time, once they
+// have been merged.
+func DeleteAdvisoryChanges(db *bun.DB, until time.Time) error {
+	_, err := db.NewDelete().
+		Model((*types.AdvisoryChange)(nil)).
+		Where("changed_at <= ?", until).
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to remove merged advisory changes: %w", err)
+	}
+	return nil
+}
+
+// GetAdvisorySources loads the records of every source aliased by the given vulnerabilities.
+func GetAdvisorySources(db *bun.DB, vulnerabilityIds []uuid.UUID) (AdvisorySources, error) {
+	ctx := context.Background()
+	sources := AdvisorySources{
+		Osv:          make(map[uuid.UUID]knowledge.OSVItem),
+		Nvd:          make(map[uuid.UUID]knowledge.NVDItem),
+		Gcve:         make(map[uuid.UUID]knowledge.GCVEItem),
+		FriendsOfPhp: make(map[uuid.UUID]knowledge.FriendsOfPHPAdvisory),
+		KEV:          make(map[string]types.KEVEntry),
+	}
+	if len(vulnerabilityIds) == 0 {
+		return sources, nil
+	}
+
+	err := db.NewSelect().
+		Model(&sources.Aliases).
+		Where("vulnerability_id IN (?)", bun.In(vulnerabilityIds)).
+		Scan(ctx)
+	if err != nil {
+		return sources, fmt.Errorf("failed to retrieve vulnerability aliases: %w", err)
+	}
+
+	recordIds := make(map[string][]uuid.UUID)
+	var cveIds []string
+	for _, alias := range sources.Aliases {
+		if alias.RecordId != nil {
+			recordIds[alias.Source] = append(recordIds[alias.Source], *alias.RecordId)
+		}
+		if len(alias.Alias) > 4 && alias.Alias[:4] == "CVE-" {
+			cveIds = append(cveIds, alias.Alias)
+		}
+	}
+
+	// Scores are keyed on the id of the advisory at its source
+	var sourceIds []string
+	if ids := recordIds["osv"]; len(ids) > 0 {
+		var items []knowledge.OSVItem
+		if err := db.NewSelect().Model(&items).Where("id IN (?)", bun.In(ids)).Scan(ctx); err != nil {
+			return sources, fmt.Errorf("failed to retrieve OSV records: %w", err)
+		}
+		for _, item := range items {
+			sources.Osv[item.Id] = item
+			sourceIds = append(sourceIds, item.OSVId)
+		}
+	}
+	if ids := recordIds["nvd"]; len(ids) > 0 {
+		var items []knowledge.NVDItem
+		if err := db.NewSelect().Model(&items).Where("id IN (?)", bun.In(ids)).Scan(ctx); err != nil {
+			return sources, fmt.Errorf("failed to retrieve NVD records: %w", err)
+		}
+		for _, item := range items {
+			sources.Nvd[item.Id] = item
+			sourceIds = append(sourceIds, item.NVDId)
+		}
+	}
+	if ids := recordIds["gcve"]; len(ids) > 0 {
+		var items []knowledge.GCVEItem
+		if err := db.NewSelect().Model(&items).Where("id IN (?)", bun.In(ids)).Scan(ctx); err != nil {
+			return sources, fmt.Errorf("failed to retrieve GCVE records: %w", err)
+		}
+		for _, item := range items {
+			sources.Gcve[item.Id] = item
+			sourceIds = append(sourceIds, item.GCVEId)
+		}
+	}
+	if ids := recordIds["friends_of_php"]; len(ids) > 0 {
+		var items []knowledge.FriendsOfPHPAdvisory
+		if err := db.NewSelect().Model(&items).Where("id IN (?)", bun.In(ids)).Scan(ctx); err != nil {
+			return sources, fmt.Errorf("failed to retrieve FriendsOfPHP records: %w", err)
+		}
+		for _, item := range items {
+			sources.FriendsOfPhp[item.Id] = item
+			sourceIds = append(sourceIds, item.AdvisoryId)
+		}
+	}
+
+	if len(sourceIds) > 0 {
+		err = db.NewSelect().
+			Model(&sources.Scores).
+			Where("vulnerability_id IN (?)", bun.In(sourceIds)).
+			Scan(ctx)
+		if err != nil {
+			return sources, fmt.Errorf("failed to retrieve vulnerability scores: %w", err)
+		}
+	}
+
+	if len(cveIds) > 0 {
+		if sources.KEV, err = GetKEVByCVEIds(db, cveIds); err != nil {
+			return sources, err
+		}
+	}
+
+	return sources, nil
+}
+
+// UpsertMergedAdvisories stores merged advisories, only rewriting the ones whose fingerprint changed.
+// It returns the number of advisories written.
+func UpsertMergedAdvisories(db *bun.DB, advisories []types.MergedAdvisory) (int64, error) {
+	if len(advisories) == 0 {
+		return 0, nil
+	}
+
+	result, err := db.NewInsert().
+		Model(&advisories).
+		On("CONFLICT (vulnerability_id) DO UPDATE SET canonical_id = EXCLUDED.canonical_id, aliases = EXCLUDED.aliases, summary = EXCLUDED.summary, summary_source = EXCLUDED.summary_source, description = EXCLUDED.description, description_source = EXCLUDED.description_source, severity = EXCLUDED.severity, base_score = EXCLUDED.base_score, cvss_version = EXCLUDED.cvss_version, cvss_vector = EXCLUDED.cvss_vector, severity_source = EXCLUDED.severity_source, severity_provider = EXCLUDED.severity_provider, cwes = EXCLUDED.cwes, cwes_source = EXCLUDED.cwes_source, reference_urls = EXCLUDED.reference_urls, references_source = EXCLUDED.references_source, affected = EXCLUDED.affected, affected_source = EXCLUDED.affected_source, epss_source = EXCLUDED.epss_source, kev = EXCLUDED.kev, fingerprint = EXCLUDED.fingerprint, updated_at = EXCLUDED.updated_at").
+		Where("ma.fingerprint IS DISTINCT FROM EXCLUDED.fingerprint").
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to upsert %d merged advisories: %w", len(advisories), err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteOrphanMergedAdvisories removes the merged advisories of vulnerabilities that no longer exist.
+func DeleteOrphanMergedAdvisories(db *bun.DB) (int64, error) {
+	result, err := db.NewDelete().
+		Model((*types.MergedAdvisory)(nil)).
+		Where("NOT EXISTS (SELECT 1 FROM vulnerability v WHERE v.id = ma.vulnerability_id)").
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove orphan merged advisories: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// GetMergedAdvisory returns the merged advisory of a vulnerability known by any of its aliases,
+// with the latest EPSS score of its CVE when the precedence takes it.
+func GetMergedAdvisory(db *bun.DB, alias string) (*types.MergedAdvisory, error) {
+	advisory := new(types.MergedAdvisory)
+	err := db.NewSelect().
+		Model(advisory).
+		ColumnExpr("ma.*").
+		ColumnExpr("e.score AS epss_score, e.percentile AS epss_percentile").
+		Join(`LEFT JOIN LATERAL (
+			SELECT score, percentile FROM epss_latest
+			WHERE cve = ANY(ma.aliases) AND ma.epss_source = 'epss'
+			ORDER BY cve LIMIT 1
+		) e ON true`).
+		Where("ma.aliases @> ARRAY[?]::text[]", aliasgraph.Normalize(alias)).
+		Limit(1).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve merged advisory of %s: %w", alias, err)
+	}
+	return advisory, nil
+}
@@ -0,0 +1,39 @@
+package pgsql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// GetMirrorState returns the synchronisation state of the named mirror.
+// A mirror that never ran gets a zero state with its name set.
+func GetMirrorState(db *bun.DB, name string) (types.MirrorState, error) {
+	state := types.MirrorState{Name: name}
+	err := db.NewSelect().Model(&state).WherePK().Scan(context.Background())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.MirrorState{Name: name}, nil
+		}
+		return state, fmt.Errorf("failed to read mirror state %s: %w", name, err)
+	}
+	return state, nil
+}
+
+// SetMirrorState stores the synchronisation state of a mirror.
+func SetMirrorState(db *bun.DB, state types.MirrorState) error {
+	state.UpdatedAt = time.Now()
+	_, err := db.NewInsert().
+		Model(&state).
+		On("CONFLICT (name) DO UPDATE SET last_sync = EXCLUDED.last_sync, cursor = EXCLUDED.cursor, updated_at = EXCLUDED.updated_at").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to store mirror state %s: %w", state.Name, err)
+	}
+	return nil
+}
@@ -9,61 +9,34 @@ import (
 	"github.com/uptrace/bun"
 )
 
-func UpdateNvd(db *bun.DB, nvd []knowledge.NVDItem) error {
-	// Start a transaction
-	tx, err := db.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
-		}
-	}()
-
-	ctx := context.Background()
-
-	// Batch insert and update
-	var newItems []knowledge.NVDItem
-	var existingItems []knowledge.NVDItem
+// IsNvdImported reports whether NVD records of a status are imported. Rejected and deferred
+// records are not relevant: they are neither written nor restored after a withdrawal.
+func IsNvdImported(status string) bool {
+	return status != "Rejected" && status != "Deferred"
+}
 
+// UpdateNvd upserts NVD records with a single INSERT ... ON CONFLICT (nvd_id) statement.
+// Only the records of an imported status are written, rejected ones already imported are withdrawn by WithdrawNvd.
+func UpdateNvd(db *bun.DB, nvd []knowledge.NVDItem) error {
+	items := make([]knowledge.NVDItem, 0, len(nvd))
 	for _, vuln := range nvd {
-		// Skip items that are rejected or deferred
-		// This is to avoid inserting or updating items that are not relevant
-		// in the NVD database.
-		if vuln.VulnStatus == "Rejected" || vuln.VulnStatus == "Deferred" {
+		if !IsNvdImported(vuln.VulnStatus) {
 			continue
 		}
-
-		exists, err := tx.NewSelect().Model(&vuln).Where("nvd_id = ?", vuln.NVDId).Exists(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to check existence for NVD item %v: %w", vuln.NVDId, err)
-		}
-
-		if !exists {
-			newItems = append(newItems, vuln)
-		} else {
-			existingItems = append(existingItems, vuln)
-		}
+		items = append(items, vuln)
 	}
-
-	if len(newItems) > 0 {
-		_, err = tx.NewInsert().Model(&newItems).Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to batch insert NVD items: %w", err)
-		}
+	if len(items) == 0 {
+		return nil
 	}
 
-	if len(existingItems) > 0 {
-		_, err = tx.NewUpdate().Model(&existingItems).WherePK().Exec(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to batch update NVD items: %w", err)
-		}
+	items = dedupeBy(items, func(item knowledge.NVDItem) string { return item.NVDId })
+
+	_, err := db.NewInsert().
+		Model(&items).
+		On("CONFLICT (nvd_id) DO UPDATE SET \"sourceIdentifier\" = EXCLUDED.\"sourceIdentifier\", published = EXCLUDED.published, \"lastModified\" = EXCLUDED.\"lastModified\", \"vulnStatus\" = EXCLUDED.\"vulnStatus\", descriptions = EXCLUDED.descriptions, vlai_score = EXCLUDED.vlai_score, vlai_confidence = EXCLUDED.vlai_confidence, metrics = EXCLUDED.metrics, weaknesses = EXCLUDED.weaknesses, configurations = EXCLUDED.configurations, \"affectedFlattened\" = EXCLUDED.\"affectedFlattened\", affected = EXCLUDED.affected, \"references\" = EXCLUDED.\"references\"").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to batch upsert %d NVD records: %w", len(items), err)
 	}
 
 	return nil
@@ -100,3 +73,45 @@ func GetNvdUUIDsByNvdIds(db *bun.DB, nvdIds []string) (map[string]uuid.UUID, err
 
 	return result, nil
 }
+
+// GetNvdIdsByCPEProducts returns the ids of the NVD records that are not withdrawn and affect one of
+// the given CPE vendor:product keys (see cpemap.Key).
+func GetNvdIdsByCPEProducts(db *bun.DB, keys []string) ([]uuid.UUID, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// Same key as cpemap.Key: lowercase and without the CPE quoting
+	var ids []uuid.UUID
+	err := db.NewSelect().
+		TableExpr("nvd AS n").
+		Column("n.id").
+		Where("n.withdrawn_at IS NULL").
+		Where(`EXISTS (
+			SELECT 1 FROM jsonb_array_elements(n."affectedFlattened") AS s
+			WHERE lower(replace(s->'criteriaDict'->>'vendor', '\', '') || ':' || replace(s->'criteriaDict'->>'product', '\', '')) IN (?)
+		)`, bun.In(keys)).
+		OrderExpr("n.id").
+		Scan(context.Background(), &ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the NVD records of %d CPE products: %w", len(keys), err)
+	}
+	return ids, nil
+}
+
+// GetNvdByIds returns the NVD records with the given ids.
+func GetNvdByIds(db *bun.DB, ids []uuid.UUID) ([]knowledge.NVDItem, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var items []knowledge.NVDItem
+	err := db.NewSelect().
+		Model(&items).
+		Where("n.id IN (?)", bun.In(ids)).
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve %d NVD records: %w", len(ids), err)
+	}
+	return items, nil
+}
@@ -14,6 +14,7 @@ import (
 
 	amqp_helper "github.com/CodeClarityCE/utility-amqp-helper"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
@@ -250,6 +251,17 @@ func (opm *OptimizedPackageManager) processBatchChunk(ctx context.Context, tx bu
 		opm.stats.TotalVersionsProcessed += int64(len(versionsToUpdate))
 	}
 
+	packageIds := make([]uuid.UUID, 0, len(packagesToInsert)+len(packagesToUpdate))
+	for _, pack := range packagesToInsert {
+		packageIds = append(packageIds, pack.Id)
+	}
+	for _, pack := range packagesToUpdate {
+		packageIds = append(packageIds, pack.Id)
+	}
+	if err := setLicenseColumns(ctx, tx, packageIds); err != nil {
+		return err
+	}
+
 	opm.stats.ConnectionReuseCount++
 
 	return nil
@@ -354,8 +366,14 @@ func UpdatePackage(db *bun.DB, pack knowledge.Package) error {
 		}
 	}
 
+	if err := setLicenseColumns(context.Background(), db, []uuid.UUID{existingPackage.Id}); err != nil {
+		return err
+	}
+
 	// Send notification about new package versions if found
 	if len(newVersions) > 0 {
+		detectRelicensing(pack, existingPackage.Versions, newVersions)
+
 		go func() {
 			err := sendPackageUpdateNotification(db, pack.Name, existingPackage.Versions, newVersions)
 			if err != nil {
@@ -371,18 +389,10 @@ func UpdatePackage(db *bun.DB, pack knowledge.Package) error {
 // and sends notifications to users about available updates
 func sendPackageUpdateNotification(knowledgeDB *bun.DB, packageName string, existingVersions []knowledge.Version, newVersions []knowledge.Version) error {
 	// Connect to codeclarity database to check for SBOM results
-	host := os.Getenv("PG_DB_HOST")
-	port := os.Getenv("PG_DB_PORT")
-	user := os.Getenv("PG_DB_USER")
-	password := os.Getenv("PG_DB_PASSWORD")
-
-	if host == "" || port == "" || user == "" || password == "" {
-		return fmt.Errorf("database connection parameters not set")
+	codeClarityDB, err := openCodeClarityDB()
+	if err != nil {
+		return err
 	}
-
-	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/codeclarity?sslmode=disable", user, password, host, port)
-	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn), pgdriver.WithTimeout(30*time.Second)))
-	codeClarityDB := bun.NewDB(sqldb, pgdialect.New())
 	defer codeClarityDB.Close()
 
 	// Find the latest version from new versions (assuming semantic versioning)
@@ -504,6 +514,22 @@ func sendPackageUpdateNotification(knowledgeDB *bun.DB, packageName string, exis
 	return nil
 }
 
+// openCodeClarityDB connects to the codeclarity database holding the analysis results.
+func openCodeClarityDB() (*bun.DB, error) {
+	host := os.Getenv("PG_DB_HOST")
+	port := os.Getenv("PG_DB_PORT")
+	user := os.Getenv("PG_DB_USER")
+	password := os.Getenv("PG_DB_PASSWORD")
+
+	if host == "" || port == "" || user == "" || password == "" {
+		return nil, fmt.Errorf("database connection parameters not set")
+	}
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/codeclarity?sslmode=disable", user, password, host, port)
+	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn), pgdriver.WithTimeout(30*time.Second)))
+	return bun.NewDB(sqldb, pgdialect.New()), nil
+}
+
 // extractCurrentVersionFromSBOM extracts the current version of a package from SBOM result
 // Returns version string and dependency type (prod/dev)
 func extractCurrentVersionFromSBOM(sbomResult map[string]interface{}, packageName string) (string, string) {
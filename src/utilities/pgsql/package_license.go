@@ -0,0 +1,78 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// setLicenseColumns copies the normalized license that tools.SetLicense stored in the extra field
+// of packages and of their versions into their license_expression and license_ids columns.
+func setLicenseColumns(ctx context.Context, db bun.IDB, packageIds []uuid.UUID) error {
+	if len(packageIds) == 0 {
+		return nil
+	}
+	_, err := db.NewUpdate().
+		TableExpr("package").
+		Set("license_expression = extra->>'license_expression'").
+		Set("license_ids = extra->'license_ids'").
+		Where("id IN (?)", bun.In(packageIds)).
+		Where("(license_expression, license_ids) IS DISTINCT FROM (extra->>'license_expression', extra->'license_ids')").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to store the licenses of %d packages: %w", len(packageIds), err)
+	}
+	_, err = db.NewUpdate().
+		TableExpr("version").
+		Set("license_expression = extra->>'license_expression'").
+		Set("license_ids = extra->'license_ids'").
+		Where("package_id IN (?)", bun.In(packageIds)).
+		Where("(license_expression, license_ids) IS DISTINCT FROM (extra->>'license_expression', extra->'license_ids')").
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to store the licenses of the versions of %d packages: %w", len(packageIds), err)
+	}
+	return nil
+}
+
+// GetUnresolvedLicenses returns the license strings of the packages of a language that could not be
+// normalized into an SPDX expression, with the number of packages using them, most used first.
+func GetUnresolvedLicenses(db *bun.DB, language string, limit int) ([]types.UnresolvedLicense, error) {
+	var licenses []types.UnresolvedLicense
+	err := db.NewSelect().
+		TableExpr("package").
+		ColumnExpr("license").
+		ColumnExpr("count(*) AS packages").
+		Where("language = ?", language).
+		Where("license <> ''").
+		Where("license_expression = ''").
+		Group("license").
+		OrderExpr("packages DESC, license").
+		Limit(limit).
+		Scan(context.Background(), &licenses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count the unresolved licenses of %s packages: %w", language, err)
+	}
+	return licenses, nil
+}
+
+// CountUnresolvedLicenses returns the number of packages of a language with a normalized license
+// and the number of them whose license could not be normalized into an SPDX expression.
+// Packages stored before their license was normalized are left out.
+func CountUnresolvedLicenses(db *bun.DB, language string) (total int, unresolved int, err error) {
+	err = db.NewSelect().
+		TableExpr("package").
+		ColumnExpr("count(*)").
+		ColumnExpr("count(*) FILTER (WHERE license_expression = '')").
+		Where("language = ?", language).
+		Where("license <> ''").
+		Where("license_expression IS NOT NULL").
+		Scan(context.Background(), &total, &unresolved)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count the licenses of %s packages: %w", language, err)
+	}
+	return total, unresolved, nil
+}
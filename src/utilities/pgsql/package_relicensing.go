@@ -0,0 +1,151 @@
+package pgsql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/relicensing"
+	amqp_helper "github.com/CodeClarityCE/utility-amqp-helper"
+	semver "github.com/CodeClarityCE/utility-node-semver"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// sbomPlugins are the SBOM plugins of the languages, whose results list the dependencies of the projects
+var sbomPlugins = map[string]string{
+	"javascript": "js-sbom",
+	"php":        "php-sbom",
+}
+
+// detectRelicensing notifies the organizations using a package when one of its new versions changed its license.
+func detectRelicensing(pack knowledge.Package, existingVersions []knowledge.Version, newVersions []knowledge.Version) {
+	ecosystem := relicensing.Ecosystem(pack.Language)
+	versions := append(append([]knowledge.Version{}, existingVersions...), newVersions...)
+	changes := relicensing.Introduced(relicensing.Detect(versions, ecosystem), newVersions)
+	if len(changes) == 0 {
+		return
+	}
+
+	// The latest change is the license users get when upgrading
+	change := changes[len(changes)-1]
+	log.Printf("Package %s changed its license from %q to %q in %s", pack.Name, change.PreviousLicense, change.License, change.Version)
+
+	go func() {
+		err := sendLicenseChangeNotification(pack, change, ecosystem)
+		if err != nil {
+			log.Printf("Failed to send license change notification for %s: %v", pack.Name, err)
+		}
+	}()
+}
+
+// sendLicenseChangeNotification notifies the organizations whose projects depend directly on an older
+// version of a package that upgrading it changes their license.
+func sendLicenseChangeNotification(pack knowledge.Package, change relicensing.Change, ecosystem semver.EcosystemType) error {
+	plugin, ok := sbomPlugins[pack.Language]
+	if !ok {
+		return nil
+	}
+	changedVersion, err := semver.ParseSemverWithEcosystem(change.Version, ecosystem)
+	if err != nil {
+		return err
+	}
+
+	codeClarityDB, err := openCodeClarityDB()
+	if err != nil {
+		return err
+	}
+	defer codeClarityDB.Close()
+
+	// SBOM results that contain this package as a direct dependency
+	var results []struct {
+		AnalysisId     string         `bun:"analysis_id"`
+		ProjectId      string         `bun:"project_id"`
+		OrganizationId string         `bun:"organization_id"`
+		ProjectName    string         `bun:"project_name"`
+		Result         map[string]any `bun:"result"`
+	}
+	searchCondition := fmt.Sprintf(`{"%s": {"dependencies": {"%s": {}}}}`, ".", pack.Name)
+	err = codeClarityDB.NewRaw(`
+		SELECT r."analysisId" AS analysis_id, a."projectId" AS project_id, a."organizationId" AS organization_id,
+			p.name AS project_name, r.result
+		FROM result r
+		JOIN analysis a ON r."analysisId" = a.id
+		JOIN project p ON a."projectId" = p.id
+		WHERE r.plugin = ?
+		AND r.result::jsonb->'workspaces' @> ?::jsonb`,
+		plugin, searchCondition).Scan(context.Background(), &results)
+	if err != nil {
+		return fmt.Errorf("failed to query SBOM results for package %s: %w", pack.Name, err)
+	}
+
+	// One notification per organization, for the projects that would change license by upgrading
+	type affected struct {
+		analysisId     string
+		projectId      string
+		projects       []string
+		currentVersion string
+		dependencyType string
+	}
+	organizations := make(map[string]*affected)
+	var order []string
+	for _, result := range results {
+		currentVersion, dependencyType := extractCurrentVersionFromSBOM(result.Result, pack.Name)
+		if currentVersion == "" {
+			continue
+		}
+		current, err := semver.ParseSemverWithEcosystem(currentVersion, ecosystem)
+		if err != nil || !current.LT(changedVersion, false) {
+			continue
+		}
+
+		organization, ok := organizations[result.OrganizationId]
+		if !ok {
+			organization = &affected{
+				analysisId:     result.AnalysisId,
+				projectId:      result.ProjectId,
+				currentVersion: currentVersion,
+				dependencyType: dependencyType,
+			}
+			organizations[result.OrganizationId] = organization
+			order = append(order, result.OrganizationId)
+		}
+		if !slices.Contains(organization.projects, result.ProjectName) {
+			organization.projects = append(organization.projects, result.ProjectName)
+		}
+	}
+
+	for _, organizationId := range order {
+		organization := organizations[organizationId]
+		projectContext := organization.projects[0]
+		if len(organization.projects) > 1 {
+			projectContext = fmt.Sprintf("%s and %d other projects", projectContext, len(organization.projects)-1)
+		}
+
+		notification := map[string]interface{}{
+			"type":             "license_change",
+			"analysis_id":      organization.analysisId,
+			"organization_id":  organizationId,
+			"project_id":       organization.projectId,
+			"project_name":     projectContext,
+			"project_count":    len(organization.projects),
+			"package_name":     pack.Name,
+			"current_version":  organization.currentVersion,
+			"dependency_type":  organization.dependencyType,
+			"previous_version": change.PreviousVersion,
+			"previous_license": change.PreviousLicense,
+			"new_version":      change.Version,
+			"new_license":      change.License,
+		}
+
+		data, err := json.Marshal(notification)
+		if err != nil {
+			log.Printf("Failed to marshal notification: %v", err)
+			continue
+		}
+		amqp_helper.Send("service_notifier", data)
+	}
+
+	return nil
+}
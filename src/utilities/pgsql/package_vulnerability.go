@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/cpemap"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -88,16 +89,14 @@ func BatchInsertOsvPackageVulnerabilities(db *bun.DB, items []knowledge.PackageV
 	return nil
 }
 
-// BatchInsertNvdPackageVulnerabilities inserts NVD-based package-vulnerability links.
-func BatchInsertNvdPackageVulnerabilities(db *bun.DB, items []knowledge.PackageVulnerability) error {
-	if len(items) == 0 {
+// ReplaceNvdPackageVulnerabilities replaces the package-vulnerability links of NVD records
+// by the given ones, in a single transaction.
+func ReplaceNvdPackageVulnerabilities(db *bun.DB, nvdIds []uuid.UUID, items []knowledge.PackageVulnerability) error {
+	if len(nvdIds) == 0 {
 		return nil
 	}
 
 	items = deduplicateNvdPackageVulnerabilities(items)
-	if len(items) == 0 {
-		return nil
-	}
 
 	ctx := context.Background()
 
@@ -107,13 +106,22 @@ func BatchInsertNvdPackageVulnerabilities(db *bun.DB, items []knowledge.PackageV
 	}
 	defer tx.Rollback()
 
-	_, err = tx.NewInsert().
-		Model(&items).
-		On("CONFLICT (package_name, package_ecosystem, nvd_id) WHERE nvd_id IS NOT NULL DO NOTHING").
+	_, err = tx.NewDelete().
+		Model((*knowledge.PackageVulnerability)(nil)).
+		Where("nvd_id IN (?)", bun.In(nvdIds)).
 		Exec(ctx)
-
 	if err != nil {
-		return fmt.Errorf("failed to batch insert %d NVD package vulnerability records: %w", len(items), err)
+		return fmt.Errorf("failed to delete the package vulnerabilities of %d NVD records: %w", len(nvdIds), err)
+	}
+
+	if len(items) > 0 {
+		_, err = tx.NewInsert().
+			Model(&items).
+			On("CONFLICT (package_name, package_ecosystem, nvd_id) WHERE nvd_id IS NOT NULL DO NOTHING").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to batch insert %d NVD package vulnerability records: %w", len(items), err)
+		}
 	}
 
 	if err = tx.Commit(); err != nil {
@@ -123,6 +131,21 @@ func BatchInsertNvdPackageVulnerabilities(db *bun.DB, items []knowledge.PackageV
 	return nil
 }
 
+// DeleteUnmappedNvdPackageVulnerabilities removes the NVD links to bare CPE products, which were
+// written under the "nvd" ecosystem before CPE products were mapped to packages.
+func DeleteUnmappedNvdPackageVulnerabilities(db *bun.DB) (int64, error) {
+	result, err := db.NewDelete().
+		Model((*knowledge.PackageVulnerability)(nil)).
+		Where("nvd_id IS NOT NULL").
+		Where("package_ecosystem = ?", cpemap.EcosystemNVD).
+		Exec(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete the NVD package vulnerabilities of unmapped CPE products: %w", err)
+	}
+	deleted, _ := result.RowsAffected()
+	return deleted, nil
+}
+
 // deduplicateFriendsOfPhpPackageVulnerabilities removes duplicate entries for FriendsOfPHP-based vulnerabilities.
 func deduplicateFriendsOfPhpPackageVulnerabilities(items []knowledge.PackageVulnerability) []knowledge.PackageVulnerability {
 	seen := make(map[string]int)
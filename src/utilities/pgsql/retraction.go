@@ -0,0 +1,160 @@
+package pgsql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	amqp_helper "github.com/CodeClarityCE/utility-amqp-helper"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Retraction describes a vulnerability that was withdrawn by its source,
+// along with the package links that were removed because of it.
+type Retraction struct {
+	Source          string             `json:"source"`
+	VulnerabilityId string             `json:"vulnerability_id"`
+	WithdrawnAt     time.Time          `json:"withdrawn_at"`
+	Packages        []RetractedPackage `json:"packages"`
+}
+
+type RetractedPackage struct {
+	Name      string `json:"name"`
+	Ecosystem string `json:"ecosystem"`
+}
+
+// WithdrawNvd marks previously imported NVD records as withdrawn after the NVD rejected them,
+// and removes their package links so they stop producing findings.
+// Records that were never imported or are already withdrawn are ignored.
+func WithdrawNvd(db *bun.DB, nvdIds []string) ([]Retraction, error) {
+	return withdraw(db, "nvd", "nvd_id", `"vulnStatus" = 'Rejected'`, nvdIds)
+}
+
+// WithdrawGcve marks previously imported GCVE records as withdrawn after their CVE was rejected,
+// and removes their package links so they stop producing findings.
+func WithdrawGcve(db *bun.DB, gcveIds []string) ([]Retraction, error) {
+	return withdraw(db, "gcve", "gcve_id", "state = 'REJECTED'", gcveIds)
+}
+
+// RestoreNvd clears the withdrawal of NVD records that are published again.
+func RestoreNvd(db *bun.DB, nvdIds []string) error {
+	return restore(db, "nvd", "nvd_id", nvdIds)
+}
+
+// RestoreGcve clears the withdrawal of GCVE records that are published again.
+func RestoreGcve(db *bun.DB, gcveIds []string) error {
+	return restore(db, "gcve", "gcve_id", gcveIds)
+}
+
+// withdraw flags the rows of table whose idColumn is in ids, and deletes the package_vulnerability
+// rows pointing at them. The package_vulnerability foreign key column has the same name as idColumn.
+func withdraw(db *bun.DB, table, idColumn, statusSet string, ids []string) ([]Retraction, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction for %s withdrawal: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var withdrawn []struct {
+		Id       uuid.UUID `bun:"id"`
+		SourceId string    `bun:"source_id"`
+	}
+	err = tx.NewUpdate().
+		Table(table).
+		Set("withdrawn_at = ?", now).
+		Set(statusSet).
+		Where("? IN (?)", bun.Ident(idColumn), bun.In(ids)).
+		Where("withdrawn_at IS NULL").
+		Returning("id, ? AS source_id", bun.Ident(idColumn)).
+		Scan(ctx, &withdrawn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to withdraw %s records: %w", table, err)
+	}
+	if len(withdrawn) == 0 {
+		return nil, tx.Commit()
+	}
+
+	retractions := make(map[uuid.UUID]*Retraction, len(withdrawn))
+	uuids := make([]uuid.UUID, 0, len(withdrawn))
+	for _, w := range withdrawn {
+		retractions[w.Id] = &Retraction{Source: table, VulnerabilityId: w.SourceId, WithdrawnAt: now}
+		uuids = append(uuids, w.Id)
+	}
+
+	var removed []struct {
+		VulnId    uuid.UUID `bun:"vuln_id"`
+		Name      string    `bun:"package_name"`
+		Ecosystem string    `bun:"package_ecosystem"`
+	}
+	err = tx.NewDelete().
+		Table("package_vulnerability").
+		Where("? IN (?)", bun.Ident(idColumn), bun.In(uuids)).
+		Returning("? AS vuln_id, package_name, package_ecosystem", bun.Ident(idColumn)).
+		Scan(ctx, &removed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove package links of withdrawn %s records: %w", table, err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit %s withdrawal: %w", table, err)
+	}
+
+	for _, r := range removed {
+		retraction := retractions[r.VulnId]
+		retraction.Packages = append(retraction.Packages, RetractedPackage{Name: r.Name, Ecosystem: r.Ecosystem})
+	}
+
+	result := make([]Retraction, 0, len(withdrawn))
+	for _, w := range withdrawn {
+		result = append(result, *retractions[w.Id])
+	}
+	return result, nil
+}
+
+func restore(db *bun.DB, table, idColumn string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := db.NewUpdate().
+		Table(table).
+		Set("withdrawn_at = NULL").
+		Where("? IN (?)", bun.Ident(idColumn), bun.In(ids)).
+		Where("withdrawn_at IS NOT NULL").
+		Exec(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to restore withdrawn %s records: %w", table, err)
+	}
+	return nil
+}
+
+// SendRetractionNotifications tells downstream services that vulnerabilities were retracted,
+// so findings based on them can be closed.
+func SendRetractionNotifications(retractions []Retraction) {
+	for _, retraction := range retractions {
+		notification := map[string]interface{}{
+			"type":             "vulnerability_retracted",
+			"source":           retraction.Source,
+			"vulnerability_id": retraction.VulnerabilityId,
+			"withdrawn_at":     retraction.WithdrawnAt,
+			"packages":         retraction.Packages,
+		}
+
+		data, err := json.Marshal(notification)
+		if err != nil {
+			log.Printf("Failed to marshal retraction notification: %v", err)
+			continue
+		}
+
+		amqp_helper.Send("service_notifier", data)
+	}
+}
@@ -0,0 +1,275 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// knowledgeModels lists the tables owned by this service.
+// Tables shared with the other services (nvd, osv, package, ...) are created by their migrations.
+var knowledgeModels = []any{
+	(*types.MirrorState)(nil),
+	(*types.CPEProduct)(nil),
+	(*types.CPEMatchCriteria)(nil),
+	(*types.CPEPackageMapping)(nil),
+	(*types.VulnerabilityScore)(nil),
+	(*types.EPSSHistory)(nil),
+	(*types.KEVEntry)(nil),
+	(*types.GCVESSVC)(nil),
+	(*types.Vulnerability)(nil),
+	(*types.VulnerabilityAlias)(nil),
+	(*types.MergedAdvisory)(nil),
+	(*types.AdvisoryChange)(nil),
+	(*types.CAPECEntry)(nil),
+	(*types.CWECAPEC)(nil),
+	(*types.CWEView)(nil),
+	(*types.CWERelationship)(nil),
+	(*types.CWEGuidance)(nil),
+	(*types.LicenseException)(nil),
+}
+
+// knowledgeStatements are applied after the tables have been created.
+// They also add the columns this service needs on the shared tables.
+var knowledgeStatements = []string{
+	`CREATE INDEX IF NOT EXISTS cpe_vendor_product_idx ON cpe (vendor, product)`,
+	`CREATE INDEX IF NOT EXISTS cpe_match_criteria_idx ON cpe_match (criteria)`,
+	// Lookups of GetCPEPackageMappings and GetPackageByRepository
+	`CREATE INDEX IF NOT EXISTS cpe_package_mapping_key_idx ON cpe_package_mapping (lower(vendor || ':' || product))`,
+	// Mappings edited by hand are linked to their CVEs again, see nvd.relinkUpdatedMappings
+	`CREATE OR REPLACE FUNCTION touch_cpe_package_mapping() RETURNS trigger AS $$
+	BEGIN
+		IF (NEW.ecosystem, NEW.package_name) IS DISTINCT FROM (OLD.ecosystem, OLD.package_name) THEN
+			NEW.updated_at := now();
+		END IF;
+		RETURN NEW;
+	END
+	$$ LANGUAGE plpgsql`,
+	`DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = 'cpe_package_mapping_touch') THEN
+			CREATE TRIGGER cpe_package_mapping_touch BEFORE UPDATE ON cpe_package_mapping
+			FOR EACH ROW EXECUTE FUNCTION touch_cpe_package_mapping();
+		END IF;
+	END $$`,
+	`CREATE INDEX IF NOT EXISTS package_github_repository_idx ON package ((` + packageRepository + `)) WHERE language IN ('javascript', 'php')`,
+	`ALTER TABLE IF EXISTS nvd ADD COLUMN IF NOT EXISTS withdrawn_at timestamptz`,
+	`ALTER TABLE IF EXISTS gcve ADD COLUMN IF NOT EXISTS withdrawn_at timestamptz`,
+	// GCVE Numbering Authority of the record, 0 for the CVE program
+	`ALTER TABLE IF EXISTS gcve ADD COLUMN IF NOT EXISTS gna_id integer GENERATED ALWAYS AS (
+		CASE WHEN gcve_id ~ '^GCVE-[0-9]+-' THEN split_part(gcve_id, '-', 2)::integer END
+	) STORED`,
+	`CREATE INDEX IF NOT EXISTS vulnerability_score_vulnerability_idx ON vulnerability_score (source, vulnerability_id)`,
+	`CREATE INDEX IF NOT EXISTS epss_history_score_date_idx ON epss_history (score_date)`,
+	// Latest known EPSS score of every CVE, derived from the history
+	`CREATE OR REPLACE VIEW epss_latest AS
+		SELECT DISTINCT ON (cve) cve, score, percentile, model_version, score_date
+		FROM epss_history
+		ORDER BY cve, score_date DESC`,
+	`CREATE INDEX IF NOT EXISTS gcve_ssvc_exploitation_idx ON gcve_ssvc (exploitation)`,
+	`CREATE INDEX IF NOT EXISTS gcve_ssvc_cve_id_idx ON gcve_ssvc (cve_id)`,
+	// Lifecycle of the CWE entries, see types.CWEStatus
+	`ALTER TABLE IF EXISTS cwe ADD COLUMN IF NOT EXISTS entry_type text NOT NULL DEFAULT 'Weakness'`,
+	`ALTER TABLE IF EXISTS cwe ADD COLUMN IF NOT EXISTS deprecated boolean NOT NULL DEFAULT false`,
+	`ALTER TABLE IF EXISTS cwe ADD COLUMN IF NOT EXISTS replaced_by text[]`,
+	`ALTER TABLE IF EXISTS cwe ADD COLUMN IF NOT EXISTS removed_at timestamptz`,
+	`ALTER TABLE IF EXISTS friends_of_php ADD COLUMN IF NOT EXISTS source_ids text[]`,
+	`CREATE INDEX IF NOT EXISTS vulnerability_alias_alias_idx ON vulnerability_alias (alias)`,
+	`CREATE INDEX IF NOT EXISTS vulnerability_alias_vulnerability_id_idx ON vulnerability_alias (vulnerability_id)`,
+	`CREATE INDEX IF NOT EXISTS merged_advisory_aliases_idx ON merged_advisory USING gin (aliases)`,
+	// Changes of the advisories merged since the last refresh, see types.AdvisoryChange
+	`CREATE INDEX IF NOT EXISTS vulnerability_alias_record_id_idx ON vulnerability_alias (record_id)`,
+	`CREATE OR REPLACE FUNCTION record_advisory_change() RETURNS trigger AS $$
+	DECLARE
+		old_row jsonb := CASE WHEN TG_OP <> 'INSERT' THEN to_jsonb(OLD) END;
+		new_row jsonb := CASE WHEN TG_OP <> 'DELETE' THEN to_jsonb(NEW) END;
+	BEGIN
+		IF old_row IS NOT DISTINCT FROM new_row THEN
+			RETURN NULL;
+		END IF;
+		INSERT INTO advisory_change (source, key, changed_at)
+		VALUES (TG_TABLE_NAME, coalesce(new_row, old_row)->>TG_ARGV[0], now())
+		ON CONFLICT (source, key) DO UPDATE SET changed_at = EXCLUDED.changed_at;
+		RETURN NULL;
+	END
+	$$ LANGUAGE plpgsql`,
+	advisoryChangeTrigger("osv", "id"),
+	advisoryChangeTrigger("nvd", "id"),
+	advisoryChangeTrigger("gcve", "id"),
+	advisoryChangeTrigger("friends_of_php", "id"),
+	// EPSS rescores most CVEs every day, the merged advisories join epss_latest when read instead
+	`DO $$ BEGIN
+		IF to_regclass('epss') IS NOT NULL THEN
+			DROP TRIGGER IF EXISTS epss_advisory_change ON epss;
+		END IF;
+	END $$`,
+	`DELETE FROM advisory_change WHERE source = 'epss'`,
+	`ALTER TABLE IF EXISTS merged_advisory DROP COLUMN IF EXISTS epss_score, DROP COLUMN IF EXISTS epss_percentile`,
+	advisoryChangeTrigger("kev", "cve_id"),
+	advisoryChangeTrigger("vulnerability", "id"),
+	`CREATE INDEX IF NOT EXISTS cwe_capec_capec_id_idx ON cwe_capec (capec_id)`,
+	`CREATE INDEX IF NOT EXISTS cwe_relationship_child_idx ON cwe_relationship (view_id, child_id)`,
+	// Standard header of the SPDX licenses, see types.LicenseHeader
+	`ALTER TABLE IF EXISTS licenses ADD COLUMN IF NOT EXISTS standard_license_header text`,
+	`ALTER TABLE IF EXISTS licenses ADD COLUMN IF NOT EXISTS standard_license_header_template text`,
+	// Normalized SPDX license of the packages and versions, filled by setLicenseColumns. An empty
+	// expression is a license that couldn't be normalized, NULL one that wasn't normalized yet.
+	`ALTER TABLE IF EXISTS package ADD COLUMN IF NOT EXISTS license_expression text`,
+	`ALTER TABLE IF EXISTS package ADD COLUMN IF NOT EXISTS license_ids jsonb`,
+	`ALTER TABLE IF EXISTS version ADD COLUMN IF NOT EXISTS license_expression text`,
+	`ALTER TABLE IF EXISTS version ADD COLUMN IF NOT EXISTS license_ids jsonb`,
+	`CREATE INDEX IF NOT EXISTS package_license_ids_idx ON package USING gin (license_ids)`,
+	// KEV entries joined to the NVD and GCVE records of the same CVE. Advisories of other GNAs
+	// may alias the CVE too, the GCVE-0 record of the CVE program is preferred.
+	`CREATE OR REPLACE VIEW kev_vulnerability AS
+		SELECT kev.cve_id, kev.date_added, kev.due_date, kev.known_ransomware_campaign_use, n.id AS nvd_id, g.id AS gcve_id
+		FROM kev
+		LEFT JOIN nvd n ON n.nvd_id = kev.cve_id
+		LEFT JOIN (
+			SELECT DISTINCT ON (cve_id) id, cve_id
+			FROM gcve
+			WHERE cve_id IS NOT NULL
+			ORDER BY cve_id, gcve_id = 'GCVE-0-' || substring(cve_id from 5) DESC, gcve_id
+		) g ON g.cve_id = kev.cve_id`,
+}
+
+// advisoryChangeTrigger returns the statement creating the trigger that records the changes of the
+// rows of table in advisory_change, keyed by keyColumn, unless the table doesn't exist yet.
+func advisoryChangeTrigger(table, keyColumn string) string {
+	return `DO $$ BEGIN
+		IF to_regclass('` + table + `') IS NOT NULL AND NOT EXISTS (
+			SELECT 1 FROM pg_trigger WHERE tgname = '` + table + `_advisory_change'
+		) THEN
+			CREATE TRIGGER ` + table + `_advisory_change AFTER INSERT OR UPDATE OR DELETE ON ` + table + `
+			FOR EACH ROW EXECUTE FUNCTION record_advisory_change('` + keyColumn + `');
+		END IF;
+	END $$`
+}
+
+// uniqueIndex is a unique index of a shared table that may already hold duplicates.
+type uniqueIndex struct {
+	name   string
+	table  string
+	column string
+	// before runs before the duplicates are deleted, with the ids of the duplicates in the
+	// temporary table duplicate(id, keep_id)
+	before []string
+}
+
+// uniqueIndexes are the conflict targets of the set-based upserts. The last written row of
+// every key is kept.
+var uniqueIndexes = []uniqueIndex{
+	{
+		name:   "nvd_nvd_id_key",
+		table:  "nvd",
+		column: "nvd_id",
+		// Links to a duplicate move to the record that is kept
+		before: []string{
+			`INSERT INTO package_vulnerability (package_name, package_ecosystem, nvd_id)
+			SELECT DISTINCT pv.package_name, pv.package_ecosystem, d.keep_id
+			FROM package_vulnerability pv
+			JOIN duplicate d ON pv.nvd_id = d.id
+			WHERE NOT EXISTS (
+				SELECT 1 FROM package_vulnerability k
+				WHERE k.nvd_id = d.keep_id AND k.package_name = pv.package_name AND k.package_ecosystem = pv.package_ecosystem
+			)`,
+			`DELETE FROM package_vulnerability pv USING duplicate d WHERE pv.nvd_id = d.id`,
+		},
+	},
+	{name: "licenses_license_id_key", table: "licenses", column: `"licenseId"`},
+	{name: "cwe_cwe_id_key", table: "cwe", column: "cwe_id"},
+	{name: "epss_cve_key", table: "epss", column: "cve"},
+}
+
+// dedupeBy keeps the last occurrence of every key, at the position of the first one. A row can't
+// be updated twice by one INSERT ... ON CONFLICT DO UPDATE statement.
+func dedupeBy[T any, K comparable](items []T, key func(T) K) []T {
+	seen := make(map[K]int, len(items))
+	result := make([]T, 0, len(items))
+	for _, item := range items {
+		if idx, exists := seen[key(item)]; exists {
+			result[idx] = item
+		} else {
+			seen[key(item)] = len(result)
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// CreateTables makes sure the tables owned by this service exist.
+// It is idempotent and safe to call before every update. A failing statement is logged and
+// doesn't prevent the next ones from running, for instance when a shared table doesn't exist yet.
+// Returns an error if a table or a unique index the upserts rely on can't be created.
+func CreateTables(db *bun.DB) error {
+	ctx := context.Background()
+
+	for _, model := range knowledgeModels {
+		_, err := db.NewCreateTable().Model(model).IfNotExists().Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to create table for %T: %w", model, err)
+		}
+	}
+
+	for _, query := range knowledgeStatements {
+		_, err := db.ExecContext(ctx, query)
+		if err != nil {
+			log.Printf("failed to apply schema statement %q: %v", query, err)
+		}
+	}
+
+	for _, index := range uniqueIndexes {
+		err := createUniqueIndex(ctx, db, index)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createUniqueIndex deletes the duplicates of a table and creates its unique index,
+// unless the table doesn't exist yet or the index already does.
+func createUniqueIndex(ctx context.Context, db *bun.DB, index uniqueIndex) error {
+	var tableExists, indexExists bool
+	err := db.QueryRowContext(ctx, "SELECT to_regclass(?) IS NOT NULL, to_regclass(?) IS NOT NULL", index.table, index.name).
+		Scan(&tableExists, &indexExists)
+	if err != nil {
+		return fmt.Errorf("failed to look up index %s: %w", index.name, err)
+	}
+	if !tableExists || indexExists {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for index %s: %w", index.name, err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TEMPORARY TABLE duplicate ON COMMIT DROP AS
+			SELECT id, keep_id FROM (
+				SELECT id, first_value(id) OVER (PARTITION BY %[2]s ORDER BY ctid DESC) AS keep_id
+				FROM %[1]s WHERE %[2]s IS NOT NULL
+			) ranked
+			WHERE id <> keep_id`, index.table, index.column),
+	}
+	statements = append(statements, index.before...)
+	statements = append(statements,
+		fmt.Sprintf(`DELETE FROM %s t USING duplicate d WHERE t.id = d.id`, index.table),
+		fmt.Sprintf(`CREATE UNIQUE INDEX %s ON %s (%s)`, index.name, index.table, index.column),
+	)
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			return fmt.Errorf("failed to create unique index %s: %w", index.name, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unique index %s: %w", index.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/aliasgraph"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// vulnerabilityBatchSize bounds the number of rows sent in a single insert statement.
+const vulnerabilityBatchSize = 1000
+
+// GetAliasRecords returns the advisories of every source with the identifiers they're known by:
+// OSV aliases, the CVE of GCVE records and the CVE and source ids of Packagist advisories.
+// Withdrawn advisories are left out.
+func GetAliasRecords(db *bun.DB) ([]aliasgraph.Record, error) {
+	ctx := context.Background()
+	var records []aliasgraph.Record
+
+	var osvItems []knowledge.OSVItem
+	err := db.NewSelect().
+		Model(&osvItems).
+		Column("id", "osv_id", "aliases").
+		Where("coalesce(withdrawn, '') = ''").
+		Scan(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve OSV aliases: %w", err)
+	}
+	for _, item := range osvItems {
+		records = append(records, aliasgraph.Record{Source: "osv", RecordId: item.Id, Id: item.OSVId, Aliases: item.Aliases})
+	}
+
+	var nvdItems []struct {
+		Id    uuid.UUID `bun:"id"`
+		NvdId string    `bun:"nvd_id"`
+	}
+	err = db.NewSelect().
+		TableExpr("nvd").
+		Column("id", "nvd_id").
+		Where("withdrawn_at IS NULL").
+		Scan(ctx, &nvdItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve NVD ids: %w", err)
+	}
+	for _, item := range nvdItems {
+		records = append(records, aliasgraph.Record{Source: "nvd", RecordId: item.Id, Id: item.NvdId})
+	}
+
+	var gcveItems []struct {
+		Id     uuid.UUID `bun:"id"`
+		GcveId string    `bun:"gcve_id"`
+		CveId  string    `bun:"cve_id"`
+	}
+	err = db.NewSelect().
+		TableExpr("gcve").
+		Column("id", "gcve_id", "cve_id").
+		Where("withdrawn_at IS NULL").
+		Scan(ctx, &gcveItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve GCVE aliases: %w", err)
+	}
+	for _, item := range gcveItems {
+		record := aliasgraph.Record{Source: "gcve", RecordId: item.Id, Id: item.GcveId}
+		if item.CveId != "" {
+			record.Aliases = []string{item.CveId}
+		}
+		records = append(records, record)
+	}
+
+	var advisories []struct {
+		Id         uuid.UUID `bun:"id"`
+		AdvisoryId string    `bun:"advisory_id"`
+		CVE        string    `bun:"cve"`
+		SourceIds  []string  `bun:"source_ids,array"`
+	}
+	err = db.NewSelect().
+		TableExpr("friends_of_php").
+		Column("id", "advisory_id", "cve", "source_ids").
+		Scan(ctx, &advisories)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve FriendsOfPHP aliases: %w", err)
+	}
+	for _, advisory := range advisories {
+		aliases := advisory.SourceIds
+		if advisory.CVE != "" {
+			aliases = append(aliases, advisory.CVE)
+		}
+		records = append(records, aliasgraph.Record{Source: "friends_of_php", RecordId: advisory.Id, Id: advisory.AdvisoryId, Aliases: aliases})
+	}
+
+	return records, nil
+}
+
+// ReplaceVulnerabilities stores the vulnerabilities grouped from the alias graph. A vulnerability
+// keeps its id as long as its canonical identifier doesn't change, the ones that disappeared
+// (merged into another group or withdrawn) are removed along with their aliases. Only the
+// vulnerabilities and aliases that changed since the previous build are written.
+func ReplaceVulnerabilities(db *bun.DB, groups []aliasgraph.Group) error {
+	ctx := context.Background()
+	now := time.Now()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for vulnerabilities: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing []types.Vulnerability
+	if err := tx.NewSelect().Model(&existing).Column("id", "canonical_id", "aliases").Scan(ctx); err != nil {
+		return fmt.Errorf("failed to retrieve vulnerabilities: %w", err)
+	}
+	ids := make(map[string]uuid.UUID, len(existing))
+	stale := make(map[string]types.Vulnerability, len(existing))
+	for _, vulnerability := range existing {
+		stale[vulnerability.CanonicalId] = vulnerability
+	}
+
+	var changed []types.Vulnerability
+	for _, group := range groups {
+		previous, ok := stale[group.Canonical]
+		delete(stale, group.Canonical)
+		if ok && slices.Equal(previous.Aliases, group.Ids) {
+			ids[group.Canonical] = previous.Id
+			continue
+		}
+		changed = append(changed, types.Vulnerability{CanonicalId: group.Canonical, Aliases: group.Ids, UpdatedAt: now})
+	}
+
+	for start := 0; start < len(changed); start += vulnerabilityBatchSize {
+		chunk := changed[start:min(start+vulnerabilityBatchSize, len(changed))]
+		_, err = tx.NewInsert().
+			Model(&chunk).
+			On("CONFLICT (canonical_id) DO UPDATE SET aliases = EXCLUDED.aliases, updated_at = EXCLUDED.updated_at").
+			Returning("id").
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to upsert %d vulnerabilities: %w", len(chunk), err)
+		}
+		for _, vulnerability := range chunk {
+			ids[vulnerability.CanonicalId] = vulnerability.Id
+		}
+	}
+
+	staleIds := make([]uuid.UUID, 0, len(stale))
+	for _, vulnerability := range stale {
+		staleIds = append(staleIds, vulnerability.Id)
+	}
+	for start := 0; start < len(staleIds); start += vulnerabilityBatchSize {
+		chunk := staleIds[start:min(start+vulnerabilityBatchSize, len(staleIds))]
+		_, err = tx.NewDelete().
+			Model((*types.VulnerabilityAlias)(nil)).
+			Where("vulnerability_id IN (?)", bun.In(chunk)).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to remove aliases of stale vulnerabilities: %w", err)
+		}
+		_, err = tx.NewDelete().
+			Model((*types.Vulnerability)(nil)).
+			Where("id IN (?)", bun.In(chunk)).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to remove stale vulnerabilities: %w", err)
+		}
+	}
+
+	// Diff the aliases: the ones of the groups that aren't stored yet are inserted,
+	// the stored ones no group has anymore are removed
+	missing := make(map[string]types.VulnerabilityAlias)
+	for _, group := range groups {
+		for _, alias := range groupAliases(ids[group.Canonical], group) {
+			missing[aliasKey(alias)] = alias
+		}
+	}
+
+	var storedAliases []types.VulnerabilityAlias
+	if err := tx.NewSelect().Model(&storedAliases).Scan(ctx); err != nil {
+		return fmt.Errorf("failed to retrieve vulnerability aliases: %w", err)
+	}
+	var removed []uuid.UUID
+	for _, alias := range storedAliases {
+		key := aliasKey(alias)
+		if _, ok := missing[key]; ok {
+			delete(missing, key)
+		} else {
+			removed = append(removed, alias.Id)
+		}
+	}
+
+	for start := 0; start < len(removed); start += vulnerabilityBatchSize {
+		chunk := removed[start:min(start+vulnerabilityBatchSize, len(removed))]
+		_, err = tx.NewDelete().
+			Model((*types.VulnerabilityAlias)(nil)).
+			Where("id IN (?)", bun.In(chunk)).
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to remove %d vulnerability aliases: %w", len(chunk), err)
+		}
+	}
+
+	added := make([]types.VulnerabilityAlias, 0, len(missing))
+	for _, alias := range missing {
+		added = append(added, alias)
+	}
+	for start := 0; start < len(added); start += vulnerabilityBatchSize {
+		chunk := added[start:min(start+vulnerabilityBatchSize, len(added))]
+		if _, err = tx.NewInsert().Model(&chunk).Exec(ctx); err != nil {
+			return fmt.Errorf("failed to insert %d vulnerability aliases: %w", len(chunk), err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit vulnerabilities: %w", err)
+	}
+	log.Printf("Vulnerabilities: %d changed, %d removed, %d aliases added, %d removed", len(changed), len(staleIds), len(added), len(removed))
+	return nil
+}
+
+// aliasKey identifies an alias by what it links together.
+func aliasKey(alias types.VulnerabilityAlias) string {
+	recordId := ""
+	if alias.RecordId != nil {
+		recordId = alias.RecordId.String()
+	}
+	return alias.VulnerabilityId.String() + "|" + alias.Alias + "|" + alias.Source + "|" + recordId
+}
+
+// groupAliases lists the aliases of a vulnerability: one per advisory, linked to its record,
+// and one for every identifier that no stored advisory carries.
+func groupAliases(vulnerabilityId uuid.UUID, group aliasgraph.Group) []types.VulnerabilityAlias {
+	var aliases []types.VulnerabilityAlias
+	stored := make(map[string]bool)
+	for _, record := range group.Records {
+		recordId := record.RecordId
+		alias := aliasgraph.Normalize(record.Id)
+		stored[alias] = true
+		aliases = append(aliases, types.VulnerabilityAlias{
+			VulnerabilityId: vulnerabilityId,
+			Alias:           alias,
+			Source:          record.Source,
+			RecordId:        &recordId,
+		})
+	}
+	for _, id := range group.Ids {
+		if !stored[id] {
+			aliases = append(aliases, types.VulnerabilityAlias{
+				VulnerabilityId: vulnerabilityId,
+				Alias:           id,
+			})
+		}
+	}
+	return aliases
+}
+
+// GetVulnerabilityByAlias returns the vulnerability known by an identifier of any source,
+// along with all its aliases.
+func GetVulnerabilityByAlias(db *bun.DB, alias string) (*types.Vulnerability, []types.VulnerabilityAlias, error) {
+	ctx := context.Background()
+
+	vulnerability := new(types.Vulnerability)
+	err := db.NewSelect().
+		Model(vulnerability).
+		Where("v.id = (SELECT vulnerability_id FROM vulnerability_alias WHERE alias = ? LIMIT 1)", aliasgraph.Normalize(alias)).
+		Scan(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve vulnerability of %s: %w", alias, err)
+	}
+
+	var aliases []types.VulnerabilityAlias
+	err = db.NewSelect().
+		Model(&aliases).
+		Where("vulnerability_id = ?", vulnerability.Id).
+		Order("source", "alias").
+		Scan(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve aliases of %s: %w", vulnerability.CanonicalId, err)
+	}
+
+	return vulnerability, aliases, nil
+}
@@ -0,0 +1,75 @@
+package pgsql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	"github.com/uptrace/bun"
+)
+
+// ReplaceVulnerabilityScores replaces the scores of the given vulnerabilities of a source, so that
+// scores dropped by the source disappear too.
+func ReplaceVulnerabilityScores(db *bun.DB, source string, vulnerabilityIds []string, scores []types.VulnerabilityScore) error {
+	if len(vulnerabilityIds) == 0 {
+		return nil
+	}
+
+	// The same vector can be listed twice by a provider
+	seen := make(map[string]bool, len(scores))
+	deduplicated := make([]types.VulnerabilityScore, 0, len(scores))
+	now := time.Now()
+	for _, score := range scores {
+		key := score.VulnerabilityId + "|" + score.Provider + "|" + score.Version + "|" + score.Vector
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		score.UpdatedAt = now
+		deduplicated = append(deduplicated, score)
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s scores: %w", source, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.NewDelete().
+		Model((*types.VulnerabilityScore)(nil)).
+		Where("source = ?", source).
+		Where("vulnerability_id IN (?)", bun.In(vulnerabilityIds)).
+		Exec(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s scores: %w", source, err)
+	}
+
+	if len(deduplicated) > 0 {
+		_, err = tx.NewInsert().Model(&deduplicated).Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to insert %d %s scores: %w", len(deduplicated), source, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit %s scores: %w", source, err)
+	}
+	return nil
+}
+
+// GetVulnerabilityScores returns the scores known for a vulnerability of a source.
+func GetVulnerabilityScores(db *bun.DB, source, vulnerabilityId string) ([]types.VulnerabilityScore, error) {
+	var scores []types.VulnerabilityScore
+	err := db.NewSelect().
+		Model(&scores).
+		Where("source = ?", source).
+		Where("vulnerability_id = ?", vulnerabilityId).
+		Order("version DESC", "provider").
+		Scan(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve scores of %s %s: %w", source, vulnerabilityId, err)
+	}
+	return scores, nil
+}
@@ -0,0 +1,101 @@
+// Package relicensing detects the versions of a package that changed its license, e.g. from MIT to BUSL-1.1.
+package relicensing
+
+import (
+	"slices"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/tools"
+	semver "github.com/CodeClarityCE/utility-node-semver"
+	"github.com/CodeClarityCE/utility-node-semver/versions"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// Change is a license change between two consecutive versions of a package.
+type Change struct {
+	PreviousVersion string `json:"previous_version"`
+	PreviousLicense string `json:"previous_license"`
+	Version         string `json:"version"`
+	License         string `json:"license"`
+}
+
+// Ecosystem returns the semver ecosystem of the packages of a language.
+func Ecosystem(language string) semver.EcosystemType {
+	if language == "php" {
+		return semver.Composer
+	}
+	return semver.NodeJS
+}
+
+// Detect returns the license changes between the consecutive versions of a package, in version order.
+// Licenses are compared once normalized, a reordered expression is not a change. Versions without
+// a license, stored before their license was normalized or that can't be parsed are skipped, so
+// are prereleases.
+func Detect(packageVersions []knowledge.Version, ecosystem semver.EcosystemType) []Change {
+	type licensed struct {
+		version versions.Semver
+		name    string
+		license string
+	}
+
+	var ordered []licensed
+	for _, version := range packageVersions {
+		license, ok := normalizedLicense(version)
+		if !ok {
+			continue
+		}
+		parsed, err := semver.ParseSemverWithEcosystem(version.Version, ecosystem)
+		if err != nil || parsed.PreReleaseTag != "" || parsed.IsDev {
+			continue
+		}
+		ordered = append(ordered, licensed{version: parsed, name: version.Version, license: license})
+	}
+	slices.SortFunc(ordered, func(a, b licensed) int {
+		return a.version.Compare(b.version, false)
+	})
+
+	var changes []Change
+	for i := 1; i < len(ordered); i++ {
+		previous, current := ordered[i-1], ordered[i]
+		if spdx.Equivalent(previous.license, current.license) {
+			continue
+		}
+		changes = append(changes, Change{
+			PreviousVersion: previous.name,
+			PreviousLicense: previous.license,
+			Version:         current.name,
+			License:         current.license,
+		})
+	}
+	return changes
+}
+
+// normalizedLicense returns the license of a version stored by tools.SetVersionLicense, normalized
+// again so that versions normalized by an older normalizer compare with the new ones. A license
+// that can't be normalized is returned as published. It reports false for the versions without
+// a license or stored before their license was normalized, which only hold the raw license.
+func normalizedLicense(version knowledge.Version) (string, bool) {
+	expression, ok := version.Extra[tools.LicenseExpressionKey].(string)
+	if !ok {
+		return "", false
+	}
+	if expression != "" {
+		if normalized := spdx.Normalize(expression); normalized.Resolved() {
+			return normalized.Expression, true
+		}
+		return expression, true
+	}
+	license := tools.VersionLicense(version)
+	return license, license != ""
+}
+
+// Introduced returns the changes of the given versions, e.g. the versions that were just published.
+func Introduced(changes []Change, newVersions []knowledge.Version) []Change {
+	var introduced []Change
+	for _, change := range changes {
+		if slices.ContainsFunc(newVersions, func(v knowledge.Version) bool { return v.Version == change.Version }) {
+			introduced = append(introduced, change)
+		}
+	}
+	return introduced
+}
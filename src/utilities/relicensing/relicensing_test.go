@@ -0,0 +1,58 @@
+package relicensing
+
+import (
+	"testing"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/tools"
+	semver "github.com/CodeClarityCE/utility-node-semver"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+	"github.com/stretchr/testify/assert"
+)
+
+func version(name string, licenses ...string) knowledge.Version {
+	v := knowledge.Version{Version: name}
+	tools.SetVersionLicense(&v, licenses)
+	return v
+}
+
+func TestDetect(t *testing.T) {
+	versions := []knowledge.Version{
+		version("2.0.0", "BUSL-1.1"),
+		version("1.0.0", "MIT"),
+		version("1.10.0", "Apache-2.0 OR MIT"),
+		version("1.2.0", "MIT License"),
+		version("1.9.0", "(MIT OR Apache-2.0)"),
+		version("1.9.1"),
+		version("2.0.0-beta.1", "ISC"),
+		version("not-a-version", "ISC"),
+	}
+
+	changes := Detect(versions, semver.NodeJS)
+	assert.Equal(t, []Change{
+		{PreviousVersion: "1.2.0", PreviousLicense: "MIT", Version: "1.9.0", License: "MIT OR Apache-2.0"},
+		{PreviousVersion: "1.10.0", PreviousLicense: "Apache-2.0 OR MIT", Version: "2.0.0", License: "BUSL-1.1"},
+	}, changes)
+
+	assert.Equal(t, changes[1:], Introduced(changes, []knowledge.Version{versions[0]}))
+	assert.Empty(t, Introduced(changes, []knowledge.Version{versions[1]}))
+}
+
+func TestDetectStoredVersions(t *testing.T) {
+	// Versions loaded from the database have their extra field decoded from JSON
+	versions := []knowledge.Version{
+		{Version: "1.0.0", Extra: map[string]any{tools.LicenseKey: []any{"proprietary"}, tools.LicenseExpressionKey: ""}},
+		{Version: "1.1.0", Extra: map[string]any{tools.LicenseKey: []any{"MIT"}, tools.LicenseExpressionKey: "MIT"}},
+		{Version: "1.2.0", Extra: map[string]any{"type": "library"}},
+		// Stored before the licenses were normalized
+		{Version: "1.3.0", Extra: map[string]any{tools.LicenseKey: []any{"MIT License"}}},
+		// Normalized by an older normalizer
+		{Version: "1.4.0", Extra: map[string]any{tools.LicenseKey: []any{"MIT"}, tools.LicenseExpressionKey: "MIT"}},
+		{Version: "2.0.0", Extra: map[string]any{tools.LicenseKey: []any{"GPL-2.0"}, tools.LicenseExpressionKey: "GPL-2.0"}},
+		{Version: "2.1.0", Extra: map[string]any{tools.LicenseKey: []any{"GPL-2.0"}, tools.LicenseExpressionKey: "GPL-2.0-only"}},
+	}
+
+	assert.Equal(t, []Change{
+		{PreviousVersion: "1.0.0", PreviousLicense: "proprietary", Version: "1.1.0", License: "MIT"},
+		{PreviousVersion: "1.4.0", PreviousLicense: "MIT", Version: "2.0.0", License: "GPL-2.0-only"},
+	}, Detect(versions, semver.Composer))
+}
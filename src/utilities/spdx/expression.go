@@ -0,0 +1,333 @@
+// Package spdx parses SPDX license expressions and normalizes the free-form license strings
+// found in package manifests ("Apache 2.0", "MIT/X11", "GPLv3+") into valid expressions.
+// https://spdx.github.io/spdx-spec/v2.3/SPDX-license-expressions/
+package spdx
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operators of a compound expression. AND binds tighter than OR.
+const (
+	And = "AND"
+	Or  = "OR"
+)
+
+// Node is a license expression: a License or a Compound of expressions.
+type Node interface {
+	String() string
+}
+
+// License is a simple expression, optionally followed by "+" and a WITH exception.
+type License struct {
+	ID        string
+	OrLater   bool
+	Exception string
+}
+
+func (l *License) String() string {
+	s := l.ID
+	if l.OrLater {
+		s += "+"
+	}
+	if l.Exception != "" {
+		s += " WITH " + l.Exception
+	}
+	return s
+}
+
+// Compound joins two or more expressions with the same operator.
+type Compound struct {
+	Operator string
+	Terms    []Node
+}
+
+func (c *Compound) String() string {
+	terms := make([]string, len(c.Terms))
+	for i, term := range c.Terms {
+		terms[i] = term.String()
+		// OR binds looser than AND, nested compounds of the other operator need parentheses
+		if nested, ok := term.(*Compound); ok && nested.Operator != c.Operator {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+	return strings.Join(terms, " "+c.Operator+" ")
+}
+
+// Equivalent reports whether two expressions only differ by the order of their terms, like
+// "MIT OR Apache-2.0" and "Apache-2.0 OR MIT". Invalid expressions are compared as strings.
+func Equivalent(a, b string) bool {
+	nodeA, errA := Parse(a)
+	nodeB, errB := Parse(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return canonical(nodeA) == canonical(nodeB)
+}
+
+// canonical returns the expression with the terms of its compounds sorted.
+func canonical(node Node) string {
+	compound, ok := node.(*Compound)
+	if !ok {
+		return node.String()
+	}
+	terms := make([]string, len(compound.Terms))
+	for i, term := range compound.Terms {
+		terms[i] = canonical(term)
+		if _, ok := term.(*Compound); ok {
+			terms[i] = "(" + terms[i] + ")"
+		}
+	}
+	slices.Sort(terms)
+	return strings.Join(slices.Compact(terms), " "+compound.Operator+" ")
+}
+
+// Licenses returns the license ids of an expression, sorted and without duplicates.
+// Exceptions are not included.
+func Licenses(node Node) []string {
+	var ids []string
+	walk(node, func(l *License) {
+		ids = append(ids, l.ID)
+	})
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+func walk(node Node, fn func(*License)) {
+	switch n := node.(type) {
+	case *License:
+		fn(n)
+	case *Compound:
+		for _, term := range n.Terms {
+			walk(term, fn)
+		}
+	}
+}
+
+// Parse parses a license expression. Operators may be upper or lower case, ids are
+// kept as written, use a Normalizer to resolve them against the SPDX license list.
+func Parse(expression string) (Node, error) {
+	tokens, err := tokenize(expression, false)
+	if err != nil {
+		return nil, err
+	}
+	return parse(tokens)
+}
+
+type tokenKind int
+
+const (
+	tokenTerm tokenKind = iota
+	tokenAnd
+	tokenOr
+	tokenWith
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// tokenize splits an expression into tokens. In lenient mode, words that are not operators
+// are merged into a single term ("Apache License 2.0") and the separators commonly used
+// in manifests are read as operators: "," "/" ";" "|" as OR, "&" as AND.
+func tokenize(expression string, lenient bool) ([]token, error) {
+	var tokens []token
+	var words []string
+
+	flush := func() {
+		if len(words) == 0 {
+			return
+		}
+		if lenient {
+			tokens = append(tokens, token{tokenTerm, strings.Join(words, " ")})
+		} else {
+			for _, word := range words {
+				tokens = append(tokens, token{tokenTerm, word})
+			}
+		}
+		words = nil
+	}
+
+	for _, field := range splitFields(expression, lenient) {
+		switch {
+		case field == "(":
+			flush()
+			tokens = append(tokens, token{tokenOpen, field})
+		case field == ")":
+			flush()
+			tokens = append(tokens, token{tokenClose, field})
+		case strings.EqualFold(field, And) || (lenient && field == "&"):
+			flush()
+			tokens = append(tokens, token{tokenAnd, And})
+		case strings.EqualFold(field, Or) || (lenient && strings.ContainsAny(field, ",/;|")):
+			flush()
+			tokens = append(tokens, token{tokenOr, Or})
+		case strings.EqualFold(field, "WITH"):
+			flush()
+			tokens = append(tokens, token{tokenWith, "WITH"})
+		default:
+			if !lenient && !validTerm(field) {
+				return nil, fmt.Errorf("invalid license id %q", field)
+			}
+			words = append(words, field)
+		}
+	}
+	flush()
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	return tokens, nil
+}
+
+// splitFields splits an expression on white space and parentheses, and in lenient mode on separators.
+func splitFields(expression string, lenient bool) []string {
+	var fields []string
+	var current strings.Builder
+	flush := func() {
+		if current.Len() > 0 {
+			fields = append(fields, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range expression {
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		case r == '(' || r == ')' || (lenient && strings.ContainsRune(",/;|&", r)):
+			flush()
+			fields = append(fields, string(r))
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return fields
+}
+
+// validTerm reports whether s is a license or exception id, optionally followed by "+".
+// LicenseRef- and DocumentRef- references also use this alphabet.
+func validTerm(s string) bool {
+	s = strings.TrimSuffix(s, "+")
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' || r == ':') {
+			return false
+		}
+	}
+	return true
+}
+
+// parser is a recursive descent parser of the grammar
+//
+//	or       = and { "OR" and }
+//	and      = simple { "AND" simple }
+//	simple   = "(" or ")" | term [ "WITH" term ]
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(tokens []token) (Node, error) {
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].value)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseCompound(tokenOr, Or, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseCompound(tokenAnd, And, p.parseSimple)
+}
+
+func (p *parser) parseCompound(kind tokenKind, operator string, operand func() (Node, error)) (Node, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	terms := []Node{first}
+	for {
+		next, ok := p.peek()
+		if !ok || next.kind != kind {
+			break
+		}
+		p.pos++
+		term, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		// Flatten "a OR (b OR c)" into a single compound
+		if nested, ok := term.(*Compound); ok && nested.Operator == operator {
+			terms = append(terms, nested.Terms...)
+		} else {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 1 {
+		return first, nil
+	}
+	return &Compound{Operator: operator, Terms: terms}, nil
+}
+
+func (p *parser) parseSimple() (Node, error) {
+	next, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of license expression")
+	}
+	p.pos++
+
+	switch next.kind {
+	case tokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing, ok := p.peek()
+		if !ok || closing.kind != tokenClose {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case tokenTerm:
+		license := &License{ID: next.value}
+		if strings.HasSuffix(license.ID, "+") && len(license.ID) > 1 {
+			license.ID = strings.TrimSuffix(license.ID, "+")
+			license.OrLater = true
+		}
+		if with, ok := p.peek(); ok && with.kind == tokenWith {
+			p.pos++
+			exception, ok := p.peek()
+			if !ok || exception.kind != tokenTerm {
+				return nil, fmt.Errorf("missing exception after WITH")
+			}
+			p.pos++
+			license.Exception = exception.value
+		}
+		return license, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q", next.value)
+	}
+}
@@ -0,0 +1,45 @@
+package spdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for expression, expected := range map[string]string{
+		"MIT":                 "MIT",
+		"(MIT OR Apache-2.0)": "MIT OR Apache-2.0",
+		"mit or apache-2.0":   "mit OR apache-2.0",
+		"MIT AND (LGPL-2.1-or-later OR BSD-3-Clause)": "MIT AND (LGPL-2.1-or-later OR BSD-3-Clause)",
+		"MIT OR (Apache-2.0 OR ISC)":                  "MIT OR Apache-2.0 OR ISC",
+		"MIT AND Apache-2.0 OR ISC":                   "(MIT AND Apache-2.0) OR ISC",
+		"GPL-2.0-only WITH Classpath-exception-2.0":   "GPL-2.0-only WITH Classpath-exception-2.0",
+		"MPL-1.1+":               "MPL-1.1+",
+		"LicenseRef-Proprietary": "LicenseRef-Proprietary",
+	} {
+		node, err := Parse(expression)
+		if assert.NoError(t, err, expression) {
+			assert.Equal(t, expected, node.String(), expression)
+		}
+	}
+
+	for _, expression := range []string{"", "MIT OR", "(MIT", "MIT)", "Apache 2.0", "MIT WITH", "MIT/X11", "AND MIT"} {
+		_, err := Parse(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestLicenses(t *testing.T) {
+	node, err := Parse("MIT AND (GPL-2.0-only WITH Classpath-exception-2.0 OR MIT)")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GPL-2.0-only", "MIT"}, Licenses(node))
+}
+
+func TestEquivalent(t *testing.T) {
+	assert.True(t, Equivalent("MIT OR Apache-2.0", "(Apache-2.0 OR MIT)"))
+	assert.True(t, Equivalent("MIT AND (ISC OR 0BSD)", "(0BSD OR ISC) AND MIT"))
+	assert.False(t, Equivalent("MIT OR Apache-2.0", "MIT AND Apache-2.0"))
+	assert.False(t, Equivalent("MIT", "BUSL-1.1"))
+	assert.True(t, Equivalent("SEE LICENSE IN LICENSE", "SEE LICENSE IN LICENSE"))
+}
@@ -0,0 +1,433 @@
+package spdx
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/licensepolicy"
+)
+
+// Result is the normalization of a license string.
+type Result struct {
+	// Raw is the license string as found in the manifest
+	Raw string
+	// Expression is the normalized SPDX expression, empty unless every term was resolved
+	Expression string
+	// Licenses are the license ids that were resolved, sorted. They include LicenseRef- references.
+	Licenses []string
+	// Unresolved are the terms that are not SPDX licenses or exceptions
+	Unresolved []string
+}
+
+// Resolved reports whether the license string was normalized into an SPDX expression.
+func (r Result) Resolved() bool {
+	return r.Expression != ""
+}
+
+// Normalizer resolves the terms of license strings against the SPDX license list.
+type Normalizer struct {
+	licenses   map[string]string
+	fuzzy      map[string]string
+	exceptions map[string]string
+}
+
+// NewNormalizer returns a normalizer of the given SPDX license and exception ids.
+// Without exceptions, any well-formed exception id is accepted.
+func NewNormalizer(licenses []string, exceptions []string) *Normalizer {
+	n := &Normalizer{
+		licenses: make(map[string]string, len(licenses)),
+		fuzzy:    make(map[string]string, len(licenses)),
+	}
+	for _, id := range licenses {
+		// Deprecated ids like "LGPL-2.1+" are parsed as "LGPL-2.1" followed by "+"
+		if strings.HasSuffix(id, "+") {
+			continue
+		}
+		n.licenses[strings.ToLower(id)] = id
+		if k := key(id); k != "" {
+			if _, ok := n.fuzzy[k]; !ok {
+				n.fuzzy[k] = id
+			}
+		}
+	}
+	if len(exceptions) > 0 {
+		n.exceptions = make(map[string]string, len(exceptions))
+		for _, id := range exceptions {
+			n.exceptions[strings.ToLower(id)] = id
+		}
+	}
+	return n
+}
+
+//go:embed exceptions.json
+var exceptionsFile []byte
+
+// exceptionList is the list of exceptions of the SPDX license list the embedded normalizer knows.
+type exceptionList struct {
+	SPDXListVersion string `json:"spdxListVersion"`
+	Exceptions      []struct {
+		ID         string `json:"id"`
+		Deprecated bool   `json:"deprecated,omitempty"`
+	} `json:"exceptions"`
+}
+
+var embeddedNormalizer = sync.OnceValue(func() *Normalizer {
+	catalog := licensepolicy.DefaultCatalog()
+	ids := make([]string, len(catalog.Licenses))
+	for i, license := range catalog.Licenses {
+		ids[i] = license.ID
+	}
+
+	var list exceptionList
+	if err := json.Unmarshal(exceptionsFile, &list); err != nil {
+		panic(fmt.Sprintf("invalid embedded exceptions.json: %v", err))
+	}
+	exceptions := make([]string, len(list.Exceptions))
+	for i, exception := range list.Exceptions {
+		exceptions[i] = exception.ID
+	}
+	return NewNormalizer(ids, exceptions)
+})
+
+var defaultNormalizer atomic.Pointer[Normalizer]
+
+// Default returns the normalizer set by SetDefault, or else the normalizer of the licenses of the
+// embedded license policy catalog and of the embedded exceptions.
+func Default() *Normalizer {
+	if n := defaultNormalizer.Load(); n != nil {
+		return n
+	}
+	return embeddedNormalizer()
+}
+
+// SetDefault replaces the default normalizer, e.g. by one of the licenses mirrored from the
+// latest SPDX license list. A nil normalizer restores the embedded one.
+func SetDefault(n *Normalizer) {
+	defaultNormalizer.Store(n)
+}
+
+// Normalize normalizes a license string with the default normalizer.
+func Normalize(raw string) Result {
+	return Default().Normalize(raw)
+}
+
+// NormalizeList normalizes a list of license strings with the default normalizer.
+func NormalizeList(raw []string) Result {
+	return Default().NormalizeList(raw)
+}
+
+// Normalize turns a license string into an SPDX expression. Besides valid expressions, it accepts
+// the common spellings of licenses ("Apache License, Version 2.0", "GPLv3+", "new BSD"),
+// deprecated SPDX ids and the separators used in manifests ("MIT/X11", "MIT, GPL-2.0").
+func (n *Normalizer) Normalize(raw string) Result {
+	node, result := n.normalize(raw)
+	if node != nil {
+		result.Expression = node.String()
+	}
+	return result
+}
+
+// NormalizeList normalizes the licenses of a manifest listing several of them, like the "license"
+// array of composer.json or the deprecated "licenses" array of package.json. The package can be
+// used under any of them, they are joined with OR.
+func (n *Normalizer) NormalizeList(raw []string) Result {
+	var nodes []Node
+	var raws []string
+	result := Result{}
+	for _, value := range raw {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		node, r := n.normalize(value)
+		raws = append(raws, value)
+		result.Licenses = append(result.Licenses, r.Licenses...)
+		result.Unresolved = append(result.Unresolved, r.Unresolved...)
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+	result.Raw = strings.Join(raws, ", ")
+	slices.Sort(result.Licenses)
+	result.Licenses = slices.Compact(result.Licenses)
+
+	if len(nodes) == 0 || len(nodes) != len(raws) {
+		return result
+	}
+	if len(nodes) == 1 {
+		result.Expression = nodes[0].String()
+	} else {
+		compound := &Compound{Operator: Or}
+		for _, node := range nodes {
+			if nested, ok := node.(*Compound); ok && nested.Operator == Or {
+				compound.Terms = append(compound.Terms, nested.Terms...)
+			} else {
+				compound.Terms = append(compound.Terms, node)
+			}
+		}
+		result.Expression = compound.String()
+	}
+	return result
+}
+
+// normalize returns the normalized expression of raw, nil if a term could not be resolved.
+func (n *Normalizer) normalize(raw string) (Node, Result) {
+	result := Result{Raw: raw}
+	value := strings.Trim(strings.TrimSpace(raw), `"'`)
+	if value == "" {
+		return nil, result
+	}
+
+	// The whole string may be the name of a license containing separators
+	if license, ok := n.resolveLicense(&License{ID: value}); ok {
+		result.Licenses = Licenses(license)
+		return license, result
+	}
+
+	// "The MIT License (MIT)"
+	if match := namedLicense.FindStringSubmatch(value); match != nil {
+		if license, ok := n.resolveLicense(&License{ID: match[1]}); ok {
+			result.Licenses = Licenses(license)
+			return license, result
+		}
+	}
+
+	tokens, err := tokenize(value, true)
+	if err == nil {
+		var node Node
+		node, err = parse(tokens)
+		if err == nil {
+			node = n.resolve(node, &result)
+			slices.Sort(result.Licenses)
+			result.Licenses = slices.Compact(result.Licenses)
+			if len(result.Unresolved) == 0 {
+				return node, result
+			}
+			return nil, result
+		}
+	}
+	result.Unresolved = []string{value}
+	return nil, result
+}
+
+// resolve returns a copy of node with the terms resolved against the SPDX lists.
+// The terms that could not be resolved are added to result.Unresolved.
+func (n *Normalizer) resolve(node Node, result *Result) Node {
+	switch node := node.(type) {
+	case *Compound:
+		compound := &Compound{Operator: node.Operator}
+		for _, term := range node.Terms {
+			resolved := n.resolve(term, result)
+			if nested, ok := resolved.(*Compound); ok && nested.Operator == node.Operator {
+				compound.Terms = append(compound.Terms, nested.Terms...)
+			} else {
+				compound.Terms = append(compound.Terms, resolved)
+			}
+		}
+		return compound
+	case *License:
+		resolved, ok := n.resolveLicense(node)
+		if !ok {
+			result.Unresolved = append(result.Unresolved, node.String())
+			return node
+		}
+		result.Licenses = append(result.Licenses, Licenses(resolved)...)
+		return resolved
+	}
+	return node
+}
+
+// deprecated are the deprecated SPDX ids that have no "-only" replacement.
+var deprecated = map[string]string{
+	"GPL-2.0-with-autoconf-exception":  "GPL-2.0-only WITH Autoconf-exception-2.0",
+	"GPL-2.0-with-bison-exception":     "GPL-2.0-or-later WITH Bison-exception-2.2",
+	"GPL-2.0-with-classpath-exception": "GPL-2.0-only WITH Classpath-exception-2.0",
+	"GPL-2.0-with-font-exception":      "GPL-2.0-only WITH Font-exception-2.0",
+	"GPL-2.0-with-GCC-exception":       "GPL-2.0-only WITH GCC-exception-2.0",
+	"GPL-3.0-with-autoconf-exception":  "GPL-3.0-only WITH Autoconf-exception-3.0",
+	"GPL-3.0-with-GCC-exception":       "GPL-3.0-only WITH GCC-exception-3.1",
+	"eCos-2.0":                         "GPL-2.0-or-later WITH eCos-exception-2.0",
+	"wxWindows":                        "LGPL-2.0-or-later WITH WxWindows-exception-3.1",
+	"Nunit":                            "zlib-acknowledgement",
+	"StandardML-NJ":                    "SMLNJ",
+	"bzip2-1.0.5":                      "bzip2-1.0.6",
+}
+
+// aliases are the common names of licenses that are not close enough to their SPDX id,
+// indexed by their key. Bare family names like "BSD" or "Apache" name several licenses
+// and are left unresolved.
+var aliases = map[string]string{
+	"asl2":            "Apache-2.0",
+	"al2":             "Apache-2.0",
+	"apachesoftware2": "Apache-2.0",
+	"expat":           "MIT",
+	"mitx11":          "MIT",
+	"simplifiedbsd":   "BSD-2-Clause",
+	"freebsd":         "BSD-2-Clause",
+	"bsd2":            "BSD-2-Clause",
+	"2clausebsd":      "BSD-2-Clause",
+	"newbsd":          "BSD-3-Clause",
+	"revisedbsd":      "BSD-3-Clause",
+	"modifiedbsd":     "BSD-3-Clause",
+	"bsd3":            "BSD-3-Clause",
+	"3clausebsd":      "BSD-3-Clause",
+	"cc0":             "CC0-1.0",
+	"boost":           "BSL-1.0",
+	"boostsoftware":   "BSL-1.0",
+	"psf":             "PSF-2.0",
+	"python":          "Python-2.0",
+	"ofl":             "OFL-1.1",
+	"silofl":          "OFL-1.1",
+	"silopenfont":     "OFL-1.1",
+	"silopenfont1.1":  "OFL-1.1",
+	"busl":            "BUSL-1.1",
+	"businesssource":  "BUSL-1.1",
+	"sspl":            "SSPL-1.0",
+	"wtfpl2":          "WTFPL",
+}
+
+// resolveLicense resolves the id and exception of a simple expression.
+func (n *Normalizer) resolveLicense(license *License) (Node, bool) {
+	value := strings.TrimSpace(license.ID)
+	orLater := license.OrLater
+	if strings.HasSuffix(value, "+") {
+		value = strings.TrimSpace(strings.TrimSuffix(value, "+"))
+		orLater = true
+	}
+	if value == "" {
+		return nil, false
+	}
+
+	// References to licenses outside of the SPDX list are valid expressions
+	if validTerm(value) && (strings.HasPrefix(value, "LicenseRef-") || strings.HasPrefix(value, "DocumentRef-")) {
+		return &License{ID: value, Exception: license.Exception}, license.Exception == "" || n.validException(license.Exception)
+	}
+
+	id, ok := n.licenses[strings.ToLower(value)]
+	if !ok {
+		k := key(value)
+		if trimmed, found := trimOrLater(k); found {
+			k, orLater = trimmed, true
+		}
+		if id, ok = aliases[k]; !ok {
+			id, ok = n.fuzzy[k]
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+
+	resolved := &License{ID: id, Exception: license.Exception}
+	if expression, ok := deprecated[id]; ok {
+		node, err := Parse(expression)
+		if err != nil {
+			return nil, false
+		}
+		replacement := node.(*License)
+		if license.Exception != "" && replacement.Exception != "" {
+			return nil, false
+		}
+		resolved = replacement
+		if license.Exception != "" {
+			resolved.Exception = license.Exception
+		}
+	}
+
+	// "GPL-2.0" and "GPL-2.0+" are deprecated for "GPL-2.0-only" and "GPL-2.0-or-later"
+	base := strings.TrimSuffix(resolved.ID, "-only")
+	if _, ok := n.licenses[strings.ToLower(base+"-only")]; ok {
+		if orLater || strings.HasSuffix(resolved.ID, "-or-later") {
+			resolved.ID = n.licenses[strings.ToLower(strings.TrimSuffix(base, "-or-later")+"-or-later")]
+		} else {
+			resolved.ID = n.licenses[strings.ToLower(base+"-only")]
+		}
+		if resolved.ID == "" {
+			return nil, false
+		}
+	} else if orLater && !strings.HasSuffix(resolved.ID, "-or-later") {
+		resolved.OrLater = true
+	}
+
+	if resolved.Exception != "" {
+		exception, ok := n.resolveException(resolved.Exception)
+		if !ok {
+			return nil, false
+		}
+		resolved.Exception = exception
+	}
+	return resolved, true
+}
+
+func (n *Normalizer) validException(id string) bool {
+	_, ok := n.resolveException(id)
+	return ok
+}
+
+func (n *Normalizer) resolveException(id string) (string, bool) {
+	if n.exceptions == nil {
+		return id, validTerm(id) && !strings.HasSuffix(id, "+")
+	}
+	exception, ok := n.exceptions[strings.ToLower(id)]
+	return exception, ok
+}
+
+// namedLicense matches a license name followed by its abbreviation in parentheses
+var namedLicense = regexp.MustCompile(`^([^()]+?)\s*\([^()]+\)$`)
+
+var (
+	keySeparators = regexp.MustCompile(`[^a-z0-9.]+`)
+	keyVersion    = regexp.MustCompile(`([a-z])v(\d)`)
+	keyZero       = regexp.MustCompile(`(\d)\.0($|[^\d.])`)
+)
+
+// keyWords are dropped from the keys of licenses
+var keyWords = map[string]bool{"the": true, "gnu": true, "license": true, "licensed": true, "version": true, "v": true, "ver": true, "under": true}
+
+// keyNames are the long names of licenses replaced by their abbreviation in keys
+var keyNames = strings.NewReplacer(
+	"lessergeneralpublic", "lgpl",
+	"librarygeneralpublic", "lgpl",
+	"afferogeneralpublic", "agpl",
+	"generalpublic", "gpl",
+	"lessergpl", "lgpl",
+	"afferogpl", "agpl",
+	"mozillapublic", "mpl",
+	"eclipsepublic", "epl",
+)
+
+// key reduces the spelling of a license to a key that is shared by its variants:
+// "Apache License, Version 2.0", "apache-2" and "Apache 2.0" all become "apache2".
+func key(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "licence", "license")
+	var words []string
+	for _, word := range keySeparators.Split(s, -1) {
+		word = strings.Trim(word, ".")
+		if word != "" && !keyWords[word] {
+			words = append(words, word)
+		}
+	}
+	k := keyNames.Replace(strings.Join(words, ""))
+	k = keyVersion.ReplaceAllString(k, "$1$2")
+	for {
+		trimmed := keyZero.ReplaceAllString(k, "$1$2")
+		if trimmed == k {
+			return k
+		}
+		k = trimmed
+	}
+}
+
+// trimOrLater removes the "or later" suffixes of a key.
+func trimOrLater(k string) (string, bool) {
+	for _, suffix := range []string{"oranylater", "orlater", "orgreater", "ornewer", "orabove"} {
+		if trimmed, found := strings.CutSuffix(k, suffix); found && trimmed != "" {
+			return trimmed, true
+		}
+	}
+	return k, false
+}
@@ -0,0 +1,95 @@
+package spdx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for raw, expected := range map[string]string{
+		"MIT":                                  "MIT",
+		"(MIT OR Apache-2.0)":                  "MIT OR Apache-2.0",
+		"Apache 2.0":                           "Apache-2.0",
+		"Apache License, Version 2.0":          "Apache-2.0",
+		"new BSD License":                      "BSD-3-Clause",
+		"The MIT License (MIT)":                "MIT",
+		"GPLv3":                                "GPL-3.0-only",
+		"GPL-2.0+":                             "GPL-2.0-or-later",
+		"GPL v2 or later":                      "GPL-2.0-or-later",
+		"GNU Lesser General Public License v3": "LGPL-3.0-only",
+		"LGPL-2.1":                             "LGPL-2.1-only",
+		"Mozilla Public License 2.0":           "MPL-2.0",
+		"MIT, BSD-3-Clause":                    "MIT OR BSD-3-Clause",
+		"mit and apache 2":                     "MIT AND Apache-2.0",
+		"MIT AND (LGPL-2.1+ OR BSD-3-Clause)":  "MIT AND (LGPL-2.1-or-later OR BSD-3-Clause)",
+		"GPL-2.0-with-classpath-exception":     "GPL-2.0-only WITH Classpath-exception-2.0",
+		"GPL-2.0 WITH Classpath-exception-2.0": "GPL-2.0-only WITH Classpath-exception-2.0",
+		"cc-by-4.0":                            "CC-BY-4.0",
+		"LicenseRef-Proprietary":               "LicenseRef-Proprietary",
+	} {
+		result := Normalize(raw)
+		assert.True(t, result.Resolved(), raw)
+		assert.Equal(t, expected, result.Expression, raw)
+		assert.Empty(t, result.Unresolved, raw)
+	}
+
+	result := Normalize("MIT OR GPL-2.0+")
+	assert.Equal(t, []string{"GPL-2.0-or-later", "MIT"}, result.Licenses)
+}
+
+func TestNormalizeUnresolved(t *testing.T) {
+	// Values found in the registries, see src/licenses.txt
+	for _, raw := range []string{"false", "1233", "UNLICENSED", "SEE LICENSE IN LICENSE", "Public Domain", "GPL", "BSD", "Apache", "CDDL", `map[name:map[toLowerCase:toString] url:javascript:alert(1)//"' onmouseover=alert(1)]`} {
+		result := Normalize(raw)
+		assert.False(t, result.Resolved(), raw)
+		assert.Empty(t, result.Expression, raw)
+		assert.NotEmpty(t, result.Unresolved, raw)
+	}
+
+	result := Normalize("MIT OR Custom")
+	assert.False(t, result.Resolved())
+	assert.Equal(t, []string{"MIT"}, result.Licenses)
+	assert.Equal(t, []string{"Custom"}, result.Unresolved)
+
+	result = Normalize("  ")
+	assert.False(t, result.Resolved())
+	assert.Empty(t, result.Unresolved)
+}
+
+func TestNormalizeList(t *testing.T) {
+	result := NormalizeList([]string{"MIT", "GPL-3.0+"})
+	assert.Equal(t, "MIT OR GPL-3.0-or-later", result.Expression)
+	assert.Equal(t, []string{"GPL-3.0-or-later", "MIT"}, result.Licenses)
+	assert.Equal(t, "MIT, GPL-3.0+", result.Raw)
+
+	result = NormalizeList([]string{"Apache License, Version 2.0", ""})
+	assert.Equal(t, "Apache-2.0", result.Expression)
+
+	result = NormalizeList([]string{"MIT", "proprietary"})
+	assert.False(t, result.Resolved())
+	assert.Equal(t, []string{"proprietary"}, result.Unresolved)
+}
+
+func TestNormalizeExceptions(t *testing.T) {
+	assert.Equal(t, "GPL-2.0-only WITH Classpath-exception-2.0", Normalize("gpl-2.0-only with classpath-exception-2.0").Expression)
+	assert.Equal(t, "Apache-2.0 WITH LLVM-exception", Normalize("Apache-2.0 WITH LLVM-exception").Expression)
+	result := Normalize("GPL-2.0-only WITH Unknown-exception")
+	assert.False(t, result.Resolved())
+	assert.Equal(t, []string{"GPL-2.0-only WITH Unknown-exception"}, result.Unresolved)
+
+	n := NewNormalizer([]string{"GPL-2.0-only", "GPL-2.0-or-later"}, []string{"Classpath-exception-2.0"})
+	assert.Equal(t, "GPL-2.0-only WITH Classpath-exception-2.0", n.Normalize("GPL-2.0-only with classpath-exception-2.0").Expression)
+	assert.False(t, n.Normalize("GPL-2.0-only WITH Unknown-exception").Resolved())
+}
+
+func TestSetDefault(t *testing.T) {
+	defer SetDefault(nil)
+
+	SetDefault(NewNormalizer([]string{"MIT", "Some-New-License-1.0"}, []string{"Some-exception"}))
+	assert.Equal(t, "Some-New-License-1.0 WITH Some-exception", Normalize("some-new-license-1.0 WITH some-exception").Expression)
+	assert.False(t, Normalize("Apache-2.0").Resolved())
+
+	SetDefault(nil)
+	assert.True(t, Normalize("Apache-2.0").Resolved())
+}
@@ -1,6 +1,9 @@
 package tools
 
 import (
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
 	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
 	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
 )
@@ -9,6 +12,7 @@ import (
 // It populates the fields of the package object with the corresponding values from the npm object.
 // If the repository field in the npm object is a string, it sets the source type to "string" and the source URL to the repository value.
 // If the repository field in the npm object is a map, it extracts the source type and source URL from the map and sets them in the package object.
+// The license is kept as published and normalized into an SPDX expression.
 // Finally, it calls CreateExtraPackageInfoNpm to populate the extra field of the package object.
 func CreatePackageInfoNpm(result types.Npm) knowledge.Package {
 	var pack knowledge.Package
@@ -20,6 +24,12 @@ func CreatePackageInfoNpm(result types.Npm) knowledge.Package {
 	pack.Versions = types.ConvertNpmVersion(result)
 	pack.Time = result.Time[pack.LatestVersion]
 
+	// Licenses of every version, a package can be relicensed
+	for i := range pack.Versions {
+		info := result.Versions[pack.Versions[i].Version]
+		SetVersionLicense(&pack.Versions[i], NpmLicenses(info.License, info.Licenses))
+	}
+
 	// Repository can be a string or a map
 	if result.Repository != nil {
 		if _, ok := result.Repository.(string); ok {
@@ -37,16 +47,18 @@ func CreatePackageInfoNpm(result types.Npm) knowledge.Package {
 		}
 	}
 
-	if license, ok := result.License.(knowledge.LicenseNpm); ok {
-		pack.License = license.Type
-	} else if license, ok := result.License.(string); ok {
-		pack.License = license
+	// License can be a string, an object or junk, the deprecated licenses field a list of objects
+	licenses := NpmLicenses(result.License, result.Licenses)
+	pack.License = strings.Join(licenses, ", ")
+	for _, license := range result.Licenses {
+		pack.Licenses = append(pack.Licenses, knowledge.LicenseNpm{Type: license.Type, Url: license.Url})
 	}
 
 	if keywords, ok := result.Repository.([]string); ok {
 		pack.Keywords = keywords
 	}
 	pack.Extra = CreateExtraPackageInfoNpm(result)
+	SetLicense(pack.Extra, spdx.NormalizeList(licenses))
 	return pack
 }
 
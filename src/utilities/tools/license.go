@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/spdx"
+	"github.com/CodeClarityCE/service-knowledge/src/utilities/types"
+	knowledge "github.com/CodeClarityCE/utility-types/knowledge_db"
+)
+
+// Keys of the license in the extra field of packages and versions. The normalized license is copied
+// to the license_expression and license_ids columns when the package is stored, see pgsql.UpdatePackage.
+const (
+	// LicenseKey holds the license strings of a version, as published
+	LicenseKey           = "license"
+	LicenseExpressionKey = "license_expression"
+	LicenseIdsKey        = "license_ids"
+)
+
+// SetLicense stores the normalized license of a package or version in its extra field.
+// The raw value is kept where it was found.
+func SetLicense(extra map[string]any, result spdx.Result) {
+	extra[LicenseExpressionKey] = result.Expression
+	licenses := result.Licenses
+	if licenses == nil {
+		licenses = []string{}
+	}
+	extra[LicenseIdsKey] = licenses
+}
+
+// SetVersionLicense stores the licenses of a version in its extra field, as published and normalized.
+func SetVersionLicense(version *knowledge.Version, licenses []string) {
+	if version.Extra == nil {
+		version.Extra = map[string]any{}
+	}
+	if licenses == nil {
+		licenses = []string{}
+	}
+	version.Extra[LicenseKey] = licenses
+	SetLicense(version.Extra, spdx.NormalizeList(licenses))
+}
+
+// VersionLicense returns the license of a version stored by SetVersionLicense: the normalized
+// expression, or else the published license strings. It also reads versions loaded from the
+// database, whose extra field is decoded from JSON.
+func VersionLicense(version knowledge.Version) string {
+	if expression, ok := version.Extra[LicenseExpressionKey].(string); ok && expression != "" {
+		return expression
+	}
+	switch licenses := version.Extra[LicenseKey].(type) {
+	case []string:
+		return strings.Join(licenses, ", ")
+	case []any:
+		values := make([]string, 0, len(licenses))
+		for _, license := range licenses {
+			if value, ok := license.(string); ok {
+				values = append(values, value)
+			}
+		}
+		return strings.Join(values, ", ")
+	}
+	return ""
+}
+
+// NpmLicenses returns the license strings of the "license" field of a package.json, a string or
+// an object with a type, or else of its deprecated "licenses" array.
+func NpmLicenses(license any, licenses any) []string {
+	var values []string
+	add := func(value any) {
+		switch v := value.(type) {
+		case string:
+			if strings.TrimSpace(v) != "" {
+				values = append(values, v)
+			}
+		case types.LicenseNpm:
+			if v.Type != "" {
+				values = append(values, v.Type)
+			}
+		case map[string]any:
+			if t, ok := v["type"].(string); ok && t != "" {
+				values = append(values, t)
+			}
+		case bool, float64:
+			// Kept so that they are counted as unresolved licenses
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+
+	if list, ok := license.([]any); ok {
+		for _, l := range list {
+			add(l)
+		}
+	} else {
+		add(license)
+	}
+	if len(values) > 0 {
+		return values
+	}
+	switch list := licenses.(type) {
+	case []types.LicenseNpm:
+		for _, l := range list {
+			add(l)
+		}
+	case []any:
+		for _, l := range list {
+			add(l)
+		}
+	}
+	return values
+}
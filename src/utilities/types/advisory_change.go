@@ -0,0 +1,17 @@
+package types
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AdvisoryChange records that an advisory changed since the merged advisories were last refreshed.
+// Rows are written by triggers on the source tables: Source is the table and Key the id of the
+// record, or the CVE id for epss and kev.
+type AdvisoryChange struct {
+	bun.BaseModel `bun:"table:advisory_change,alias:ac"`
+	Source        string    `bun:"source,pk"`
+	Key           string    `bun:"key,pk"`
+	ChangedAt     time.Time `bun:"changed_at,type:timestamptz,notnull,default:current_timestamp"`
+}
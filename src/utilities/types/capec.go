@@ -0,0 +1,40 @@
+package types
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CAPECEntry is an attack pattern of the MITRE Common Attack Pattern Enumeration and Classification.
+// Ids are stored without their CAPEC- prefix, like the ids of the cwe table.
+// https://capec.mitre.org/data/index.html
+type CAPECEntry struct {
+	bun.BaseModel         `bun:"table:capec,alias:capec"`
+	Id                    uuid.UUID       `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	CAPECId               string          `bun:"capec_id,unique,notnull" json:"id"`
+	Name                  string          `bun:"name" json:"name"`
+	Abstraction           string          `bun:"abstraction" json:"abstraction"`
+	Status                string          `bun:"status" json:"status"`
+	Description           string          `bun:"description" json:"description"`
+	ExtendedDescription   string          `bun:"extended_description" json:"extended_description"`
+	LikelihoodOfAttack    string          `bun:"likelihood_of_attack" json:"likelihood_of_attack"`
+	TypicalSeverity       string          `bun:"typical_severity" json:"typical_severity"`
+	Prerequisites         []string        `bun:"prerequisites,type:jsonb" json:"prerequisites"`
+	Mitigations           []string        `bun:"mitigations,type:jsonb" json:"mitigations"`
+	RelatedAttackPatterns []CAPECRelation `bun:"related_attack_patterns,type:jsonb" json:"related_attack_patterns"`
+	CatalogVersion        string          `bun:"catalog_version" json:"catalog_version"`
+}
+
+// CAPECRelation relates an attack pattern to another one (ChildOf, CanPrecede, ...).
+type CAPECRelation struct {
+	Nature  string `json:"nature"`
+	CAPECId string `json:"capec_id"`
+}
+
+// CWECAPEC links a weakness to an attack pattern exploiting it.
+// It's queried both ways: the attack patterns of a CWE and the weaknesses of a CAPEC.
+type CWECAPEC struct {
+	bun.BaseModel `bun:"table:cwe_capec,alias:cc"`
+	CWEId         string `bun:"cwe_id,pk" json:"cwe_id"`
+	CAPECId       string `bun:"capec_id,pk" json:"capec_id"`
+}
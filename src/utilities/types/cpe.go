@@ -0,0 +1,62 @@
+package types
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CPEProduct is an entry of the NVD CPE Dictionary.
+// https://nvd.nist.gov/developers/products
+type CPEProduct struct {
+	bun.BaseModel `bun:"table:cpe,alias:cpe"`
+	Id            uuid.UUID    `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	CPENameId     string       `bun:"cpe_name_id,unique" json:"cpeNameId"`
+	CPEName       string       `bun:"cpe_name" json:"cpeName"`
+	Part          string       `bun:"part" json:"part"`
+	Vendor        string       `bun:"vendor" json:"vendor"`
+	Product       string       `bun:"product" json:"product"`
+	Version       string       `bun:"version" json:"version"`
+	TargetSw      string       `bun:"target_sw" json:"target_sw"`
+	Title         string       `bun:"title" json:"title"`
+	Titles        []CPETitle   `bun:"titles,type:jsonb" json:"titles"`
+	Refs          []CPERef     `bun:"refs,type:jsonb" json:"refs"`
+	Deprecated    bool         `bun:"deprecated" json:"deprecated"`
+	DeprecatedBy  []CPENameRef `bun:"deprecated_by,type:jsonb" json:"deprecatedBy"`
+	Created       string       `bun:"created" json:"created"`
+	LastModified  string       `bun:"last_modified" json:"lastModified"`
+}
+
+type CPETitle struct {
+	Title string `json:"title"`
+	Lang  string `json:"lang"`
+}
+
+type CPERef struct {
+	Ref  string `json:"ref"`
+	Type string `json:"type,omitempty"`
+}
+
+// CPENameRef points at a concrete CPE name of the dictionary.
+type CPENameRef struct {
+	CPEName   string `json:"cpeName"`
+	CPENameId string `json:"cpeNameId"`
+}
+
+// CPEMatchCriteria is an entry of the NVD CPE Match Criteria API. It resolves
+// the matchCriteriaId referenced by CVE configurations into concrete CPE names.
+// https://nvd.nist.gov/developers/products
+type CPEMatchCriteria struct {
+	bun.BaseModel         `bun:"table:cpe_match,alias:cm"`
+	Id                    uuid.UUID    `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	MatchCriteriaId       string       `bun:"match_criteria_id,unique" json:"matchCriteriaId"`
+	Criteria              string       `bun:"criteria" json:"criteria"`
+	VersionStartIncluding string       `bun:"version_start_including" json:"versionStartIncluding"`
+	VersionStartExcluding string       `bun:"version_start_excluding" json:"versionStartExcluding"`
+	VersionEndIncluding   string       `bun:"version_end_including" json:"versionEndIncluding"`
+	VersionEndExcluding   string       `bun:"version_end_excluding" json:"versionEndExcluding"`
+	Status                string       `bun:"status" json:"status"`
+	Matches               []CPENameRef `bun:"matches,type:jsonb" json:"matches"`
+	Created               string       `bun:"created" json:"created"`
+	LastModified          string       `bun:"last_modified" json:"lastModified"`
+	CPELastModified       string       `bun:"cpe_last_modified" json:"cpeLastModified"`
+}
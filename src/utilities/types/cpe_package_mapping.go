@@ -0,0 +1,32 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Origins of a CPE to package mapping. Manual mappings are never overwritten by the mirrors.
+const (
+	MappingSourceSeed      = "seed"
+	MappingSourceHeuristic = "heuristic"
+	MappingSourceManual    = "manual"
+)
+
+// CPEPackageMapping maps a CPE vendor:product pair to a package of a known ecosystem.
+// Rows can be edited by hand: set source to "manual" to pin a mapping, or set the
+// ecosystem to "nvd" to pin a product as not being a package.
+type CPEPackageMapping struct {
+	bun.BaseModel `bun:"table:cpe_package_mapping,alias:cpm"`
+	Id            uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	Vendor        string    `bun:"vendor,notnull,unique:cpe_package_mapping_vendor_product"`
+	Product       string    `bun:"product,notnull,unique:cpe_package_mapping_vendor_product"`
+	Ecosystem     string    `bun:"ecosystem,notnull"`
+	PackageName   string    `bun:"package_name,notnull"`
+	Purl          string    `bun:"purl"`
+	Source        string    `bun:"source,notnull"`
+	Evidence      string    `bun:"evidence"`
+	CreatedAt     time.Time `bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// CWEGuidance holds the remediation guidance MITRE publishes for a weakness that the shared
+// cwe table can't hold: the mitigations and detection methods with their identifiers,
+// strategies and effectiveness, and the demonstrative examples.
+type CWEGuidance struct {
+	bun.BaseModel         `bun:"table:cwe_guidance,alias:cg"`
+	Id                    uuid.UUID                 `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	CWEId                 string                    `bun:"cwe_id,unique,notnull" json:"cwe_id"`
+	Mitigations           []CWEMitigation           `bun:"mitigations,type:jsonb" json:"mitigations"`
+	DetectionMethods      []CWEDetectionMethod      `bun:"detection_methods,type:jsonb" json:"detection_methods"`
+	DemonstrativeExamples []CWEDemonstrativeExample `bun:"demonstrative_examples,type:jsonb" json:"demonstrative_examples"`
+}
+
+// CWEMitigation is a potential mitigation of a weakness, applicable during the given phases
+// (Architecture and Design, Implementation, ...).
+type CWEMitigation struct {
+	Id                 string   `json:"id,omitempty"`
+	Phases             []string `json:"phases"`
+	Strategy           string   `json:"strategy,omitempty"`
+	Description        string   `json:"description"`
+	Effectiveness      string   `json:"effectiveness,omitempty"`
+	EffectivenessNotes string   `json:"effectiveness_notes,omitempty"`
+}
+
+// CWEDetectionMethod is a method detecting a weakness.
+type CWEDetectionMethod struct {
+	Id                 string `json:"id,omitempty"`
+	Method             string `json:"method"`
+	Description        string `json:"description"`
+	Effectiveness      string `json:"effectiveness,omitempty"`
+	EffectivenessNotes string `json:"effectiveness_notes,omitempty"`
+}
+
+// CWEDemonstrativeExample is an example of a weakness, made of text and code parts in the
+// order MITRE publishes them.
+type CWEDemonstrativeExample struct {
+	Id    string           `json:"id,omitempty"`
+	Parts []CWEExamplePart `json:"parts"`
+}
+
+// Types of the parts of a demonstrative example.
+const (
+	CWEExampleText = "text"
+	CWEExampleCode = "code"
+)
+
+// CWEExamplePart is a part of a demonstrative example. Code parts have a nature (Bad, Good,
+// Attack, ...) and usually a language, their content keeps its lines and indentation.
+type CWEExamplePart struct {
+	Type     string `json:"type"`
+	Nature   string `json:"nature,omitempty"`
+	Language string `json:"language,omitempty"`
+	Content  string `json:"content"`
+}
@@ -0,0 +1,16 @@
+package types
+
+// Types of the entries of the cwe table.
+const (
+	CWEEntryWeakness = "Weakness"
+	CWEEntryCategory = "Category"
+)
+
+// CWEStatus is the lifecycle of an entry of the cwe table, stored in the columns this service
+// adds to the shared table. A deprecated entry points at the entries replacing it, if any.
+type CWEStatus struct {
+	CWEId      string   `bun:"cwe_id" json:"cwe_id"`
+	EntryType  string   `bun:"entry_type" json:"entry_type"`
+	Deprecated bool     `bun:"deprecated" json:"deprecated"`
+	ReplacedBy []string `bun:"replaced_by,array" json:"replaced_by"`
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Well-known CWE views. Ids are stored without their CWE- prefix, like the ids of the cwe table.
+const (
+	CWEResearchView    = "1000"
+	CWEDevelopmentView = "699"
+)
+
+// CWEView is a view of the CWE catalog: a hierarchy (research, development) or a list of
+// entries (Top 25, OWASP Top Ten, ...). Members are the direct members of the view.
+// https://cwe.mitre.org/data/definitions/1000.html
+type CWEView struct {
+	bun.BaseModel `bun:"table:cwe_view,alias:cv"`
+	Id            uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	ViewId        string    `bun:"view_id,unique,notnull" json:"view_id"`
+	Name          string    `bun:"name" json:"name"`
+	Type          string    `bun:"type" json:"type"`
+	Status        string    `bun:"status" json:"status"`
+	Objective     string    `bun:"objective" json:"objective"`
+	Members       []string  `bun:"members,array" json:"members"`
+}
+
+// CWERelationship is an edge of the hierarchy of a view, from a parent to one of its children.
+// The roots of a view are the children of the view itself. Nature is ChildOf for weaknesses
+// and HasMember for the members of categories and views.
+type CWERelationship struct {
+	bun.BaseModel `bun:"table:cwe_relationship,alias:cr"`
+	ViewId        string `bun:"view_id,pk" json:"view_id"`
+	ParentId      string `bun:"parent_id,pk" json:"parent_id"`
+	ChildId       string `bun:"child_id,pk" json:"child_id"`
+	Nature        string `bun:"nature" json:"nature"`
+}
+
+// CWEHierarchyNode is an ancestor or a descendant of a CWE in a view, Depth edges away from it.
+type CWEHierarchyNode struct {
+	CWEId string `bun:"cwe_id" json:"cwe_id"`
+	Name  string `bun:"name" json:"name"`
+	Depth int    `bun:"depth" json:"depth"`
+}
@@ -0,0 +1,27 @@
+package types
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// EPSSHistory is the EPSS score of a CVE on a given day.
+// https://www.first.org/epss/data_stats
+type EPSSHistory struct {
+	bun.BaseModel `bun:"table:epss_history,alias:eh"`
+	CVE           string    `bun:"cve,pk" json:"cve"`
+	ScoreDate     time.Time `bun:"score_date,pk,type:date" json:"score_date"`
+	Score         float32   `bun:"score,notnull" json:"score"`
+	Percentile    float32   `bun:"percentile,notnull" json:"percentile"`
+	ModelVersion  string    `bun:"model_version" json:"model_version"`
+}
+
+// EPSSSpike is a CVE whose EPSS score rose sharply between two score dates.
+type EPSSSpike struct {
+	CVE           string    `bun:"cve" json:"cve"`
+	PreviousScore float32   `bun:"previous_score" json:"previous_score"`
+	CurrentScore  float32   `bun:"current_score" json:"current_score"`
+	PreviousDate  time.Time `bun:"previous_date" json:"previous_date"`
+	CurrentDate   time.Time `bun:"current_date" json:"current_date"`
+}
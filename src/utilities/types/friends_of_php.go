@@ -0,0 +1,8 @@
+package types
+
+// FriendsOfPHPSourceIds are the ids of a FriendsOfPHP advisory at its original sources (e.g. its
+// GHSA id), stored in the column this service adds to the shared friends_of_php table.
+type FriendsOfPHPSourceIds struct {
+	AdvisoryId string   `bun:"advisory_id" json:"advisory_id"`
+	SourceIds  []string `bun:"source_ids,array" json:"source_ids"`
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// GCVESSVC is the SSVC decision and KEV marker published by an ADP (e.g. CISA-ADP vulnrichment)
+// in the "other" metrics of a CVE record. Values are lowercased as published, e.g. exploitation
+// is one of "none", "poc" or "active".
+// https://www.cisa.gov/stakeholder-specific-vulnerability-categorization-ssvc
+type GCVESSVC struct {
+	bun.BaseModel   `bun:"table:gcve_ssvc,alias:gs"`
+	Id              uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	GCVEId          string    `bun:"gcve_id,notnull,unique:gcve_ssvc_gcve_id_provider_key" json:"gcve_id"`
+	CVEId           string    `bun:"cve_id" json:"cve_id"`
+	Provider        string    `bun:"provider,notnull,unique:gcve_ssvc_gcve_id_provider_key" json:"provider"`
+	Role            string    `bun:"role" json:"role"`
+	Version         string    `bun:"version" json:"version"`
+	Exploitation    string    `bun:"exploitation" json:"exploitation"`
+	Automatable     string    `bun:"automatable" json:"automatable"`
+	TechnicalImpact string    `bun:"technical_impact" json:"technical_impact"`
+	AssessedAt      time.Time `bun:"assessed_at,type:timestamptz,nullzero" json:"assessed_at"`
+	KEV             bool      `bun:"kev,notnull" json:"kev"`
+	KEVDateAdded    time.Time `bun:"kev_date_added,type:date,nullzero" json:"kev_date_added"`
+	KEVReference    string    `bun:"kev_reference" json:"kev_reference"`
+}
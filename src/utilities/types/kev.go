@@ -0,0 +1,27 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// KEVEntry is an entry of the CISA Known Exploited Vulnerabilities catalog.
+// https://www.cisa.gov/known-exploited-vulnerabilities-catalog
+type KEVEntry struct {
+	bun.BaseModel              `bun:"table:kev,alias:kev"`
+	Id                         uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	CVEId                      string    `bun:"cve_id,unique,notnull" json:"cveID"`
+	VendorProject              string    `bun:"vendor_project" json:"vendorProject"`
+	Product                    string    `bun:"product" json:"product"`
+	VulnerabilityName          string    `bun:"vulnerability_name" json:"vulnerabilityName"`
+	DateAdded                  time.Time `bun:"date_added,type:date" json:"dateAdded"`
+	ShortDescription           string    `bun:"short_description" json:"shortDescription"`
+	RequiredAction             string    `bun:"required_action" json:"requiredAction"`
+	DueDate                    time.Time `bun:"due_date,type:date,nullzero" json:"dueDate"`
+	KnownRansomwareCampaignUse bool      `bun:"known_ransomware_campaign_use" json:"knownRansomwareCampaignUse"`
+	Notes                      string    `bun:"notes" json:"notes"`
+	Cwes                       []string  `bun:"cwes,array" json:"cwes"`
+	CatalogVersion             string    `bun:"catalog_version" json:"catalogVersion"`
+}
@@ -4,3 +4,9 @@ type LinkLicensePackage struct {
 	FromKey    string `json:"packageKey"`
 	LicenseKey string `json:"licenseKey"`
 }
+
+// UnresolvedLicense is a license string of packages that could not be normalized into an SPDX expression.
+type UnresolvedLicense struct {
+	License  string `bun:"license" json:"license"`
+	Packages int    `bun:"packages" json:"packages"`
+}
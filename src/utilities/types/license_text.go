@@ -0,0 +1,42 @@
+package types
+
+import (
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// LicenseHeader is the standard header of an SPDX license, the notice to put in the source files.
+// It is stored in the columns this service adds to the shared licenses table.
+type LicenseHeader struct {
+	LicenseID      string `bun:"licenseId" json:"licenseId"`
+	Header         string `bun:"standard_license_header" json:"standardLicenseHeader"`
+	HeaderTemplate string `bun:"standard_license_header_template" json:"standardLicenseHeaderTemplate"`
+}
+
+// LicenseException is an exception of the SPDX license list, the additional permission
+// granted by "GPL-2.0-only WITH Classpath-exception-2.0".
+// https://spdx.org/licenses/exceptions-index.html
+type LicenseException struct {
+	bun.BaseModel `bun:"table:license_exception,alias:le"`
+	Id            uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	ExceptionID   string    `bun:"exception_id,unique" json:"licenseExceptionId"`
+	Name          string    `bun:"name" json:"name"`
+	Reference     string    `bun:"reference" json:"reference"`
+	Deprecated    bool      `bun:"deprecated" json:"isDeprecatedLicenseId"`
+	SeeAlso       []string  `bun:"see_also,array" json:"seeAlso"`
+	Text          string    `bun:"text" json:"licenseExceptionText"`
+	TextHTML      string    `bun:"text_html" json:"exceptionTextHtml"`
+	Template      string    `bun:"template" json:"licenseExceptionTemplate"`
+	Comments      string    `bun:"comments" json:"licenseComments"`
+	ListVersion   string    `bun:"list_version" json:"licenseListVersion"`
+}
+
+// LicenseText is the text of a license or of an exception, to be shown offline.
+type LicenseText struct {
+	ID        string   `bun:"id" json:"id"`
+	Name      string   `bun:"name" json:"name"`
+	Exception bool     `bun:"exception" json:"exception"`
+	Text      string   `bun:"text" json:"text"`
+	Header    string   `bun:"header" json:"header,omitempty"`
+	SeeAlso   []string `bun:"see_also,array" json:"seeAlso"`
+}
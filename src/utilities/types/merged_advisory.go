@@ -0,0 +1,43 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// MergedAdvisory is the view of a vulnerability merged from all the sources describing it.
+// Every field records the source it was taken from, picked by a configurable precedence.
+// Affected keeps the shape of its source: OSV affected entries or GCVE affected products.
+// The EPSS score is rescored daily, it isn't stored but joined from epss_latest when read.
+type MergedAdvisory struct {
+	bun.BaseModel     `bun:"table:merged_advisory,alias:ma"`
+	VulnerabilityId   uuid.UUID       `bun:"vulnerability_id,pk,type:uuid" json:"vulnerability_id"`
+	CanonicalId       string          `bun:"canonical_id,notnull" json:"canonical_id"`
+	Aliases           []string        `bun:"aliases,array" json:"aliases"`
+	Summary           string          `bun:"summary" json:"summary"`
+	SummarySource     string          `bun:"summary_source" json:"summary_source"`
+	Description       string          `bun:"description" json:"description"`
+	DescriptionSource string          `bun:"description_source" json:"description_source"`
+	Severity          string          `bun:"severity" json:"severity"`
+	BaseScore         *float64        `bun:"base_score" json:"base_score"`
+	CVSSVersion       string          `bun:"cvss_version" json:"cvss_version"`
+	CVSSVector        string          `bun:"cvss_vector" json:"cvss_vector"`
+	SeveritySource    string          `bun:"severity_source" json:"severity_source"`
+	SeverityProvider  string          `bun:"severity_provider" json:"severity_provider"`
+	Cwes              []string        `bun:"cwes,array" json:"cwes"`
+	CwesSource        string          `bun:"cwes_source" json:"cwes_source"`
+	References        []string        `bun:"reference_urls,array" json:"references"`
+	ReferencesSource  string          `bun:"references_source" json:"references_source"`
+	Affected          json.RawMessage `bun:"affected,type:jsonb" json:"affected"`
+	AffectedSource    string          `bun:"affected_source" json:"affected_source"`
+	EPSSScore         *float64        `bun:"epss_score,scanonly" json:"epss_score"`
+	EPSSPercentile    *float64        `bun:"epss_percentile,scanonly" json:"epss_percentile"`
+	EPSSSource        string          `bun:"epss_source" json:"epss_source"`
+	KEV               bool            `bun:"kev,notnull" json:"kev"`
+	// Fingerprint is a hash of the merged fields, the row is only rewritten when it changes
+	Fingerprint string    `bun:"fingerprint" json:"-"`
+	UpdatedAt   time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
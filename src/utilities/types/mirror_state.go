@@ -0,0 +1,17 @@
+package types
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MirrorState stores the synchronisation cursor of a mirror that has no
+// dedicated column in the shared config table.
+type MirrorState struct {
+	bun.BaseModel `bun:"table:mirror_state,alias:ms"`
+	Name          string    `bun:"name,pk"`
+	LastSync      time.Time `bun:"last_sync,type:timestamptz"`
+	Cursor        string    `bun:"cursor"`
+	UpdatedAt     time.Time `bun:"updated_at,type:timestamptz,nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// Vulnerability is a real-world vulnerability, whatever the sources describing it.
+// It's known by the canonical identifier of its aliases, a CVE id when it has one.
+type Vulnerability struct {
+	bun.BaseModel `bun:"table:vulnerability,alias:v"`
+	Id            uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	CanonicalId   string    `bun:"canonical_id,unique,notnull" json:"canonical_id"`
+	Aliases       []string  `bun:"aliases,array" json:"aliases"`
+	UpdatedAt     time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}
+
+// VulnerabilityAlias is an identifier of a vulnerability. Aliases of an advisory stored by a source
+// link to it with the source and the id of the record (osv.id, nvd.id, gcve.id or friends_of_php.id),
+// aliases only known through other advisories have neither.
+type VulnerabilityAlias struct {
+	bun.BaseModel   `bun:"table:vulnerability_alias,alias:va"`
+	Id              uuid.UUID  `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	VulnerabilityId uuid.UUID  `bun:"vulnerability_id,type:uuid,notnull" json:"vulnerability_id"`
+	Alias           string     `bun:"alias,notnull" json:"alias"`
+	Source          string     `bun:"source,notnull" json:"source"`
+	RecordId        *uuid.UUID `bun:"record_id,type:uuid" json:"record_id"`
+}
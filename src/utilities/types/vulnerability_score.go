@@ -0,0 +1,24 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// VulnerabilityScore is the normalized severity of an advisory, whatever the source it comes from.
+// Advisories only rated qualitatively (e.g. "high") have no version, vector nor base score.
+type VulnerabilityScore struct {
+	bun.BaseModel   `bun:"table:vulnerability_score,alias:vs"`
+	Id              uuid.UUID `bun:",pk,autoincrement,type:uuid,default:uuid_generate_v4()"`
+	Source          string    `bun:"source,notnull" json:"source"`
+	VulnerabilityId string    `bun:"vulnerability_id,notnull" json:"vulnerability_id"`
+	Provider        string    `bun:"provider,notnull" json:"provider"`
+	Version         string    `bun:"version,notnull" json:"version"`
+	Vector          string    `bun:"vector,notnull" json:"vector"`
+	BaseScore       *float64  `bun:"base_score" json:"base_score"`
+	Severity        string    `bun:"severity" json:"severity"`
+	Computed        bool      `bun:"computed,notnull" json:"computed"`
+	UpdatedAt       time.Time `bun:"updated_at,nullzero,notnull,default:current_timestamp" json:"updated_at"`
+}